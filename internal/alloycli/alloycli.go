@@ -26,7 +26,10 @@ func Run() {
 	cmd.AddCommand(
 		convertCommand(),
 		fmtCommand(),
+		modCommand(),
+		planCommand(),
 		runCommand(),
+		testCommand(),
 		toolsCommand(),
 		validateCommand(),
 	)
@@ -39,6 +39,9 @@ type ClusterOptions struct {
 	TLSCertPath            string
 	TLSKeyPath             string
 	TLSServerName          string
+	NodeWeight             int
+	NodeTopology           map[string]string
+	TopologyAwareLabel     string
 }
 
 func buildClusterService(opts ClusterOptions) (*cluster.Service, error) {
@@ -70,6 +73,9 @@ func NewClusterService(
 		TLSCertPath:            opts.TLSCertPath,
 		TLSKeyPath:             opts.TLSKeyPath,
 		TLSServerName:          opts.TLSServerName,
+		NodeWeight:             opts.NodeWeight,
+		NodeTopology:           opts.NodeTopology,
+		TopologyAwareLabel:     opts.TopologyAwareLabel,
 	}
 
 	if config.NodeName == "" {
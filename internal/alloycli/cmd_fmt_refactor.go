@@ -0,0 +1,160 @@
+package alloycli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+
+	"github.com/grafana/alloy/internal/refactor"
+	"github.com/grafana/alloy/syntax/diag"
+)
+
+func fmtRenameCommand() *cobra.Command {
+	var write bool
+
+	cmd := &cobra.Command{
+		Use:   "rename [flags] path component_id new_label",
+		Short: "Rename a component",
+		Long: `The rename subcommand changes the label of a component declared in
+the configuration at the specified path, and updates the references to the
+component. The path can be a file or a directory of .alloy files.
+
+The -w flag can be used to write the files which changed back to disk. When -w
+is not provided, rename will write the files which changed to stdout.`,
+		Args:         cobra.ExactArgs(3),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			path, id, label := args[0], args[1], args[2]
+
+			files, err := loadSourceFiles(path, "alloy", false, "")
+			if err != nil {
+				return err
+			}
+			out, err := refactor.Rename(files, id, label)
+			if err != nil {
+				return refactorError(err)
+			}
+			return writeRefactored(out, write)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&write, "write", "w", write, "write result to (source) file instead of stdout")
+	return cmd
+}
+
+func fmtExtractCommand() *cobra.Command {
+	var (
+		write bool
+		opts  refactor.ExtractOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "extract [flags] path component_id...",
+		Short: "Move components into a custom component",
+		Long: `The extract subcommand moves components declared in the configuration
+at the specified path into a declare block, and replaces them with an instance
+of the declared custom component. The path can be a file or a directory of
+.alloy files. The components must be declared in the same file.
+
+References made by the extracted components to other components become
+arguments of the custom component, and references made by other components to
+the extracted components become exports of the custom component.
+
+The --module flag can be used to declare the custom component in a separate
+module file, imported with an import.file block whose filename is relative to
+the module_path of the configuration. --module requires -w.
+
+The -w flag can be used to write the files which changed back to disk. When -w
+is not provided, extract will write the files which changed to stdout.`,
+		Args:         cobra.MinimumNArgs(2),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := args[0]
+			opts.Components = args[1:]
+
+			if opts.ModulePath != "" {
+				if !write {
+					return fmt.Errorf("--module can only be used with -w/--write")
+				}
+				if opts.ModuleNamespace == "" {
+					opts.ModuleNamespace = strings.TrimSuffix(filepath.Base(opts.ModulePath), filepath.Ext(opts.ModulePath))
+				}
+				if _, err := os.Stat(opts.ModulePath); err == nil {
+					return fmt.Errorf("module file %s already exists", opts.ModulePath)
+				}
+			}
+
+			files, err := loadSourceFiles(path, "alloy", false, "")
+			if err != nil {
+				return err
+			}
+			res, err := refactor.Extract(files, opts)
+			if err != nil {
+				return refactorError(err)
+			}
+
+			if res.Module != nil {
+				if err := os.WriteFile(opts.ModulePath, res.Module, 0o644); err != nil {
+					return err
+				}
+			}
+			return writeRefactored(res.Files, write)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&write, "write", "w", write, "write result to (source) file instead of stdout")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "name of the declared custom component")
+	cmd.Flags().StringVar(&opts.Label, "label", "default", "label of the instance of the custom component")
+	cmd.Flags().StringVar(&opts.ModulePath, "module", "", "path of a new module file declaring the custom component")
+	cmd.Flags().StringVar(&opts.ModuleNamespace, "module.namespace", "", "label of the import.file block importing the module, defaults to the name of the module file")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+// refactorError prints the diagnostics of err to stderr.
+func refactorError(err error) error {
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) {
+		for _, diag := range diags {
+			fmt.Fprintln(os.Stderr, diag)
+		}
+		return fmt.Errorf("encountered errors during refactoring")
+	}
+	return err
+}
+
+// writeRefactored writes the files which changed back to disk, or to stdout
+// when write is false. Files written to stdout are preceded by a comment
+// holding their name when there are more than one.
+func writeRefactored(files map[string][]byte, write bool) error {
+	names := maps.Keys(files)
+	slices.Sort(names)
+	for _, name := range names {
+		if !write {
+			if len(names) > 1 {
+				fmt.Fprintf(os.Stdout, "// %s\n", name)
+			}
+			if _, err := os.Stdout.Write(files[name]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, files[name], fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package alloycli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+func modCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mod",
+		Short: "Manage the modules imported with a version constraint",
+		Long: `The mod command manages the lock file of the modules imported by
+import.git blocks with a version constraint.`,
+	}
+
+	cmd.AddCommand(
+		modUpdateCommand(),
+		modVendorCommand(),
+	)
+
+	return cmd
+}
+
+func modUpdateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [flags] path",
+		Short: "Resolve the version constraints of module imports and update the lock file",
+		Long: `The update subcommand resolves the version constraints of the import.git
+blocks of the configuration at path against the tags of their repository,
+and records the resolved commits and the hashes of the modules in the
+alloy.lock file next to the configuration.
+
+Modules in the lock file are loaded at the locked commit until the lock file
+is updated again.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return modUpdate(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+func modVendorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "vendor [flags] path",
+		Short: "Copy the locked modules to the vendor directory",
+		Long: `The vendor subcommand copies the repositories of the modules locked in
+the alloy.lock file at their locked commit to the vendor directory next to
+the lock file.
+
+Vendored modules are loaded from the vendor directory without accessing
+their repository, which allows running on hosts without network access.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return modVendor(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+func modUpdate(ctx context.Context, w io.Writer, configPath string) error {
+	imports, errs, err := versionedGitImports(configPath)
+	if err != nil {
+		return err
+	}
+
+	lockFile := importsource.LockFilePath(configPath)
+	prev, err := importsource.ReadLock(lockFile)
+	if err != nil {
+		return err
+	}
+
+	// The lock file is rebuilt so that imports removed from the
+	// configuration are removed from the lock file. When some blocks can't be
+	// evaluated, the modules they import aren't known, so the previously
+	// locked modules are kept.
+	lock := &importsource.Lock{}
+	if len(errs) > 0 {
+		lock.Modules = slices.Clone(prev.Modules)
+	}
+	for _, args := range imports {
+		m, err := withTempDir(func(dir string) (importsource.LockedModule, error) {
+			return importsource.LockGitModule(ctx, dir, args)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolving %s from %s: %w", args.Path, args.Repository, err))
+			if m, ok := prev.Find(args.Repository, args.Path, args.Version); ok {
+				lock.Set(m)
+			}
+			continue
+		}
+		lock.Set(m)
+		fmt.Fprintf(w, "%s %s %s: %s (%s)\n", m.Repository, m.Path, m.Version, m.Tag, m.Commit)
+	}
+
+	if err := lock.Write(lockFile); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func modVendor(ctx context.Context, w io.Writer, configPath string) error {
+	imports, errs, err := versionedGitImports(configPath)
+	if err != nil {
+		return err
+	}
+
+	lockFile := importsource.LockFilePath(configPath)
+	lock, err := importsource.ReadLock(lockFile)
+	if err != nil {
+		return err
+	}
+
+	for _, args := range imports {
+		m, ok := lock.Find(args.Repository, args.Path, args.Version)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s from %s isn't in the lock file, run `alloy mod update` first", args.Path, args.Repository))
+			continue
+		}
+		_, err := withTempDir(func(dir string) (struct{}, error) {
+			return struct{}{}, importsource.VendorGitModule(ctx, dir, lockFile, m, args.GitAuthConfig)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("vendoring %s from %s: %w", args.Path, args.Repository, err))
+			continue
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", m.Repository, m.Path, importsource.VendorPath(lockFile, m))
+	}
+	return errors.Join(errs...)
+}
+
+// modImportArguments are the arguments of an import.git block identifying
+// the module it imports and how it's verified.
+type modImportArguments struct {
+	Repository string                           `alloy:"repository,attr"`
+	Path       string                           `alloy:"path,attr"`
+	Version    string                           `alloy:"version,attr"`
+	Verify     *importsource.GitVerifyArguments `alloy:"verify,block,optional"`
+}
+
+// versionedGitImports returns the arguments of the import.git blocks with a
+// version constraint in the configuration at configPath. Blocks which can't
+// be evaluated are reported in errs, and err is only set if the configuration
+// can't be read.
+//
+// Only the repository, path and version attributes and the verify block of
+// the blocks must be evaluated without the rest of the configuration, so that
+// modules are never locked without being verified. The other arguments, such
+// as authentication, are used when they can be evaluated as well, and are
+// ignored when they refer to components.
+func versionedGitImports(configPath string) (imports []importsource.GitArguments, errs []error, err error) {
+	sources, err := loadSourceFiles(configPath, "alloy", false, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(sources)) {
+		file, err := parser.ParseFile(name, sources[name])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, block := range gitImportBlocks(file.Body) {
+			if !hasAttribute(block.Body, "version") {
+				continue
+			}
+
+			var mod modImportArguments
+			body := statements(block.Body, "repository", "path", "version", "verify")
+			if err := vm.New(body).Evaluate(vm.NewScope(nil), &mod); err != nil {
+				errs = append(errs, fmt.Errorf("%s: evaluating %s: %w", ast.StartPos(block).Position(), block.GetBlockName(), err))
+				continue
+			}
+
+			args := importsource.GitArguments{Repository: mod.Repository, Path: mod.Path, Version: mod.Version, Verify: mod.Verify}
+			var full importsource.GitArguments
+			if err := vm.New(block.Body).Evaluate(vm.NewScope(nil), &full); err == nil {
+				args = full
+			} else if err := args.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: evaluating %s: %w", ast.StartPos(block).Position(), block.GetBlockName(), err))
+				continue
+			}
+			imports = append(imports, args)
+		}
+	}
+	return imports, errs, nil
+}
+
+// hasAttribute returns true if body sets the attribute name.
+func hasAttribute(body ast.Body, name string) bool {
+	return slices.ContainsFunc(body, func(stmt ast.Stmt) bool {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		return ok && attr.Name.Name == name
+	})
+}
+
+// statements returns the attributes and blocks of body named one of names.
+func statements(body ast.Body, names ...string) ast.Body {
+	var res ast.Body
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if slices.Contains(names, stmt.Name.Name) {
+				res = append(res, stmt)
+			}
+		case *ast.BlockStmt:
+			if slices.Contains(names, stmt.GetBlockName()) {
+				res = append(res, stmt)
+			}
+		}
+	}
+	return res
+}
+
+// gitImportBlocks returns the import.git blocks of body, including the ones
+// inside declare blocks.
+func gitImportBlocks(body ast.Body) []*ast.BlockStmt {
+	var blocks []*ast.BlockStmt
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+		switch block.GetBlockName() {
+		case importsource.BlockNameGit:
+			blocks = append(blocks, block)
+		case "declare":
+			blocks = append(blocks, gitImportBlocks(block.Body)...)
+		}
+	}
+	return blocks
+}
+
+// withTempDir calls f with a temporary directory, which is removed when f
+// returns.
+func withTempDir[T any](f func(dir string) (T, error)) (T, error) {
+	dir, err := os.MkdirTemp("", "alloy-mod-")
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer os.RemoveAll(dir)
+	return f(dir)
+}
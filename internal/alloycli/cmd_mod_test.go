@@ -0,0 +1,86 @@
+package alloycli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
+)
+
+func TestVersionedGitImports(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "imports.alloy"), []byte(`
+		import.git "authenticated" {
+			repository = "https://example.com/a.git"
+			path       = "a.alloy"
+			version    = "~> 1.0"
+
+			basic_auth {
+				username = "user"
+				password = local.file.password.content
+			}
+		}
+
+		import.git "revision" {
+			repository = local.file.repository.content
+			path       = "b.alloy"
+			revision   = "main"
+		}
+
+		import.git "unresolvable" {
+			repository = local.file.repository.content
+			path       = "c.alloy"
+			version    = "~> 1.0"
+		}
+
+		declare "nested" {
+			import.git "plain" {
+				repository = "https://example.com/d.git"
+				path       = "d.alloy"
+				version    = ">= 1.2, < 2.0"
+			}
+		}
+
+		import.git "verified" {
+			repository = "https://example.com/e.git"
+			path       = "e.alloy"
+			version    = "~> 1.0"
+
+			basic_auth {
+				username = "user"
+				password = local.file.password.content
+			}
+
+			verify {
+				sha256 = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+			}
+		}
+
+		import.git "unverifiable" {
+			repository = "https://example.com/f.git"
+			path       = "f.alloy"
+			version    = "~> 1.0"
+
+			verify {
+				gpg_keyring = local.file.keys.content
+			}
+		}
+	`), 0o644))
+
+	imports, errs, err := versionedGitImports(dir)
+	require.NoError(t, err)
+	require.Equal(t, []importsource.GitArguments{
+		{Repository: "https://example.com/a.git", Path: "a.alloy", Version: "~> 1.0"},
+		{Repository: "https://example.com/d.git", Path: "d.alloy", Version: ">= 1.2, < 2.0", PullFrequency: importsource.DefaultGitArguments.PullFrequency},
+		{Repository: "https://example.com/e.git", Path: "e.alloy", Version: "~> 1.0", Verify: &importsource.GitVerifyArguments{
+			SHA256: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		}},
+	}, imports)
+	require.Len(t, errs, 2)
+	require.ErrorContains(t, errs[0], "imports.alloy:19:3: evaluating import.git")
+	// Modules are never locked without their verify block.
+	require.ErrorContains(t, errs[1], "imports.alloy:48:3: evaluating import.git")
+}
@@ -0,0 +1,158 @@
+package alloycli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/pipelinetest"
+	"github.com/spf13/cobra"
+)
+
+func testCommand() *cobra.Command {
+	t := &alloyTest{
+		timeout:      10 * time.Second,
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "test [flags] path...",
+		Short: "Run unit tests of configurations",
+		Long: `The test command runs the tests declared in test files against the
+configurations they refer to.
+
+Each path can be a test file or a directory. Test files are found in
+directories by their ` + pipelinetest.FileExtension + ` extension, ignoring nested directories.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.Run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&t.run, "run", t.run, "Only run the tests whose name matches the regular expression")
+	cmd.Flags().DurationVar(&t.timeout, "timeout", t.timeout, "Maximum duration of each test")
+	cmd.Flags().Var(&t.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&t.enableCommunityComps, "feature.community-components.enabled", t.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyTest struct {
+	run     string
+	timeout time.Duration
+
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (t *alloyTest) Run(cmd *cobra.Command, paths []string) error {
+	filter, err := regexp.Compile(t.run)
+	if err != nil {
+		return fmt.Errorf("invalid --run expression: %w", err)
+	}
+
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	opts := pipelinetest.Options{
+		MinStability:         t.minStability,
+		EnableCommunityComps: t.enableCommunityComps,
+		Timeout:              t.timeout,
+	}
+
+	w := cmd.OutOrStdout()
+	failed := false
+	for _, path := range files {
+		if !runTestFile(cmd, w, path, filter, opts) {
+			failed = true
+		}
+	}
+
+	if failed {
+		fmt.Fprintln(w, "FAIL")
+		return errors.New("tests failed")
+	}
+	fmt.Fprintln(w, "PASS")
+	return nil
+}
+
+// runTestFile runs the tests of the test file at path whose name matches
+// filter, and returns true if they all pass.
+func runTestFile(cmd *cobra.Command, w io.Writer, path string, filter *regexp.Regexp, opts pipelinetest.Options) bool {
+	fmt.Fprintf(w, "=== %s\n", path)
+
+	f, err := pipelinetest.LoadFile(path)
+	if err != nil {
+		fmt.Fprintf(w, "    %s\n", err)
+		return false
+	}
+	sources, err := loadSourceFiles(f.ConfigPath(), "alloy", false, "")
+	if err != nil {
+		fmt.Fprintf(w, "    reading configuration: %s\n", err)
+		return false
+	}
+
+	passed := true
+	for _, test := range f.Tests {
+		if !filter.MatchString(test.Name) {
+			continue
+		}
+
+		res := pipelinetest.Run(cmd.Context(), f.ConfigPath(), sources, test, opts)
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+			passed = false
+		}
+		fmt.Fprintf(w, "--- %s: %s (%.2fs)\n", status, res.Name, res.Duration.Seconds())
+		if res.Err != nil {
+			fmt.Fprintf(w, "    %s\n", res.Err)
+		}
+		for _, failure := range res.Failures {
+			fmt.Fprintf(w, "    %s\n", failure)
+		}
+	}
+	return passed
+}
+
+// findTestFiles returns the test files found in paths.
+func findTestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), pipelinetest.FileExtension) {
+				found = append(found, filepath.Join(path, e.Name()))
+			}
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no %s files found in %s", pipelinetest.FileExtension, path)
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
@@ -0,0 +1,103 @@
+package alloycli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+func planCommand() *cobra.Command {
+	p := &alloyPlan{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "plan [flags] old new",
+		Short: "Show the changes a configuration reload would make",
+		Long: `The plan command compares two configurations and shows which components
+would be added, removed or updated with changed arguments when reloading from
+the old configuration to the new one.
+
+Each configuration can be a file or a directory of .alloy files. Arguments
+referencing other components can't be evaluated without running the
+configuration; components with such arguments are reported as updated when
+their blocks differ.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.Run(cmd.OutOrStdout(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().Var(&p.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&p.enableCommunityComps, "feature.community-components.enabled", p.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyPlan struct {
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (p *alloyPlan) Run(w io.Writer, oldPath, newPath string) error {
+	oldSource, err := loadPlanSource(oldPath)
+	if err != nil {
+		return err
+	}
+	newSource, err := loadPlanSource(newPath)
+	if err != nil {
+		return err
+	}
+
+	registry := component.NewDefaultRegistry(p.minStability, p.enableCommunityComps)
+	printPlan(w, runtime.PlanSources(oldSource, newSource, registry))
+	return nil
+}
+
+func loadPlanSource(path string) (*runtime.Source, error) {
+	sources, err := loadSourceFiles(path, "alloy", false, "")
+	if err != nil {
+		return nil, err
+	}
+	source, err := runtime.ParseSources(sources)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return source, nil
+}
+
+// printPlan prints the components changed by plan, followed by a summary.
+func printPlan(w io.Writer, plan *runtime.Plan) {
+	symbols := map[runtime.ChangeAction]string{
+		runtime.ChangeAdded:   "+",
+		runtime.ChangeRemoved: "-",
+		runtime.ChangeUpdated: "~",
+	}
+	for _, c := range plan.Components {
+		symbol, ok := symbols[c.Action]
+		if !ok {
+			continue
+		}
+		if c.Reason != "" {
+			fmt.Fprintf(w, "%s %s (%s)\n", symbol, c.ID, c.Reason)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", symbol, c.ID)
+		}
+	}
+
+	if plan.HasChanges() {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d to add, %d to update, %d to remove, %d unchanged.\n",
+		plan.Count(runtime.ChangeAdded),
+		plan.Count(runtime.ChangeUpdated),
+		plan.Count(runtime.ChangeRemoved),
+		plan.Count(runtime.ChangeUnchanged),
+	)
+}
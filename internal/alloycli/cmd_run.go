@@ -34,9 +34,11 @@ import (
 	"github.com/grafana/alloy/internal/converter"
 	convert_diag "github.com/grafana/alloy/internal/converter/diag"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/resources"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/internal/service"
 	httpservice "github.com/grafana/alloy/internal/service/http"
@@ -72,11 +74,13 @@ func runCommand() *cobra.Command {
 		clusterAdvInterfaces:  advertise.DefaultInterfaces,
 		clusterMaxJoinPeers:   5,
 		clusterRejoinInterval: 60 * time.Second,
+		clusterNodeWeight:     1,
 		disableSupportBundle:  false,
 		// For backwards compatibility - use the LegacyValidation of Prometheus metrics name. This is a global variable
 		// setting that has changed upstream. See https://github.com/prometheus/common/pull/724.
 		prometheusMetricNameValidationScheme: prometheusLegacyMetricValidationScheme,
 		windowsPriority:                      windowspriority.PriorityNormal,
+		shutdownDrainTimeout:                 20 * time.Second,
 	}
 
 	cmd := &cobra.Command{
@@ -126,6 +130,8 @@ depending on the nature of the reload error.
 		BoolVar(&r.enablePprof, "server.http.enable-pprof", r.enablePprof, "Enable /debug/pprof profiling endpoints.")
 	cmd.Flags().
 		BoolVar(&r.disableSupportBundle, "server.http.disable-support-bundle", r.disableSupportBundle, "Disable /-/support support bundle retrieval.")
+	cmd.Flags().
+		BoolVar(&r.enablePlan, "server.http.enable-plan", r.enablePlan, "Enable the /api/v0/web/plan endpoint, which evaluates the posted configurations.")
 
 	// Cluster flags
 	cmd.Flags().
@@ -160,6 +166,12 @@ depending on the nature of the reload error.
 		IntVar(&r.clusterWaitForSize, "cluster.wait-for-size", r.clusterWaitForSize, "Wait for the cluster to reach the specified number of instances before allowing components that use clustering to begin processing. Zero means disabled")
 	cmd.Flags().
 		DurationVar(&r.clusterWaitTimeout, "cluster.wait-timeout", 0, "Maximum duration to wait for minimum cluster size before proceeding with available nodes. Zero means wait forever, no timeout")
+	cmd.Flags().
+		IntVar(&r.clusterNodeWeight, "cluster.node-weight", r.clusterNodeWeight, "Share of the work assigned to this node relative to other nodes")
+	cmd.Flags().
+		StringToStringVar(&r.clusterNodeTopology, "cluster.node-topology", r.clusterNodeTopology, "Comma-separated list of key=value labels describing where this node runs, such as its zone")
+	cmd.Flags().
+		StringVar(&r.clusterTopologyAwareLabel, "cluster.topology-aware-label", r.clusterTopologyAwareLabel, "Topology label used to assign targets with a label of the same name to nodes of the same topology")
 
 	// Config flags
 	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
@@ -170,8 +182,10 @@ depending on the nature of the reload error.
 	cmd.Flags().
 		BoolVar(&r.disableReporting, "disable-reporting", r.disableReporting, "Disable reporting of enabled components to Grafana.")
 	cmd.Flags().StringVar(&r.storagePath, "storage.path", r.storagePath, "Base directory where components can store data")
+	cmd.Flags().DurationVar(&r.shutdownDrainTimeout, "shutdown.drain-timeout", r.shutdownDrainTimeout, "Maximum duration of the drain of components on shutdown. Zero stops all components at once")
 	cmd.Flags().Var(&r.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
 	cmd.Flags().BoolVar(&r.enableCommunityComps, "feature.community-components.enabled", r.enableCommunityComps, "Enable community components.")
+	cmd.Flags().BoolVar(&r.enableComponentResources, "feature.component-resources.enabled", r.enableComponentResources, "Sample the resource usage of components from profiles of the process. This is an experimental feature.")
 	cmd.Flags().StringVar(&r.prometheusMetricNameValidationScheme, "feature.prometheus.metric-validation-scheme", prometheusLegacyMetricValidationScheme, fmt.Sprintf("Prometheus metric validation scheme to use. Supported values: %q, %q. NOTE: this is an experimental flag and may be removed in future releases.", prometheusLegacyMetricValidationScheme, prometheusUTF8MetricValidationScheme))
 	if runtime.GOOS == "windows" {
 		cmd.Flags().StringVar(&r.windowsPriority, "windows.priority", r.windowsPriority, fmt.Sprintf("Process priority to use when running on windows. This flag is currently in public preview. Supported values: %s", strings.Join(slices.Collect(windowspriority.PriorityValues()), ", ")))
@@ -188,6 +202,7 @@ type alloyRun struct {
 	minStability                         featuregate.Stability
 	uiPrefix                             string
 	enablePprof                          bool
+	enablePlan                           bool
 	disableReporting                     bool
 	clusterEnabled                       bool
 	clusterNodeName                      string
@@ -205,10 +220,15 @@ type alloyRun struct {
 	clusterTLSServerName                 string
 	clusterWaitForSize                   int
 	clusterWaitTimeout                   time.Duration
+	clusterNodeWeight                    int
+	clusterNodeTopology                  map[string]string
+	clusterTopologyAwareLabel            string
 	configFormat                         string
 	configBypassConversionErrors         bool
 	configExtraArgs                      string
 	enableCommunityComps                 bool
+	enableComponentResources             bool
+	shutdownDrainTimeout                 time.Duration
 	disableSupportBundle                 bool
 	prometheusMetricNameValidationScheme string
 	windowsPriority                      string
@@ -292,6 +312,22 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	reg := prometheus.DefaultRegisterer
 	reg.MustRegister(newResourcesCollector(l))
 
+	var resourceTracker *resources.Tracker
+	if fr.enableComponentResources {
+		if err := featuregate.CheckAllowed(
+			featuregate.StabilityExperimental,
+			fr.minStability,
+			"component resource tracking"); err != nil {
+			return err
+		}
+
+		opts := resources.DefaultOptions
+		opts.Logger = log.With(l, "service", "resources")
+		resourceTracker = resources.NewTracker(opts)
+		reg.MustRegister(resourceTracker)
+		go resourceTracker.Run(ctx)
+	}
+
 	// There's a cyclic dependency between the definition of the Alloy controller,
 	// the reload/ready functions, and the HTTP service.
 	//
@@ -324,6 +360,9 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		TLSServerName:          fr.clusterTLSServerName,
 		MinimumClusterSize:     fr.clusterWaitForSize,
 		MinimumSizeWaitTimeout: fr.clusterWaitTimeout,
+		NodeWeight:             fr.clusterNodeWeight,
+		NodeTopology:           fr.clusterNodeTopology,
+		TopologyAwareLabel:     fr.clusterTopologyAwareLabel,
 	})
 	if err != nil {
 		return err
@@ -373,6 +412,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		UIPrefix:        fr.uiPrefix,
 		CallbackManager: liveDebuggingService.Data().(livedebugging.CallbackManager),
 		Logger:          log.With(l, "service", "ui"),
+		EnablePlan:      fr.enablePlan,
 	})
 
 	otelService := otel_service.New(l)
@@ -390,6 +430,9 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		Reg:                  reg,
 		MinStability:         fr.minStability,
 		EnableCommunityComps: fr.enableCommunityComps,
+		ModuleLockFile:       importsource.LockFilePath(configPath),
+		ResourceTracker:      resourceTracker,
+		DrainTimeout:         fr.shutdownDrainTimeout,
 		Services: []service.Service{
 			clusterService,
 			httpService,
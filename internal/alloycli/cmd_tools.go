@@ -1,9 +1,21 @@
 package alloycli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/prometheus/remotewrite"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/lsp"
+	"github.com/grafana/alloy/internal/service/cluster"
+	"github.com/grafana/alloy/internal/service/http"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/otel"
+	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +28,8 @@ func toolsCommand() *cobra.Command {
 
 	cmd.AddCommand(
 		getTools("prometheus.remote_write", remotewrite.InstallTools),
+		lspCommand(),
+		schemaCommand(),
 	)
 
 	return cmd
@@ -29,3 +43,74 @@ func getTools(name string, installFunc func(*cobra.Command)) *cobra.Command {
 	installFunc(groupCommand)
 	return groupCommand
 }
+
+func lspCommand() *cobra.Command {
+	l := &alloyLSP{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lsp [flags]",
+		Short: "Run a language server for configuration files",
+		Long: `The lsp command runs a language server for Alloy configuration files,
+speaking the Language Server Protocol over stdin and stdout.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return l.Run(cmd)
+		},
+	}
+
+	cmd.Flags().Var(&l.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&l.enableCommunityComps, "feature.community-components.enabled", l.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyLSP struct {
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (l *alloyLSP) Run(cmd *cobra.Command) error {
+	server := lsp.New(lsp.Options{
+		ComponentRegistry: component.NewDefaultRegistry(l.minStability, l.enableCommunityComps),
+		ComponentNames:    component.AllNames(),
+		ServiceDefinitions: getServiceDefinitions(
+			&cluster.Service{},
+			&http.Service{},
+			&labelstore.Service{},
+			&otel.Service{},
+			&remotecfg.Service{},
+			&ui.Service{},
+		),
+		MinStability: l.minStability,
+	})
+	return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+}
+
+func schemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [component]",
+		Short: "Print the JSON Schema of components",
+		Long: `The schema command prints a JSON Schema describing the arguments of every
+registered component, keyed by component name. If a component name is given,
+only the schema of that component is printed.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			var schema any = component.AllSchemas()
+			if len(args) == 1 {
+				reg, ok := component.Get(args[0])
+				if !ok {
+					return fmt.Errorf("cannot find the definition of component name %q", args[0])
+				}
+				schema = reg.Schema()
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(schema)
+		},
+	}
+}
@@ -40,6 +40,7 @@ func validateCommand() *cobra.Command {
 	cmd.Flags().StringVar(&v.configFormat, "config.format", v.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
 	cmd.Flags().BoolVar(&v.configBypassConversionErrors, "config.bypass-conversion-errors", v.configBypassConversionErrors, "Enable bypassing errors when converting")
 	cmd.Flags().StringVar(&v.configExtraArgs, "config.extra-args", v.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	cmd.Flags().BoolVar(&v.strict, "strict", v.strict, "Type check the expressions referencing other components")
 
 	// Misc flags
 	cmd.Flags().Var(&v.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
@@ -52,6 +53,7 @@ type alloyValidate struct {
 	configFormat                 string
 	configBypassConversionErrors bool
 	configExtraArgs              string
+	strict                       bool
 
 	minStability         featuregate.Stability
 	enableCommunityComps bool
@@ -76,6 +78,7 @@ func (v *alloyValidate) Run(configFile string) error {
 			),
 			ComponentRegistry: component.NewDefaultRegistry(v.minStability, v.enableCommunityComps),
 			MinStability:      v.minStability,
+			Strict:            v.strict,
 		},
 	); err != nil {
 		validator.Report(os.Stderr, err, sources)
@@ -1,6 +1,7 @@
 package all
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -178,3 +179,14 @@ func initValue(rv reflect.Value) {
 		}
 	}
 }
+
+// TestSchema ensures that a JSON Schema can be generated for the arguments
+// and exports of every registered component.
+func TestSchema(t *testing.T) {
+	for name, schema := range component.AllSchemas() {
+		t.Run(name, func(t *testing.T) {
+			_, err := json.Marshal(schema)
+			require.NoError(t, err)
+		})
+	}
+}
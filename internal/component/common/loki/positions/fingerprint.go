@@ -0,0 +1,165 @@
+package positions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// removedFileRetention is how long the fingerprint of a file is kept after
+// its position is removed, so that the file can be resumed if it was renamed
+// to a path which is read later.
+const removedFileRetention = time.Hour
+
+// Fingerprint identifies the content of a file by a hash of its first bytes.
+// Unlike its path, the fingerprint of a file doesn't change when the file is
+// renamed, and changes when the file is replaced or truncated.
+type Fingerprint struct {
+	// Size is the number of bytes hashed. It's smaller than the configured
+	// fingerprint size if the file was smaller when it was fingerprinted.
+	Size   int    `yaml:"size"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// NewFingerprint returns the fingerprint of a file starting with head.
+func NewFingerprint(head []byte) Fingerprint {
+	sum := sha256.Sum256(head)
+	return Fingerprint{Size: len(head), SHA256: hex.EncodeToString(sum[:])}
+}
+
+// Matches returns whether a file starting with head can be the file which
+// had the fingerprint f, which might have grown since: head must start with
+// the bytes hashed in f.
+func (f Fingerprint) Matches(head []byte) bool {
+	if len(head) < f.Size {
+		return false
+	}
+	return NewFingerprint(head[:f.Size]) == f
+}
+
+// FingerprintRecord is the fingerprint of the file at a path in the
+// positions file.
+type FingerprintRecord struct {
+	Fingerprint Fingerprint `yaml:"fingerprint"`
+
+	// Previous is the file which was at the path before it was replaced, kept
+	// to resume reading it if it was renamed to a path which is read later.
+	Previous *PreviousFile `yaml:"previous,omitempty"`
+}
+
+// PreviousFile is a file which was replaced at its path, or whose position
+// was removed, with how far it was read.
+type PreviousFile struct {
+	Fingerprint Fingerprint `yaml:"fingerprint"`
+	Position    int64       `yaml:"position"`
+
+	// RemovedAt is when the position of the file was removed. The file is
+	// forgotten removedFileRetention after it.
+	RemovedAt time.Time `yaml:"removed_at,omitempty"`
+}
+
+func (p *positions) PutFingerprint(path, labels string, pos int64, head []byte) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var (
+		entry = Entry{path, labels}
+		fp    = NewFingerprint(head)
+	)
+	record, ok := p.fingerprints[entry]
+	if ok && !record.Fingerprint.Matches(head) {
+		// The file was replaced or truncated.
+		prevPos, err := strconv.ParseInt(p.positions[entry], 10, 64)
+		if err == nil && record.Fingerprint.Size > 0 {
+			record.Previous = &PreviousFile{Fingerprint: record.Fingerprint, Position: prevPos}
+		}
+	}
+	record.Fingerprint = fp
+	p.fingerprints[entry] = record
+	p.positions[entry] = strconv.FormatInt(pos, 10)
+}
+
+func (p *positions) GetFingerprint(path, labels string) (Fingerprint, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	entry := Entry{path, labels}
+	if _, recorded := p.positions[entry]; !recorded {
+		// The record of a removed file only keeps its previous file.
+		return Fingerprint{}, false
+	}
+	record, ok := p.fingerprints[entry]
+	return record.Fingerprint, ok
+}
+
+func (p *positions) FindFingerprint(labels string, head []byte) (string, int64, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	// Iterate in a stable order in case several files match.
+	entries := make([]Entry, 0, len(p.fingerprints))
+	for entry := range p.fingerprints {
+		if entry.Labels == labels {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.Path, b.Path) })
+
+	for _, entry := range entries {
+		record := p.fingerprints[entry]
+		// An empty file matches any file, so it can't identify one.
+		if record.Fingerprint.Size > 0 && record.Fingerprint.Matches(head) {
+			pos, err := strconv.ParseInt(p.positions[entry], 10, 64)
+			if err == nil {
+				return entry.Path, pos, true
+			}
+		}
+		if prev := record.Previous; prev != nil && prev.Fingerprint.Matches(head) {
+			return entry.Path, prev.Position, true
+		}
+	}
+	return "", 0, false
+}
+
+// removeFingerprint replaces the fingerprint record of an entry whose position
+// is being removed by a record only keeping the file as the previous file of
+// the path, so that FindFingerprint can find the file for
+// removedFileRetention.
+func (p *positions) removeFingerprint(entry Entry, now time.Time) {
+	record, ok := p.fingerprints[entry]
+	if !ok {
+		return
+	}
+	if _, recorded := p.positions[entry]; !recorded {
+		// The file was already removed.
+		return
+	}
+
+	pos, err := strconv.ParseInt(p.positions[entry], 10, 64)
+	if err != nil || record.Fingerprint.Size == 0 {
+		delete(p.fingerprints, entry)
+		return
+	}
+	p.fingerprints[entry] = FingerprintRecord{
+		Previous: &PreviousFile{Fingerprint: record.Fingerprint, Position: pos, RemovedAt: now},
+	}
+}
+
+// expireRemovedFiles forgets the files whose position was removed more than
+// removedFileRetention ago.
+func (p *positions) expireRemovedFiles(now time.Time) {
+	for entry, record := range p.fingerprints {
+		prev := record.Previous
+		if prev == nil || prev.RemovedAt.IsZero() || now.Sub(prev.RemovedAt) < removedFileRetention {
+			continue
+		}
+		if _, recorded := p.positions[entry]; recorded {
+			record.Previous = nil
+			p.fingerprints[entry] = record
+		} else {
+			delete(p.fingerprints, entry)
+		}
+	}
+}
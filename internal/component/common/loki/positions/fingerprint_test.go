@@ -0,0 +1,167 @@
+package positions
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint_Matches(t *testing.T) {
+	fp := NewFingerprint([]byte("first line\n"))
+	require.Equal(t, 11, fp.Size)
+
+	require.True(t, fp.Matches([]byte("first line\n")))
+	require.True(t, fp.Matches([]byte("first line\nsecond line\n")))
+	require.False(t, fp.Matches([]byte("first")))
+	require.False(t, fp.Matches([]byte("other line\n")))
+
+	// The fingerprint of an empty file matches any file.
+	require.True(t, NewFingerprint(nil).Matches([]byte("first line\n")))
+}
+
+func TestPutFingerprint(t *testing.T) {
+	temp := tempFilename(t)
+	p, err := New(log.NewNopLogger(), Config{
+		SyncPeriod:    20 * time.Second,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+	defer p.Stop()
+
+	const labels = `{job="tmp"}`
+	p.PutFingerprint("/tmp/app.log", labels, 5, []byte("first"))
+	p.PutFingerprint("/tmp/app.log", labels, 17, []byte("first line\nsecond"))
+
+	// The file grew, so it keeps its fingerprint.
+	fp, ok := p.GetFingerprint("/tmp/app.log", labels)
+	require.True(t, ok)
+	require.Equal(t, NewFingerprint([]byte("first line\nsecond")), fp)
+
+	// The file is rotated: the rotated file can be found from its fingerprint.
+	p.PutFingerprint("/tmp/app.log", labels, 4, []byte("new\n"))
+	path, pos, ok := p.FindFingerprint(labels, []byte("first line\nsecond line\n"))
+	require.True(t, ok)
+	require.Equal(t, "/tmp/app.log", path)
+	require.Equal(t, int64(17), pos)
+
+	path, pos, ok = p.FindFingerprint(labels, []byte("new\nline\n"))
+	require.True(t, ok)
+	require.Equal(t, "/tmp/app.log", path)
+	require.Equal(t, int64(4), pos)
+
+	_, _, ok = p.FindFingerprint(`{job="other"}`, []byte("new\n"))
+	require.False(t, ok)
+	_, _, ok = p.FindFingerprint(labels, []byte("unknown\n"))
+	require.False(t, ok)
+
+	// A position recorded without a fingerprint removes the fingerprint.
+	p.Put("/tmp/app.log", labels, 10)
+	_, ok = p.GetFingerprint("/tmp/app.log", labels)
+	require.False(t, ok)
+}
+
+func TestFingerprintsFile(t *testing.T) {
+	temp := tempFilename(t)
+
+	// Positions files written without fingerprints are read.
+	err := os.WriteFile(temp, []byte(`
+positions:
+  ? path: /tmp/random.log
+    labels: '{job="tmp"}'
+  : "17623"
+`), 0644)
+	require.NoError(t, err)
+
+	p, err := New(log.NewNopLogger(), Config{
+		SyncPeriod:    20 * time.Second,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+	pos, err := p.Get("/tmp/random.log", `{job="tmp"}`)
+	require.NoError(t, err)
+	require.Equal(t, int64(17623), pos)
+	_, ok := p.GetFingerprint("/tmp/random.log", `{job="tmp"}`)
+	require.False(t, ok)
+
+	p.PutFingerprint("/tmp/random.log", `{job="tmp"}`, 17700, []byte("first line\n"))
+	p.PutFingerprint("/tmp/random.log", `{job="tmp"}`, 3, []byte("new"))
+	p.Stop()
+
+	f, err := readFile(Config{PositionsFile: temp}, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, map[Entry]string{
+		{Path: "/tmp/random.log", Labels: `{job="tmp"}`}: "3",
+	}, f.Positions)
+	require.Equal(t, map[Entry]FingerprintRecord{
+		{Path: "/tmp/random.log", Labels: `{job="tmp"}`}: {
+			Fingerprint: NewFingerprint([]byte("new")),
+			Previous: &PreviousFile{
+				Fingerprint: NewFingerprint([]byte("first line\n")),
+				Position:    17700,
+			},
+		},
+	}, f.Fingerprints)
+}
+
+func TestRemoveFingerprint(t *testing.T) {
+	temp := tempFilename(t)
+	p, err := New(log.NewNopLogger(), Config{
+		SyncPeriod:    20 * time.Second,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+
+	const labels = `{job="tmp"}`
+	p.PutFingerprint("/tmp/app.log", labels, 17, []byte("first line\n"))
+	p.Put("/tmp/other.log", labels, 10)
+	p.Remove("/tmp/app.log", labels)
+	p.Remove("/tmp/other.log", labels)
+
+	// The position of the removed file is gone.
+	require.Equal(t, "", p.GetString("/tmp/app.log", labels))
+	_, ok := p.GetFingerprint("/tmp/app.log", labels)
+	require.False(t, ok)
+
+	// The removed file can still be found from its fingerprint, in case it was
+	// renamed.
+	path, pos, ok := p.FindFingerprint(labels, []byte("first line\nsecond line\n"))
+	require.True(t, ok)
+	require.Equal(t, "/tmp/app.log", path)
+	require.Equal(t, int64(17), pos)
+
+	// Removing it again doesn't forget it.
+	p.Remove("/tmp/app.log", labels)
+	_, _, ok = p.FindFingerprint(labels, []byte("first line\n"))
+	require.True(t, ok)
+
+	// A new file at the path keeps the removed file as its previous file.
+	p.PutFingerprint("/tmp/app.log", labels, 4, []byte("new\n"))
+	fp, ok := p.GetFingerprint("/tmp/app.log", labels)
+	require.True(t, ok)
+	require.Equal(t, NewFingerprint([]byte("new\n")), fp)
+	_, pos, ok = p.FindFingerprint(labels, []byte("first line\n"))
+	require.True(t, ok)
+	require.Equal(t, int64(17), pos)
+	p.Remove("/tmp/app.log", labels)
+	p.Stop()
+
+	f, err := readFile(Config{PositionsFile: temp}, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Empty(t, f.Positions)
+	require.Len(t, f.Fingerprints, 1)
+	removed := f.Fingerprints[Entry{Path: "/tmp/app.log", Labels: labels}].Previous
+	require.NotNil(t, removed)
+	require.Equal(t, NewFingerprint([]byte("new\n")), removed.Fingerprint)
+	require.Equal(t, int64(4), removed.Position)
+	require.False(t, removed.RemovedAt.IsZero())
+
+	// Removed files are forgotten after a while.
+	p2 := &positions{positions: f.Positions, fingerprints: f.Fingerprints}
+	p2.expireRemovedFiles(removed.RemovedAt.Add(removedFileRetention - time.Second))
+	require.Len(t, p2.fingerprints, 1)
+	p2.expireRemovedFiles(removed.RemovedAt.Add(removedFileRetention))
+	require.Empty(t, p2.fingerprints)
+}
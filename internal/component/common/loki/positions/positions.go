@@ -7,6 +7,7 @@ package positions
 import (
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -48,12 +49,13 @@ func (cfg *Config) RegisterFlags(flags *flag.FlagSet) {
 
 // Positions tracks how far through each file we've read.
 type positions struct {
-	logger    log.Logger
-	cfg       Config
-	mtx       sync.Mutex
-	positions map[Entry]string
-	quit      chan struct{}
-	done      chan struct{}
+	logger       log.Logger
+	cfg          Config
+	mtx          sync.Mutex
+	positions    map[Entry]string
+	fingerprints map[Entry]FingerprintRecord
+	quit         chan struct{}
+	done         chan struct{}
 }
 
 // Entry describes a positions file entry consisting of an absolute file path and
@@ -70,7 +72,8 @@ type Entry struct {
 
 // File format for the positions data.
 type File struct {
-	Positions map[Entry]string `yaml:"positions"`
+	Positions    map[Entry]string            `yaml:"positions"`
+	Fingerprints map[Entry]FingerprintRecord `yaml:"fingerprints,omitempty"`
 }
 
 type Positions interface {
@@ -88,7 +91,24 @@ type Positions interface {
 	PutString(path, labels string, pos string)
 	// Put records (asynchronously) how far we've read through a file.
 	Put(path, labels string, pos int64)
-	// Remove removes the position tracking for a filepath
+	// PutFingerprint records (asynchronously) how far we've read through a
+	// file along with the fingerprint of the file, computed from head, its
+	// first bytes. If the fingerprint recorded for the path doesn't match
+	// head, the file at the path was replaced: the previous fingerprint and
+	// position are kept so that FindFingerprint can find the previous file if
+	// it was renamed.
+	PutFingerprint(path, labels string, pos int64, head []byte)
+	// GetFingerprint returns the fingerprint recorded for a file. It returns
+	// false if no position is recorded for the file, or if it was recorded
+	// without a fingerprint.
+	GetFingerprint(path, labels string) (Fingerprint, bool)
+	// FindFingerprint returns the path and position of a file recorded with
+	// the given labels and a fingerprint matching head, the first bytes of a
+	// file, including files which were previously at the path.
+	FindFingerprint(labels string, head []byte) (path string, pos int64, ok bool)
+	// Remove removes the position tracking for a filepath. The fingerprint
+	// and position of a fingerprinted file are kept for an hour, so that
+	// FindFingerprint can find the file if it was renamed.
 	Remove(path, labels string)
 	// SyncPeriod returns how often the positions file gets resynced
 	SyncPeriod() time.Duration
@@ -157,17 +177,18 @@ func readLegacyFile(legacyPath string, l log.Logger) *LegacyFile {
 
 // New makes a new Positions.
 func New(logger log.Logger, cfg Config) (Positions, error) {
-	positionData, err := readPositionsFile(cfg, logger)
+	positionData, err := readFile(cfg, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	p := &positions{
-		logger:    logger,
-		cfg:       cfg,
-		positions: positionData,
-		quit:      make(chan struct{}),
-		done:      make(chan struct{}),
+		logger:       logger,
+		cfg:          cfg,
+		positions:    positionData.Positions,
+		fingerprints: positionData.Fingerprints,
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
 	}
 
 	go p.run()
@@ -183,6 +204,9 @@ func (p *positions) PutString(path, labels string, pos string) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
 	p.positions[Entry{path, labels}] = pos
+	// A position recorded without a fingerprint might not be the one of the
+	// fingerprinted file.
+	delete(p.fingerprints, Entry{path, labels})
 }
 
 func (p *positions) Put(path, labels string, pos int64) {
@@ -212,7 +236,9 @@ func (p *positions) Remove(path, labels string) {
 }
 
 func (p *positions) remove(path, labels string) {
-	delete(p.positions, Entry{path, labels})
+	entry := Entry{path, labels}
+	p.removeFingerprint(entry, time.Now())
+	delete(p.positions, entry)
 }
 
 func (p *positions) SyncPeriod() time.Duration {
@@ -243,13 +269,15 @@ func (p *positions) save() {
 		return
 	}
 	p.mtx.Lock()
-	positions := make(map[Entry]string, len(p.positions))
-	for k, v := range p.positions {
-		positions[k] = v
+	f := File{
+		Positions: maps.Clone(p.positions),
+	}
+	if len(p.fingerprints) > 0 {
+		f.Fingerprints = maps.Clone(p.fingerprints)
 	}
 	p.mtx.Unlock()
 
-	if err := writePositionFile(p.cfg.PositionsFile, positions); err != nil {
+	if err := writeFile(p.cfg.PositionsFile, f); err != nil {
 		level.Error(p.logger).Log("msg", "error writing positions file", "error", err)
 	}
 }
@@ -285,16 +313,34 @@ func (p *positions) cleanup() {
 	for _, tr := range toRemove {
 		p.remove(tr.Path, tr.Labels)
 	}
+	p.expireRemovedFiles(time.Now())
 }
 
 func readPositionsFile(cfg Config, logger log.Logger) (map[Entry]string, error) {
+	p, err := readFile(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return p.Positions, nil
+}
+
+func writePositionFile(filename string, positions map[Entry]string) error {
+	return writeFile(filename, File{Positions: positions})
+}
+
+func readFile(cfg Config, logger log.Logger) (File, error) {
+	empty := File{
+		Positions:    map[Entry]string{},
+		Fingerprints: map[Entry]FingerprintRecord{},
+	}
+
 	cleanfn := filepath.Clean(cfg.PositionsFile)
 	buf, err := os.ReadFile(cleanfn)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return map[Entry]string{}, nil
+			return empty, nil
 		}
-		return nil, err
+		return File{}, err
 	}
 
 	var p File
@@ -303,16 +349,20 @@ func readPositionsFile(cfg Config, logger log.Logger) (map[Entry]string, error)
 		// return empty if cfg option enabled
 		if cfg.IgnoreInvalidYaml {
 			level.Debug(logger).Log("msg", "ignoring invalid positions file", "file", cleanfn, "error", err)
-			return map[Entry]string{}, nil
+			return empty, nil
 		}
 
-		return nil, fmt.Errorf("invalid yaml positions file [%s]: %v", cleanfn, err)
+		return File{}, fmt.Errorf("invalid yaml positions file [%s]: %v", cleanfn, err)
 	}
 
-	// p.Positions will be nil if the file exists but is empty
+	// p.Positions will be nil if the file exists but is empty, and
+	// p.Fingerprints if no fingerprint was recorded.
 	if p.Positions == nil {
-		p.Positions = map[Entry]string{}
+		p.Positions = empty.Positions
+	}
+	if p.Fingerprints == nil {
+		p.Fingerprints = empty.Fingerprints
 	}
 
-	return p.Positions, nil
+	return p, nil
 }
@@ -14,10 +14,8 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-func writePositionFile(filename string, positions map[Entry]string) error {
-	buf, err := yaml.Marshal(File{
-		Positions: positions,
-	})
+func writeFile(filename string, f File) error {
+	buf, err := yaml.Marshal(f)
 	if err != nil {
 		return err
 	}
@@ -64,6 +64,7 @@ type InfoOptions struct {
 	GetArguments bool // When true, sets the Arguments field of returned components.
 	GetExports   bool // When true, sets the Exports field of returned components.
 	GetDebugInfo bool // When true, sets the DebugInfo field of returned components.
+	GetResources bool // When true, sets the Resources field of returned components.
 }
 
 // String returns the "<ModuleID>/<LocalID>" string representation of the id.
@@ -130,6 +131,25 @@ type Info struct {
 	Exports              Exports     // Current exports value of the component.
 	DebugInfo            interface{} // Current debug info of the component.
 	LiveDebuggingEnabled bool
+
+	// Resources is the approximate resource usage of the component. It's nil
+	// if resource usage isn't tracked.
+	Resources *ResourceUsage
+}
+
+// ResourceUsage is the approximate resource usage of a component, sampled
+// from profiles of the process.
+type ResourceUsage struct {
+	// Goroutines is the number of goroutines running the component.
+	Goroutines int
+	// CPUCores is the average number of CPU cores used by the component during
+	// the last CPU profile.
+	CPUCores float64
+	// HeapInuseBytes is the size of the heap in use allocated by the code of
+	// the component. It's shared by all the components with the same name.
+	HeapInuseBytes int64
+	// UpdateTime is the time the resource usage was sampled.
+	UpdateTime time.Time
 }
 
 // MarshalJSON returns a JSON representation of cd. The format of the
@@ -142,22 +162,30 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			UpdatedTime time.Time `json:"updatedTime"`
 		}
 
+		componentResourcesJSON struct {
+			Goroutines     int       `json:"goroutines"`
+			CPUCores       float64   `json:"cpuCores"`
+			HeapInuseBytes int64     `json:"heapInuseBytes"`
+			UpdatedTime    time.Time `json:"updatedTime"`
+		}
+
 		componentDetailJSON struct {
-			Name                 string               `json:"name"`
-			Type                 string               `json:"type,omitempty"`
-			LocalID              string               `json:"localID"`
-			ModuleID             string               `json:"moduleID"`
-			Label                string               `json:"label,omitempty"`
-			References           []string             `json:"referencesTo"`
-			ReferencedBy         []string             `json:"referencedBy"`
-			DataFlowEdgesTo      []string             `json:"dataFlowEdgesTo"`
-			Health               *componentHealthJSON `json:"health"`
-			Original             string               `json:"original"`
-			Arguments            json.RawMessage      `json:"arguments,omitempty"`
-			Exports              json.RawMessage      `json:"exports,omitempty"`
-			DebugInfo            json.RawMessage      `json:"debugInfo,omitempty"`
-			CreatedModuleIDs     []string             `json:"createdModuleIDs,omitempty"`
-			LiveDebuggingEnabled bool                 `json:"liveDebuggingEnabled"`
+			Name                 string                  `json:"name"`
+			Type                 string                  `json:"type,omitempty"`
+			LocalID              string                  `json:"localID"`
+			ModuleID             string                  `json:"moduleID"`
+			Label                string                  `json:"label,omitempty"`
+			References           []string                `json:"referencesTo"`
+			ReferencedBy         []string                `json:"referencedBy"`
+			DataFlowEdgesTo      []string                `json:"dataFlowEdgesTo"`
+			Health               *componentHealthJSON    `json:"health"`
+			Original             string                  `json:"original"`
+			Arguments            json.RawMessage         `json:"arguments,omitempty"`
+			Exports              json.RawMessage         `json:"exports,omitempty"`
+			DebugInfo            json.RawMessage         `json:"debugInfo,omitempty"`
+			CreatedModuleIDs     []string                `json:"createdModuleIDs,omitempty"`
+			LiveDebuggingEnabled bool                    `json:"liveDebuggingEnabled"`
+			Resources            *componentResourcesJSON `json:"resources,omitempty"`
 		}
 	)
 
@@ -193,6 +221,16 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 
+	var resources *componentResourcesJSON
+	if info.Resources != nil {
+		resources = &componentResourcesJSON{
+			Goroutines:     info.Resources.Goroutines,
+			CPUCores:       info.Resources.CPUCores,
+			HeapInuseBytes: info.Resources.HeapInuseBytes,
+			UpdatedTime:    info.Resources.UpdateTime,
+		}
+	}
+
 	return json.Marshal(&componentDetailJSON{
 		Name:            info.ComponentName,
 		Type:            "block",
@@ -212,6 +250,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		DebugInfo:            debugInfo,
 		CreatedModuleIDs:     info.ModuleIDs,
 		LiveDebuggingEnabled: info.LiveDebuggingEnabled,
+		Resources:            resources,
 	})
 }
 
@@ -25,15 +25,19 @@ func NewDistributedTargets(clusteringEnabled bool, cluster cluster.Cluster, allT
 // NewDistributedTargetsWithCustomLabels creates the abstraction that allows components to
 // dynamically shard targets between components. Passing in labels will limit the sharding to only use those labels for computing the hash key.
 // Passing in nil or empty array means look at all labels.
-func NewDistributedTargetsWithCustomLabels(clusteringEnabled bool, cluster cluster.Cluster, allTargets []Target, labels []string) *DistributedTargets {
-	if !clusteringEnabled || cluster == nil {
-		cluster = disabledCluster{}
+//
+// When the cluster enables topology-aware sharding, targets with the
+// topology-aware label are assigned to the nodes of the same topology.
+func NewDistributedTargetsWithCustomLabels(clusteringEnabled bool, c cluster.Cluster, allTargets []Target, labels []string) *DistributedTargets {
+	if !clusteringEnabled || c == nil {
+		c = disabledCluster{}
 	}
+	topologyLabel := cluster.TopologyAwareLabel(c)
 
 	var localCap int
-	if !cluster.Ready() {
+	if !c.Ready() {
 		localCap = 0 // cluster not ready - won't take any traffic locally
-	} else if peerCount := len(cluster.Peers()); peerCount != 0 {
+	} else if peerCount := len(c.Peers()); peerCount != 0 {
 		localCap = (len(allTargets) + 1) / peerCount // if we have peers - calculate expected capacity
 	} else {
 		localCap = len(allTargets) // cluster ready but no peers? fall back to all traffic locally
@@ -62,8 +66,8 @@ func NewDistributedTargetsWithCustomLabels(clusteringEnabled bool, cluster clust
 
 		// Determine if target belongs locally. Make sure it doesn't if cluster not ready.
 		belongsToLocal := false
-		if cluster.Ready() {
-			peers, err := cluster.Lookup(targetKey, 1, shard.OpReadWrite)
+		if c.Ready() {
+			peers, err := lookupTarget(c, tgt, targetKey, topologyLabel)
 			belongsToLocal = err != nil || len(peers) == 0 || peers[0].Self
 		}
 
@@ -109,6 +113,17 @@ func (dt *DistributedTargets) MovedToRemoteInstance(prev *DistributedTargets) []
 	return movedAwayTargets
 }
 
+// lookupTarget returns the owner of tgt. Targets with the topology-aware
+// label are owned by a node of the same topology, if any.
+func lookupTarget(c cluster.Cluster, tgt Target, key shard.Key, topologyLabel string) ([]peer.Peer, error) {
+	if topologyLabel != "" {
+		if value, ok := tgt.Get(topologyLabel); ok && value != "" {
+			return cluster.LookupTopology(c, key, value, 1, shard.OpReadWrite)
+		}
+	}
+	return c.Lookup(key, 1, shard.OpReadWrite)
+}
+
 func keyFor(tgt Target) shard.Key {
 	return shard.Key(tgt.NonMetaLabelsHash())
 }
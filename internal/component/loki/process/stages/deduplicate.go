@@ -0,0 +1,220 @@
+package stages
+
+import (
+	"container/list"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Configuration errors.
+var (
+	ErrDeduplicateStageEmptyKey          = errors.New("deduplicate stage key must contain the line or at least one label")
+	ErrDeduplicateStageInvalidWindow     = errors.New("deduplicate stage window must be greater than 0")
+	ErrDeduplicateStageInvalidMaxEntries = errors.New("deduplicate stage max_entries must be greater than 0")
+)
+
+// DeduplicateConfig contains the configuration for a deduplicateStage.
+type DeduplicateConfig struct {
+	Window     time.Duration `alloy:"window,attr,optional"`
+	Line       bool          `alloy:"line,attr,optional"`
+	Labels     []string      `alloy:"labels,attr,optional"`
+	MaxEntries int           `alloy:"max_entries,attr,optional"`
+	CountKey   string        `alloy:"count_key,attr,optional"`
+}
+
+// DefaultDeduplicateConfig holds the default settings of the deduplicate
+// stage.
+var DefaultDeduplicateConfig = DeduplicateConfig{
+	Window:     10 * time.Second,
+	Line:       true,
+	MaxEntries: 10000,
+	CountKey:   "repeat_count",
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *DeduplicateConfig) SetToDefault() {
+	*args = DefaultDeduplicateConfig
+}
+
+// Validate implements syntax.Validator.
+func (args *DeduplicateConfig) Validate() error {
+	return validateDeduplicateConfig(*args)
+}
+
+func validateDeduplicateConfig(cfg DeduplicateConfig) error {
+	if !cfg.Line && len(cfg.Labels) == 0 {
+		return ErrDeduplicateStageEmptyKey
+	}
+	if cfg.Window <= 0 {
+		return ErrDeduplicateStageInvalidWindow
+	}
+	if cfg.MaxEntries <= 0 {
+		return ErrDeduplicateStageInvalidMaxEntries
+	}
+	return nil
+}
+
+// deduplicateStage holds the first entry of each key for the duration of the
+// window and drops the entries with the same key received in the meantime.
+// The held entry is then sent with the number of entries received for the
+// key.
+type deduplicateStage struct {
+	logger             log.Logger
+	cfg                DeduplicateConfig
+	deduplicatedCount  prometheus.Counter
+	labels             []model.LabelName
+	pendingEntries     map[string]*list.Element
+	pendingEntriesList *list.List // Pending entries, from the oldest to the newest.
+}
+
+// pendingEntry is an entry held by the deduplicate stage.
+type pendingEntry struct {
+	key      string
+	entry    Entry
+	count    int
+	deadline time.Time
+}
+
+func newDeduplicateStage(logger log.Logger, cfg DeduplicateConfig, registerer prometheus.Registerer) (Stage, error) {
+	if err := validateDeduplicateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	labels := make([]model.LabelName, 0, len(cfg.Labels))
+	for _, l := range cfg.Labels {
+		labels = append(labels, model.LabelName(l))
+	}
+
+	return &deduplicateStage{
+		logger:             log.With(logger, "component", "stage", "type", "deduplicate"),
+		cfg:                cfg,
+		deduplicatedCount:  getDeduplicatedCountMetric(registerer),
+		labels:             labels,
+		pendingEntries:     make(map[string]*list.Element),
+		pendingEntriesList: list.New(),
+	}, nil
+}
+
+// Run implements Stage.
+func (d *deduplicateStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(d.cfg.Window)
+		defer timer.Stop()
+
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					d.flush(out, func(*pendingEntry) bool { return true })
+					return
+				}
+				d.add(out, e)
+			case <-timer.C:
+				now := time.Now()
+				d.flush(out, func(p *pendingEntry) bool { return !p.deadline.After(now) })
+			}
+
+			timer.Reset(d.nextFlush())
+		}
+	}()
+	return out
+}
+
+// add holds e if it's the first entry of its key, and drops it otherwise.
+func (d *deduplicateStage) add(out chan Entry, e Entry) {
+	key := d.key(e)
+	if elem, ok := d.pendingEntries[key]; ok {
+		elem.Value.(*pendingEntry).count++
+		d.deduplicatedCount.Inc()
+		return
+	}
+
+	// Send the oldest entry early to bound the memory used by the stage.
+	if len(d.pendingEntries) >= d.cfg.MaxEntries {
+		if Debug {
+			level.Debug(d.logger).Log("msg", "too many pending entries, sending the oldest one before the end of its window", "max_entries", d.cfg.MaxEntries)
+		}
+		d.send(out, d.pendingEntriesList.Front())
+	}
+
+	d.pendingEntries[key] = d.pendingEntriesList.PushBack(&pendingEntry{
+		key:      key,
+		entry:    e,
+		count:    1,
+		deadline: time.Now().Add(d.cfg.Window),
+	})
+}
+
+// flush sends the pending entries from the oldest one, until shouldFlush
+// returns false.
+func (d *deduplicateStage) flush(out chan Entry, shouldFlush func(*pendingEntry) bool) {
+	for elem := d.pendingEntriesList.Front(); elem != nil; elem = d.pendingEntriesList.Front() {
+		if !shouldFlush(elem.Value.(*pendingEntry)) {
+			return
+		}
+		d.send(out, elem)
+	}
+}
+
+// send removes a pending entry and sends it, with its count in structured
+// metadata if it was repeated.
+func (d *deduplicateStage) send(out chan Entry, elem *list.Element) {
+	p := d.pendingEntriesList.Remove(elem).(*pendingEntry)
+	delete(d.pendingEntries, p.key)
+
+	e := p.entry
+	if p.count > 1 {
+		e.StructuredMetadata = append(e.StructuredMetadata, logproto.LabelAdapter{Name: d.cfg.CountKey, Value: strconv.Itoa(p.count)})
+	}
+	out <- e
+}
+
+// nextFlush returns the duration until the end of the window of the oldest
+// pending entry.
+func (d *deduplicateStage) nextFlush() time.Duration {
+	front := d.pendingEntriesList.Front()
+	if front == nil {
+		return d.cfg.Window
+	}
+	return time.Until(front.Value.(*pendingEntry).deadline)
+}
+
+// key returns the deduplication key of an entry, made of its line and the
+// values of the configured labels.
+func (d *deduplicateStage) key(e Entry) string {
+	if len(d.labels) == 0 {
+		return e.Line
+	}
+
+	var sb strings.Builder
+	for _, name := range d.labels {
+		sb.WriteString(string(e.Labels[name]))
+		sb.WriteByte(0xff)
+	}
+	if d.cfg.Line {
+		sb.WriteString(e.Line)
+	}
+	return sb.String()
+}
+
+// Name implements Stage.
+func (*deduplicateStage) Name() string {
+	return StageTypeDeduplicate
+}
+
+// Cleanup implements Stage.
+func (*deduplicateStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,167 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+var testDeduplicateAlloy = `
+stage.deduplicate {
+	labels = ["app"]
+}
+`
+
+func TestDeduplicatePipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testDeduplicateAlloy), &plName, registry, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+
+	app1, app2 := model.LabelSet{"app": "app1"}, model.LabelSet{"app": "app2"}
+	ts := time.Now()
+	out := processEntries(pl,
+		newEntry(nil, app1, "connection refused", ts),
+		newEntry(nil, app1, "connection refused", ts.Add(time.Second)),
+		newEntry(nil, app2, "connection refused", ts),
+		newEntry(nil, app1, "connection reset", ts),
+		newEntry(nil, app1, "connection refused", ts.Add(2*time.Second)),
+	)
+
+	require.Len(t, out, 3)
+	require.Equal(t, "connection refused", out[0].Line)
+	require.Equal(t, app1, out[0].Labels)
+	require.Equal(t, ts, out[0].Timestamp)
+	require.Equal(t, push.LabelsAdapter{{Name: "repeat_count", Value: "3"}}, out[0].StructuredMetadata)
+	require.Equal(t, "connection refused", out[1].Line)
+	require.Equal(t, app2, out[1].Labels)
+	require.Empty(t, out[1].StructuredMetadata)
+	require.Equal(t, "connection reset", out[2].Line)
+	require.Empty(t, out[2].StructuredMetadata)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(getDeduplicatedCountMetric(registry)))
+}
+
+func TestDeduplicateStage_Labels(t *testing.T) {
+	s, err := newDeduplicateStage(util_log.Logger, DeduplicateConfig{
+		Window:     time.Minute,
+		Labels:     []string{"app"},
+		MaxEntries: 10,
+		CountKey:   "count",
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	app1 := model.LabelSet{"app": "app1"}
+	out := processEntries(s,
+		newEntry(nil, app1, "first", time.Now()),
+		newEntry(nil, app1, "second", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "app2"}, "third", time.Now()),
+	)
+
+	require.Len(t, out, 2)
+	require.Equal(t, "first", out[0].Line)
+	require.Equal(t, push.LabelsAdapter{{Name: "count", Value: "2"}}, out[0].StructuredMetadata)
+	require.Equal(t, "third", out[1].Line)
+}
+
+func TestDeduplicateStage_Window(t *testing.T) {
+	s, err := newDeduplicateStage(util_log.Logger, DeduplicateConfig{
+		Window:     50 * time.Millisecond,
+		Line:       true,
+		MaxEntries: 10,
+		CountKey:   "repeat_count",
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	in := make(chan Entry)
+	out := s.Run(in)
+	defer close(in)
+
+	in <- newEntry(nil, nil, "error", time.Now())
+	in <- newEntry(nil, nil, "error", time.Now())
+
+	select {
+	case e := <-out:
+		require.Equal(t, "error", e.Line)
+		require.Equal(t, push.LabelsAdapter{{Name: "repeat_count", Value: "2"}}, e.StructuredMetadata)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the entry wasn't sent at the end of the window")
+	}
+
+	// The same line starts a new window.
+	in <- newEntry(nil, nil, "error", time.Now())
+	select {
+	case e := <-out:
+		require.Equal(t, "error", e.Line)
+		require.Empty(t, e.StructuredMetadata)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the entry wasn't sent at the end of the window")
+	}
+}
+
+func TestDeduplicateStage_MaxEntries(t *testing.T) {
+	s, err := newDeduplicateStage(util_log.Logger, DeduplicateConfig{
+		Window:     time.Minute,
+		Line:       true,
+		MaxEntries: 1,
+		CountKey:   "repeat_count",
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	out := processEntries(s,
+		newEntry(nil, nil, "a", time.Now()),
+		newEntry(nil, nil, "a", time.Now()),
+		newEntry(nil, nil, "b", time.Now()),
+		newEntry(nil, nil, "a", time.Now()),
+	)
+
+	require.Len(t, out, 3)
+	require.Equal(t, "a", out[0].Line)
+	require.Equal(t, push.LabelsAdapter{{Name: "repeat_count", Value: "2"}}, out[0].StructuredMetadata)
+	require.Equal(t, "b", out[1].Line)
+	require.Equal(t, "a", out[2].Line)
+	require.Empty(t, out[2].StructuredMetadata)
+}
+
+func TestDeduplicateConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		config DeduplicateConfig
+		err    error
+	}{
+		"defaults": {
+			config: DefaultDeduplicateConfig,
+		},
+		"empty key": {
+			config: DeduplicateConfig{Window: time.Second, MaxEntries: 1},
+			err:    ErrDeduplicateStageEmptyKey,
+		},
+		"labels without line": {
+			config: DeduplicateConfig{Window: time.Second, MaxEntries: 1, Labels: []string{"app"}},
+		},
+		"invalid window": {
+			config: DeduplicateConfig{Line: true, MaxEntries: 1},
+			err:    ErrDeduplicateStageInvalidWindow,
+		},
+		"invalid max entries": {
+			config: DeduplicateConfig{Line: true, Window: time.Second},
+			err:    ErrDeduplicateStageInvalidMaxEntries,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.err, tt.config.Validate())
+		})
+	}
+}
+
+func TestPipeline_DeduplicateStability(t *testing.T) {
+	_, err := NewPipeline(util_log.Logger, loadConfig(testDeduplicateAlloy), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	require.ErrorContains(t, err, `stage "deduplicate" is at stability level "experimental"`)
+}
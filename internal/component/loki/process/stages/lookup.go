@@ -0,0 +1,396 @@
+package stages
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Configuration errors.
+var (
+	ErrLookupStageEmptySource    = errors.New("lookup stage source cannot be empty")
+	ErrLookupStageEmptyKey       = errors.New("lookup stage key cannot be empty")
+	ErrLookupStageTableSource    = errors.New("lookup stage must have exactly one of file or content")
+	ErrLookupStageNoOutput       = errors.New("lookup stage must set at least one label or structured metadata")
+	ErrLookupStageInvalidFormat  = errors.New("lookup stage format must be one of csv, json or yaml")
+	ErrLookupStageInvalidOnMiss  = errors.New("lookup stage on_miss must be one of keep, drop or default")
+	ErrLookupStageInvalidReload  = errors.New("lookup stage reload_interval must be greater than 0")
+	ErrLookupStageInvalidMaxRows = errors.New("lookup stage max_rows must be greater than 0")
+)
+
+// Formats of lookup tables.
+const (
+	LookupFormatCSV  = "csv"
+	LookupFormatJSON = "json"
+	LookupFormatYAML = "yaml"
+)
+
+// Behaviors of the lookup stage when a value isn't in the table.
+const (
+	LookupOnMissKeep    = "keep"
+	LookupOnMissDrop    = "drop"
+	LookupOnMissDefault = "default"
+)
+
+// LookupConfig contains the configuration for a lookupStage.
+type LookupConfig struct {
+	Source             string            `alloy:"source,attr"`
+	File               string            `alloy:"file,attr,optional"`
+	Content            string            `alloy:"content,attr,optional"`
+	Format             string            `alloy:"format,attr,optional"`
+	Key                string            `alloy:"key,attr"`
+	Labels             map[string]string `alloy:"labels,attr,optional"`
+	StructuredMetadata map[string]string `alloy:"structured_metadata,attr,optional"`
+	OnMiss             string            `alloy:"on_miss,attr,optional"`
+	DefaultValues      map[string]string `alloy:"default_values,attr,optional"`
+	DropReason         string            `alloy:"drop_counter_reason,attr,optional"`
+	ReloadInterval     time.Duration     `alloy:"reload_interval,attr,optional"`
+	MaxRows            int               `alloy:"max_rows,attr,optional"`
+}
+
+// DefaultLookupConfig holds the default settings of the lookup stage.
+var DefaultLookupConfig = LookupConfig{
+	Format:         LookupFormatCSV,
+	OnMiss:         LookupOnMissKeep,
+	DropReason:     "lookup_stage",
+	ReloadInterval: time.Minute,
+	MaxRows:        100000,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *LookupConfig) SetToDefault() {
+	*args = DefaultLookupConfig
+}
+
+// Validate implements syntax.Validator.
+func (args *LookupConfig) Validate() error {
+	return validateLookupConfig(*args)
+}
+
+func validateLookupConfig(cfg LookupConfig) error {
+	if cfg.Source == "" {
+		return ErrLookupStageEmptySource
+	}
+	if cfg.Key == "" {
+		return ErrLookupStageEmptyKey
+	}
+	if (cfg.File == "") == (cfg.Content == "") {
+		return ErrLookupStageTableSource
+	}
+	if len(cfg.Labels) == 0 && len(cfg.StructuredMetadata) == 0 {
+		return ErrLookupStageNoOutput
+	}
+	for name := range cfg.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf(ErrInvalidLabelName, name)
+		}
+	}
+	switch cfg.Format {
+	case LookupFormatCSV, LookupFormatJSON, LookupFormatYAML:
+	default:
+		return ErrLookupStageInvalidFormat
+	}
+	switch cfg.OnMiss {
+	case LookupOnMissKeep, LookupOnMissDrop, LookupOnMissDefault:
+	default:
+		return ErrLookupStageInvalidOnMiss
+	}
+	if cfg.ReloadInterval <= 0 {
+		return ErrLookupStageInvalidReload
+	}
+	if cfg.MaxRows <= 0 {
+		return ErrLookupStageInvalidMaxRows
+	}
+	return nil
+}
+
+// lookupResult is the labels and structured metadata added to the entries
+// with a given value.
+type lookupResult struct {
+	labels   model.LabelSet
+	metadata push.LabelsAdapter
+	found    bool // Whether the value is in the table.
+}
+
+// lookupStage adds labels and structured metadata to entries from the row of
+// a table matching an extracted value.
+type lookupStage struct {
+	logger    log.Logger
+	cfg       LookupConfig
+	dropCount *prometheus.CounterVec
+
+	table   map[string]lookupResult // Results of the rows of the table by key.
+	missing lookupResult            // Result for the values missing from the table.
+
+	// Hot reload of the file.
+	lastCheck   time.Time
+	lastModTime time.Time
+	lastSize    int64
+}
+
+func newLookupStage(logger log.Logger, cfg LookupConfig, registerer prometheus.Registerer) (Stage, error) {
+	if err := validateLookupConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	l := &lookupStage{
+		logger:    log.With(logger, "component", "stage", "type", "lookup"),
+		cfg:       cfg,
+		dropCount: getDropCountMetric(registerer),
+	}
+	if cfg.OnMiss == LookupOnMissDefault {
+		l.missing = l.result(cfg.DefaultValues)
+	}
+
+	content := []byte(cfg.Content)
+	if cfg.File != "" {
+		fi, err := os.Stat(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = os.ReadFile(cfg.File); err != nil {
+			return nil, err
+		}
+		l.lastCheck, l.lastModTime, l.lastSize = time.Now(), fi.ModTime(), fi.Size()
+	}
+	table, err := parseLookupTable(content, cfg.Format, cfg.Key, cfg.MaxRows)
+	if err != nil {
+		return nil, fmt.Errorf("lookup stage failed to parse the table: %w", err)
+	}
+	l.table = l.results(table)
+	return l, nil
+}
+
+// Run implements Stage.
+func (l *lookupStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if l.cfg.File != "" && time.Since(l.lastCheck) >= l.cfg.ReloadInterval {
+				l.reload()
+			}
+			if !l.process(&e) {
+				l.dropCount.WithLabelValues(l.cfg.DropReason).Inc()
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// process adds the labels and structured metadata of the row matching the
+// source value to e. It returns false if e must be dropped.
+func (l *lookupStage) process(e *Entry) bool {
+	res := l.missing
+	if value, ok := e.Extracted[l.cfg.Source]; ok {
+		s, err := getString(value)
+		if err != nil {
+			if Debug {
+				level.Debug(l.logger).Log("msg", "failed to convert source value to string", "source", l.cfg.Source, "err", err, "type", reflect.TypeOf(value))
+			}
+		} else if row, ok := l.table[s]; ok {
+			res = row
+		}
+	}
+
+	if !res.found && l.cfg.OnMiss == LookupOnMissDrop {
+		return false
+	}
+	if e.Labels == nil && len(res.labels) > 0 {
+		e.Labels = make(model.LabelSet, len(res.labels))
+	}
+	for name, value := range res.labels {
+		e.Labels[name] = value
+	}
+	e.StructuredMetadata = append(e.StructuredMetadata, res.metadata...)
+	return true
+}
+
+// results returns the results of the rows of a table, so that only the
+// columns used by the stage are kept in memory.
+func (l *lookupStage) results(table map[string]map[string]string) map[string]lookupResult {
+	results := make(map[string]lookupResult, len(table))
+	for key, row := range table {
+		res := l.result(row)
+		res.found = true
+		results[key] = res
+	}
+	return results
+}
+
+// result returns the labels and structured metadata built from a row.
+func (l *lookupStage) result(row map[string]string) lookupResult {
+	res := lookupResult{labels: make(model.LabelSet, len(l.cfg.Labels))}
+	for name, column := range l.cfg.Labels {
+		value := model.LabelValue(row[columnName(name, column)])
+		if value == "" || !value.IsValid() {
+			continue
+		}
+		res.labels[model.LabelName(name)] = value
+	}
+	for name, column := range l.cfg.StructuredMetadata {
+		if value, ok := row[columnName(name, column)]; ok {
+			res.metadata = append(res.metadata, logproto.LabelAdapter{Name: name, Value: value})
+		}
+	}
+	sort.Slice(res.metadata, func(i, j int) bool { return res.metadata[i].Name < res.metadata[j].Name })
+	return res
+}
+
+// reload reloads the table if the file changed. The current table is kept if
+// the file can't be read.
+func (l *lookupStage) reload() {
+	l.lastCheck = time.Now()
+
+	fi, err := os.Stat(l.cfg.File)
+	if err != nil {
+		level.Warn(l.logger).Log("msg", "failed to check the lookup table file, keeping the current table", "file", l.cfg.File, "err", err)
+		return
+	}
+	if fi.ModTime().Equal(l.lastModTime) && fi.Size() == l.lastSize {
+		return
+	}
+
+	content, err := os.ReadFile(l.cfg.File)
+	if err == nil {
+		var table map[string]map[string]string
+		if table, err = parseLookupTable(content, l.cfg.Format, l.cfg.Key, l.cfg.MaxRows); err == nil {
+			l.table = l.results(table)
+			l.lastModTime, l.lastSize = fi.ModTime(), fi.Size()
+			level.Info(l.logger).Log("msg", "reloaded the lookup table", "file", l.cfg.File, "rows", len(table))
+			return
+		}
+	}
+	level.Warn(l.logger).Log("msg", "failed to reload the lookup table, keeping the current table", "file", l.cfg.File, "err", err)
+}
+
+// columnName returns the column holding the value of the label or
+// structured metadata name. An empty column is the same as the name.
+func columnName(name, column string) string {
+	if column == "" {
+		return name
+	}
+	return column
+}
+
+// parseLookupTable parses the rows of a table and indexes them by the value
+// of their key column. Later rows override earlier rows with the same key. It
+// fails if the table has more than maxRows keys.
+func parseLookupTable(content []byte, format, key string, maxRows int) (map[string]map[string]string, error) {
+	var (
+		rows []map[string]string
+		err  error
+	)
+	switch format {
+	case LookupFormatCSV:
+		rows, err = parseLookupCSV(content)
+	case LookupFormatJSON:
+		var records []map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err = dec.Decode(&records); err == nil {
+			rows, err = lookupRows(records)
+		}
+	case LookupFormatYAML:
+		var records []map[string]interface{}
+		if err = yaml.Unmarshal(content, &records); err == nil {
+			rows, err = lookupRows(records)
+		}
+	default:
+		err = ErrLookupStageInvalidFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]map[string]string, len(rows))
+	for i, row := range rows {
+		value, ok := row[key]
+		if !ok {
+			return nil, fmt.Errorf("row %d has no %q key", i+1, key)
+		}
+		table[value] = row
+		if len(table) > maxRows {
+			return nil, fmt.Errorf("the table has more than the maximum of %d rows", maxRows)
+		}
+	}
+	return table, nil
+}
+
+// parseLookupCSV parses a CSV table whose first record is the header.
+func parseLookupCSV(content []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+}
+
+// lookupRows converts the values of JSON or YAML records to strings.
+func lookupRows(records []map[string]interface{}) ([]map[string]string, error) {
+	rows := make([]map[string]string, 0, len(records))
+	for i, record := range records {
+		row := make(map[string]string, len(record))
+		for column, value := range record {
+			if value == nil {
+				continue
+			}
+			if n, ok := value.(json.Number); ok {
+				row[column] = n.String()
+				continue
+			}
+			s, err := getString(value)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: column %q: %w", i+1, column, err)
+			}
+			row[column] = s
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Name implements Stage.
+func (*lookupStage) Name() string {
+	return StageTypeLookup
+}
+
+// Cleanup implements Stage.
+func (*lookupStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,302 @@
+package stages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+var testLookupAlloy = `
+stage.json {
+	expressions = { user = "" }
+}
+
+stage.lookup {
+	source  = "user"
+	content = "id,team,tier\nfrank,payments,gold\nalice,search,silver\n"
+	key     = "id"
+	labels  = { team = "" }
+	structured_metadata = { customer_tier = "tier" }
+}
+`
+
+func TestLookupPipeline(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testLookupAlloy), &plName, prometheus.DefaultRegisterer, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"job": "app"}, `{"user":"frank"}`, time.Now()),
+		newEntry(nil, model.LabelSet{"job": "app"}, `{"user":"bob"}`, time.Now()),
+	)
+
+	require.Len(t, out, 2)
+	require.Equal(t, model.LabelSet{"job": "app", "team": "payments"}, out[0].Labels)
+	require.Equal(t, push.LabelsAdapter{{Name: "customer_tier", Value: "gold"}}, out[0].StructuredMetadata)
+	require.Equal(t, model.LabelSet{"job": "app"}, out[1].Labels)
+	require.Empty(t, out[1].StructuredMetadata)
+}
+
+func TestLookupStage_Formats(t *testing.T) {
+	tests := map[string]struct {
+		format  string
+		content string
+	}{
+		"csv": {
+			format:  LookupFormatCSV,
+			content: "code,severity,retry\n404,warning,false\n500,critical,true\n",
+		},
+		"json": {
+			format:  LookupFormatJSON,
+			content: `[{"code": 404, "severity": "warning", "retry": false}, {"code": 500, "severity": "critical", "retry": true}]`,
+		},
+		"yaml": {
+			format: LookupFormatYAML,
+			content: `
+- code: 404
+  severity: warning
+  retry: false
+- code: 500
+  severity: critical
+  retry: true
+`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := newLookupStage(util_log.Logger, LookupConfig{
+				Source:             "status",
+				Content:            tt.content,
+				Format:             tt.format,
+				Key:                "code",
+				Labels:             map[string]string{"severity": ""},
+				StructuredMetadata: map[string]string{"retryable": "retry"},
+				OnMiss:             LookupOnMissKeep,
+				DropReason:         "lookup_stage",
+				ReloadInterval:     time.Minute,
+				MaxRows:            10,
+			}, prometheus.NewRegistry())
+			require.NoError(t, err)
+
+			out := processEntries(s,
+				newEntry(map[string]interface{}{"status": 500}, nil, "failed", time.Now()),
+				newEntry(map[string]interface{}{"status": "404"}, nil, "not found", time.Now()),
+			)
+
+			require.Len(t, out, 2)
+			require.Equal(t, model.LabelSet{"severity": "critical"}, out[0].Labels)
+			require.Equal(t, push.LabelsAdapter{{Name: "retryable", Value: "true"}}, out[0].StructuredMetadata)
+			require.Equal(t, model.LabelSet{"severity": "warning"}, out[1].Labels)
+			require.Equal(t, push.LabelsAdapter{{Name: "retryable", Value: "false"}}, out[1].StructuredMetadata)
+		})
+	}
+}
+
+func TestLookupStage_OnMiss(t *testing.T) {
+	tests := map[string]struct {
+		onMiss         string
+		expectedLabels []model.LabelSet
+		expectedDrops  float64
+	}{
+		"keep": {
+			onMiss: LookupOnMissKeep,
+			expectedLabels: []model.LabelSet{
+				{"host": "web-1", "datacenter": "eu-west"},
+				{"host": "web-2"},
+				{"host": "web-3"},
+			},
+		},
+		"drop": {
+			onMiss: LookupOnMissDrop,
+			expectedLabels: []model.LabelSet{
+				{"host": "web-1", "datacenter": "eu-west"},
+			},
+			expectedDrops: 2,
+		},
+		"default": {
+			onMiss: LookupOnMissDefault,
+			expectedLabels: []model.LabelSet{
+				{"host": "web-1", "datacenter": "eu-west"},
+				{"host": "web-2", "datacenter": "unknown"},
+				{"host": "web-3", "datacenter": "unknown"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			s, err := newLookupStage(util_log.Logger, LookupConfig{
+				Source:         "host",
+				Content:        "host,datacenter\nweb-1,eu-west\n",
+				Format:         LookupFormatCSV,
+				Key:            "host",
+				Labels:         map[string]string{"datacenter": ""},
+				OnMiss:         tt.onMiss,
+				DefaultValues:  map[string]string{"datacenter": "unknown"},
+				DropReason:     "unknown_host",
+				ReloadInterval: time.Minute,
+				MaxRows:        10,
+			}, registry)
+			require.NoError(t, err)
+
+			out := processEntries(s,
+				newEntry(map[string]interface{}{"host": "web-1"}, model.LabelSet{"host": "web-1"}, "hello", time.Now()),
+				newEntry(map[string]interface{}{"host": "web-2"}, model.LabelSet{"host": "web-2"}, "hello", time.Now()),
+				newEntry(nil, model.LabelSet{"host": "web-3"}, "hello", time.Now()),
+			)
+
+			labels := make([]model.LabelSet, 0, len(out))
+			for _, e := range out {
+				labels = append(labels, e.Labels)
+			}
+			require.Equal(t, tt.expectedLabels, labels)
+			require.Equal(t, tt.expectedDrops, testutil.ToFloat64(getDropCountMetric(registry).WithLabelValues("unknown_host")))
+		})
+	}
+}
+
+func TestLookupStage_Reload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "teams.csv")
+	require.NoError(t, os.WriteFile(file, []byte("user,team\nfrank,payments\n"), 0o644))
+
+	s, err := newLookupStage(util_log.Logger, LookupConfig{
+		Source:         "user",
+		File:           file,
+		Format:         LookupFormatCSV,
+		Key:            "user",
+		Labels:         map[string]string{"team": ""},
+		OnMiss:         LookupOnMissKeep,
+		DropReason:     "lookup_stage",
+		ReloadInterval: time.Millisecond,
+		MaxRows:        10,
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	in := make(chan Entry)
+	out := s.Run(in)
+	defer close(in)
+
+	in <- newEntry(map[string]interface{}{"user": "frank"}, nil, "hello", time.Now())
+	require.Equal(t, model.LabelSet{"team": "payments"}, (<-out).Labels)
+
+	require.NoError(t, os.WriteFile(file, []byte("user,team\nfrank,search-infra\n"), 0o644))
+	time.Sleep(10 * time.Millisecond)
+	in <- newEntry(map[string]interface{}{"user": "frank"}, nil, "hello", time.Now())
+	require.Equal(t, model.LabelSet{"team": "search-infra"}, (<-out).Labels)
+
+	// The current table is kept when the file can't be parsed.
+	require.NoError(t, os.WriteFile(file, []byte("name,team\nfrank,\"search\n"), 0o644))
+	time.Sleep(10 * time.Millisecond)
+	in <- newEntry(map[string]interface{}{"user": "frank"}, nil, "hello", time.Now())
+	require.Equal(t, model.LabelSet{"team": "search-infra"}, (<-out).Labels)
+}
+
+func TestLookupConfig_Validate(t *testing.T) {
+	valid := func(f func(*LookupConfig)) LookupConfig {
+		cfg := DefaultLookupConfig
+		cfg.Source = "user"
+		cfg.Key = "id"
+		cfg.Content = "id,team\n"
+		cfg.Labels = map[string]string{"team": ""}
+		f(&cfg)
+		return cfg
+	}
+
+	tests := map[string]struct {
+		config LookupConfig
+		err    error
+	}{
+		"valid": {
+			config: valid(func(*LookupConfig) {}),
+		},
+		"structured metadata only": {
+			config: valid(func(cfg *LookupConfig) {
+				cfg.Labels = nil
+				cfg.StructuredMetadata = map[string]string{"team": ""}
+			}),
+		},
+		"empty source": {
+			config: valid(func(cfg *LookupConfig) { cfg.Source = "" }),
+			err:    ErrLookupStageEmptySource,
+		},
+		"empty key": {
+			config: valid(func(cfg *LookupConfig) { cfg.Key = "" }),
+			err:    ErrLookupStageEmptyKey,
+		},
+		"no table": {
+			config: valid(func(cfg *LookupConfig) { cfg.Content = "" }),
+			err:    ErrLookupStageTableSource,
+		},
+		"file and content": {
+			config: valid(func(cfg *LookupConfig) { cfg.File = "teams.csv" }),
+			err:    ErrLookupStageTableSource,
+		},
+		"no output": {
+			config: valid(func(cfg *LookupConfig) { cfg.Labels = nil }),
+			err:    ErrLookupStageNoOutput,
+		},
+		"invalid label": {
+			config: valid(func(cfg *LookupConfig) { cfg.Labels = map[string]string{"": "team"} }),
+			err:    fmt.Errorf(ErrInvalidLabelName, ""),
+		},
+		"invalid format": {
+			config: valid(func(cfg *LookupConfig) { cfg.Format = "xml" }),
+			err:    ErrLookupStageInvalidFormat,
+		},
+		"invalid on_miss": {
+			config: valid(func(cfg *LookupConfig) { cfg.OnMiss = "ignore" }),
+			err:    ErrLookupStageInvalidOnMiss,
+		},
+		"invalid reload interval": {
+			config: valid(func(cfg *LookupConfig) { cfg.ReloadInterval = 0 }),
+			err:    ErrLookupStageInvalidReload,
+		},
+		"invalid max rows": {
+			config: valid(func(cfg *LookupConfig) { cfg.MaxRows = 0 }),
+			err:    ErrLookupStageInvalidMaxRows,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.err, tt.config.Validate())
+		})
+	}
+}
+
+func TestParseLookupTable(t *testing.T) {
+	table, err := parseLookupTable([]byte("id,team\nfrank,payments\nfrank,search\nalice,\n"), LookupFormatCSV, "id", 10)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]string{
+		"frank": {"id": "frank", "team": "search"},
+		"alice": {"id": "alice", "team": ""},
+	}, table)
+
+	_, err = parseLookupTable([]byte(`[{"id": "frank"}, {"team": "search"}]`), LookupFormatJSON, "id", 10)
+	require.EqualError(t, err, `row 2 has no "id" key`)
+
+	_, err = parseLookupTable([]byte(`- id: [frank]`), LookupFormatYAML, "id", 10)
+	require.ErrorContains(t, err, `row 1: column "id"`)
+
+	// Rows with the same key count once.
+	_, err = parseLookupTable([]byte("id\nfrank\nfrank\nalice\n"), LookupFormatCSV, "id", 2)
+	require.NoError(t, err)
+	_, err = parseLookupTable([]byte("id\nfrank\nalice\nbob\n"), LookupFormatCSV, "id", 2)
+	require.EqualError(t, err, "the table has more than the maximum of 2 rows")
+}
+
+func TestPipeline_LookupStability(t *testing.T) {
+	_, err := NewPipeline(util_log.Logger, loadConfig(testLookupAlloy), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	require.ErrorContains(t, err, `stage "lookup" is at stability level "experimental"`)
+}
@@ -119,6 +119,23 @@ func getDropCountMetric(registerer prometheus.Registerer) *prometheus.CounterVec
 	return dropCount
 }
 
+func getDeduplicatedCountMetric(registerer prometheus.Registerer) prometheus.Counter {
+	deduplicatedCount := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_process_deduplicated_lines_total",
+		Help: "A count of all log lines dropped as duplicates by a deduplicate stage",
+	})
+	err := registerer.Register(deduplicatedCount)
+	if err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			deduplicatedCount = existing.ExistingCollector.(prometheus.Counter)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	return deduplicatedCount
+}
+
 // matcherStage applies Label matchers to determine if the include stages should be run
 type matcherStage struct {
 	dropReason string
@@ -0,0 +1,130 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/loki/v3/pkg/logql/log/pattern"
+	"github.com/prometheus/common/model"
+)
+
+// Config Errors.
+var (
+	ErrPatternRequired         = errors.New("pattern is required")
+	ErrCouldNotParsePattern    = errors.New("could not parse pattern")
+	ErrEmptyPatternStageSource = errors.New("empty source")
+)
+
+// PatternConfig configures a processing stage which uses a LogQL pattern
+// expression to extract values from log lines into the shared values map.
+type PatternConfig struct {
+	Pattern string  `alloy:"pattern,attr"`
+	Source  *string `alloy:"source,attr,optional"`
+}
+
+// validatePatternConfig validates the config and returns a pattern matcher.
+func validatePatternConfig(c PatternConfig) (*pattern.Matcher, error) {
+	if c.Pattern == "" {
+		return nil, ErrPatternRequired
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, ErrEmptyPatternStageSource
+	}
+
+	matcher, err := pattern.New(c.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", ErrCouldNotParsePattern, err)
+	}
+
+	// Captures must be valid label names, like in the LogQL pattern parser.
+	for _, name := range matcher.Names() {
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("%v: invalid capture label name '%s'", ErrCouldNotParsePattern, name)
+		}
+	}
+
+	return matcher, nil
+}
+
+// patternStage sets extracted data using a LogQL pattern expression.
+type patternStage struct {
+	config  *PatternConfig
+	matcher *pattern.Matcher
+	names   []string
+	logger  log.Logger
+}
+
+// newPatternStage creates a new patternStage.
+func newPatternStage(logger log.Logger, config PatternConfig) (Stage, error) {
+	matcher, err := validatePatternConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return toStage(&patternStage{
+		config:  &config,
+		matcher: matcher,
+		names:   matcher.Names(),
+		logger:  log.With(logger, "component", "stage", "type", "pattern"),
+	}), nil
+}
+
+// Process implements Stage
+func (p *patternStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	// If a source key is provided, the pattern stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if p.config.Source != nil {
+		if _, ok := extracted[*p.config.Source]; !ok {
+			if Debug {
+				level.Debug(p.logger).Log("msg", "source does not exist in the set of extracted values", "source", *p.config.Source)
+			}
+			return
+		}
+
+		value, err := getString(extracted[*p.config.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(p.logger).Log("msg", "failed to convert source value to string", "source", *p.config.Source, "err", err, "type", reflect.TypeOf(extracted[*p.config.Source]))
+			}
+			return
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(p.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return
+	}
+
+	// Like the LogQL pattern parser, a capture followed by a literal which
+	// isn't found takes the rest of the input, and the following captures
+	// aren't extracted.
+	matches := p.matcher.Matches([]byte(*input))
+	if len(matches) == 0 {
+		if Debug {
+			level.Debug(p.logger).Log("msg", "pattern did not match", "input", *input, "pattern", p.config.Pattern)
+		}
+		return
+	}
+
+	for i, m := range matches {
+		extracted[p.names[i]] = string(m)
+	}
+	if Debug {
+		level.Debug(p.logger).Log("msg", "extracted data debug in pattern stage", "extracted data", fmt.Sprintf("%v", extracted))
+	}
+}
+
+// Name implements Stage
+func (p *patternStage) Name() string {
+	return StageTypePattern
+}
@@ -0,0 +1,209 @@
+package stages
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPatternAlloyMultiStageWithSource = `
+stage.pattern {
+    pattern = "<ip> <_> <user> [<timestamp>] \"<action> <path> <protocol>\" <status> <size> \"<referer>\" \"<useragent>\""
+}
+stage.pattern {
+    pattern = "HTTP/<protocol_version>"
+    source  = "protocol"
+}
+`
+
+func TestPipeline_Pattern(t *testing.T) {
+	t.Parallel()
+
+	logger := util.TestAlloyLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testPatternAlloyMultiStageWithSource), nil, prometheus.DefaultRegisterer, featuregate.StabilityExperimental)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := processEntries(pl, newEntry(nil, nil, testRegexLogLine, time.Now()))[0]
+	assert.Equal(t, map[string]interface{}{
+		"ip":               "11.11.11.11",
+		"user":             "frank",
+		"timestamp":        "25/Jan/2000:14:00:01 -0500",
+		"action":           "GET",
+		"path":             "/1986.js",
+		"protocol":         "HTTP/1.1",
+		"protocol_version": "1.1",
+		"status":           "200",
+		"size":             "932",
+		"referer":          "-",
+		"useragent":        "Mozilla/5.0 (Windows; U; Windows NT 5.1; de; rv:1.9.1.7) Gecko/20091221 Firefox/3.5.7 GTB6",
+	}, out.Extracted)
+}
+
+func TestPatternConfig_validate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		config PatternConfig
+		err    error
+	}{
+		"missing pattern": {
+			PatternConfig{},
+			ErrPatternRequired,
+		},
+		"no capture": {
+			PatternConfig{Pattern: "foo"},
+			errors.New(ErrCouldNotParsePattern.Error() + ": at least one capture is required"),
+		},
+		"consecutive captures": {
+			PatternConfig{Pattern: "<foo><bar>"},
+			errors.New(ErrCouldNotParsePattern.Error() + ": found consecutive capture '<foo><bar>': invalid expression"),
+		},
+		"empty source": {
+			PatternConfig{Pattern: "<foo>", Source: new(string)},
+			ErrEmptyPatternStageSource,
+		},
+		"valid with source": {
+			PatternConfig{Pattern: "<foo> <_>", Source: &protocolStr},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			_, err := validatePatternConfig(tt.config)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestPatternParser_Parse(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		config          PatternConfig
+		extracted       map[string]interface{}
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully match pattern on entry": {
+			PatternConfig{Pattern: `<ip> <_> <user> [<timestamp>] "<action> <path> <_>" <status> <_>`},
+			map[string]interface{}{},
+			regexLogFixture,
+			map[string]interface{}{
+				"ip":        "11.11.11.11",
+				"user":      "frank",
+				"timestamp": "25/Jan/2000:14:00:01 -0500",
+				"action":    "GET",
+				"path":      "/1986.js",
+				"status":    "200",
+			},
+		},
+		"successfully match pattern on extracted[source]": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{
+				"protocol": "HTTP/1.1",
+			},
+			regexLogFixture,
+			map[string]interface{}{
+				"protocol":         "HTTP/1.1",
+				"protocol_version": "1.1",
+			},
+		},
+		"capture before a missing literal takes the rest of the line": {
+			PatternConfig{Pattern: "<level> [<component>] <msg>"},
+			map[string]interface{}{},
+			"info starting the server",
+			map[string]interface{}{
+				"level": "info starting the server",
+			},
+		},
+		"leading literal doesn't match": {
+			PatternConfig{Pattern: "level=<level> <_>"},
+			map[string]interface{}{},
+			"msg=hello level=info",
+			map[string]interface{}{},
+		},
+		"missing extracted[source]": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{},
+			"blahblahblah",
+			map[string]interface{}{},
+		},
+		"invalid data type in extracted[source]": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{
+				"protocol": true,
+			},
+			"unknown/unknown",
+			map[string]interface{}{
+				"protocol": true,
+			},
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			logger := util.TestAlloyLogger(t)
+			p, err := New(logger, nil, StageConfig{PatternConfig: &tt.config}, nil, featuregate.StabilityExperimental)
+			if err != nil {
+				t.Fatalf("failed to create pattern parser: %s", err)
+			}
+			out := processEntries(p, newEntry(tt.extracted, nil, tt.entry, time.Now()))[0]
+			assert.Equal(t, tt.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+// BenchmarkPatternStage extracts the same fields as BenchmarkRegexStage.
+func BenchmarkPatternStage(b *testing.B) {
+	benchmarks := []struct {
+		name   string
+		config PatternConfig
+		entry  string
+	}{
+		{"apache common log",
+			PatternConfig{
+				Pattern: `<ip> <identd> <user> [<timestamp>] "<action> <path> <protocol>" <status> <size> "<referer>" "<useragent>"`},
+			regexLogFixture,
+		},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			logger := util.TestAlloyLogger(b)
+			stage, err := New(logger, nil, StageConfig{PatternConfig: &bm.config}, nil, featuregate.StabilityExperimental)
+			if err != nil {
+				panic(err)
+			}
+			labels := model.LabelSet{}
+			ts := time.Now()
+			extr := map[string]interface{}{}
+
+			in := make(chan Entry)
+			out := stage.Run(in)
+			go func() {
+				for range out {
+				}
+			}()
+			for i := 0; i < b.N; i++ {
+				in <- newEntry(extr, labels, bm.entry, ts)
+			}
+			close(in)
+		})
+	}
+}
+
+func TestPipeline_PatternStability(t *testing.T) {
+	_, err := NewPipeline(util.TestAlloyLogger(t), loadConfig(testPatternAlloyMultiStageWithSource), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	assert.ErrorContains(t, err, `stage "pattern" is at stability level "experimental"`)
+}
@@ -19,6 +19,7 @@ import (
 type StageConfig struct {
 	CRIConfig             *CRIConfig             `alloy:"cri,block,optional"`
 	DecolorizeConfig      *DecolorizeConfig      `alloy:"decolorize,block,optional"`
+	DeduplicateConfig     *DeduplicateConfig     `alloy:"deduplicate,block,optional"`
 	DockerConfig          *DockerConfig          `alloy:"docker,block,optional"`
 	DropConfig            *DropConfig            `alloy:"drop,block,optional"`
 	EventLogMessageConfig *EventLogMessageConfig `alloy:"eventlogmessage,block,optional"`
@@ -29,12 +30,14 @@ type StageConfig struct {
 	LabelsConfig          *LabelsConfig          `alloy:"labels,block,optional"`
 	LimitConfig           *LimitConfig           `alloy:"limit,block,optional"`
 	LogfmtConfig          *LogfmtConfig          `alloy:"logfmt,block,optional"`
+	LookupConfig          *LookupConfig          `alloy:"lookup,block,optional"`
 	LuhnFilterConfig      *LuhnFilterConfig      `alloy:"luhn,block,optional"`
 	MatchConfig           *MatchConfig           `alloy:"match,block,optional"`
 	MetricsConfig         *MetricsConfig         `alloy:"metrics,block,optional"`
 	MultilineConfig       *MultilineConfig       `alloy:"multiline,block,optional"`
 	OutputConfig          *OutputConfig          `alloy:"output,block,optional"`
 	PackConfig            *PackConfig            `alloy:"pack,block,optional"`
+	PatternConfig         *PatternConfig         `alloy:"pattern,block,optional"`
 	RegexConfig           *RegexConfig           `alloy:"regex,block,optional"`
 	ReplaceConfig         *ReplaceConfig         `alloy:"replace,block,optional"`
 	StaticLabelsConfig    *StaticLabelsConfig    `alloy:"static_labels,block,optional"`
@@ -43,6 +46,7 @@ type StageConfig struct {
 	TemplateConfig        *TemplateConfig        `alloy:"template,block,optional"`
 	TenantConfig          *TenantConfig          `alloy:"tenant,block,optional"`
 	TimestampConfig       *TimestampConfig       `alloy:"timestamp,block,optional"`
+	TransformConfig       *TransformConfig       `alloy:"transform,block,optional"`
 	WindowsEventConfig    *WindowsEventConfig    `alloy:"windowsevent,block,optional"`
 }
 
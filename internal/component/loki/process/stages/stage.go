@@ -16,10 +16,11 @@ import (
 
 // TODO(@tpaschalis) Let's use this as the list of stages we need to port over.
 const (
-	StageTypeCRI        = "cri"
-	StageTypeDecolorize = "decolorize"
-	StageTypeDocker     = "docker"
-	StageTypeDrop       = "drop"
+	StageTypeCRI         = "cri"
+	StageTypeDecolorize  = "decolorize"
+	StageTypeDeduplicate = "deduplicate"
+	StageTypeDocker      = "docker"
+	StageTypeDrop        = "drop"
 	//TODO(thampiotr): Add support for eventlogmessage stage
 	StageTypeEventLogMessage    = "eventlogmessage"
 	StageTypeGeoIP              = "geoip"
@@ -29,12 +30,14 @@ const (
 	StageTypeLabelDrop          = "labeldrop"
 	StageTypeLimit              = "limit"
 	StageTypeLogfmt             = "logfmt"
+	StageTypeLookup             = "lookup"
 	StageTypeLuhn               = "luhn"
 	StageTypeMatch              = "match"
 	StageTypeMetric             = "metrics"
 	StageTypeMultiline          = "multiline"
 	StageTypeOutput             = "output"
 	StageTypePack               = "pack"
+	StageTypePattern            = "pattern"
 	StageTypePipeline           = "pipeline"
 	StageTypeRegex              = "regex"
 	StageTypeReplace            = "replace"
@@ -44,11 +47,16 @@ const (
 	StageTypeTemplate           = "template"
 	StageTypeTenant             = "tenant"
 	StageTypeTimestamp          = "timestamp"
+	StageTypeTransform          = "transform"
 	StageTypeWindowsEvent       = "windowsevent"
 )
 
 // Add stages that are not GA. Stages that are not specified here are considered GA.
 var stagesUnstable = map[string]featuregate.Stability{
+	StageTypeTransform:    featuregate.StabilityExperimental,
+	StageTypeLookup:       featuregate.StabilityExperimental,
+	StageTypeDeduplicate:  featuregate.StabilityExperimental,
+	StageTypePattern:      featuregate.StabilityExperimental,
 	StageTypeWindowsEvent: featuregate.StabilityExperimental,
 }
 
@@ -178,6 +186,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.PatternConfig != nil:
+		s, err = newPatternStage(logger, *cfg.PatternConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.TimestampConfig != nil:
 		s, err = newTimestampStage(logger, *cfg.TimestampConfig)
 		if err != nil {
@@ -213,6 +226,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.LookupConfig != nil:
+		s, err = newLookupStage(logger, *cfg.LookupConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.DropConfig != nil:
 		s, err = newDropStage(logger, *cfg.DropConfig, registerer)
 		if err != nil {
@@ -245,6 +263,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.DeduplicateConfig != nil:
+		s, err = newDeduplicateStage(logger, *cfg.DeduplicateConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.DecolorizeConfig != nil:
 		s, err = newDecolorizeStage(*cfg.DecolorizeConfig)
 		if err != nil {
@@ -256,6 +279,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		s = newEventLogMessageStage(logger, cfg.EventLogMessageConfig)
 	case cfg.WindowsEventConfig != nil:
 		s = newWindowsEventStage(logger, cfg.WindowsEventConfig)
+	case cfg.TransformConfig != nil:
+		s, err = newTransformStage(logger, *cfg.TransformConfig)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		panic(fmt.Sprintf("unreachable; should have decoded into one of the StageConfig fields: %+v", cfg))
 	}
@@ -0,0 +1,185 @@
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"github.com/prometheus/common/model"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/util/zapadapter"
+)
+
+// Configuration errors.
+var (
+	ErrTransformStatementsRequired = errors.New("at least one statement is required")
+	ErrTransformInvalidStatements  = errors.New("invalid OTTL statements")
+)
+
+// TransformConfig configures a stage which runs OTTL statements against the
+// log entry.
+type TransformConfig struct {
+	Statements []string       `alloy:"statements,attr"`
+	ErrorMode  ottl.ErrorMode `alloy:"error_mode,attr,optional"`
+}
+
+// DefaultTransformConfig holds the default settings of the transform stage.
+var DefaultTransformConfig = TransformConfig{
+	ErrorMode: ottl.IgnoreError,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *TransformConfig) SetToDefault() {
+	*args = DefaultTransformConfig
+}
+
+// Validate implements syntax.Validator.
+func (args *TransformConfig) Validate() error {
+	if len(args.Statements) == 0 {
+		return ErrTransformStatementsRequired
+	}
+	return nil
+}
+
+// transformStage runs OTTL statements in the log context, where the log
+// entry is mapped to a log record:
+//
+//   - body is the log line.
+//   - attributes are the structured metadata.
+//   - resource.attributes are the labels.
+//   - time is the timestamp.
+//   - cache is the extracted map.
+type transformStage struct {
+	logger     log.Logger
+	errorMode  ottl.ErrorMode
+	statements []*ottl.Statement[ottllog.TransformContext]
+}
+
+func newTransformStage(logger log.Logger, cfg TransformConfig) (Stage, error) {
+	if len(cfg.Statements) == 0 {
+		return nil, ErrTransformStatementsRequired
+	}
+	if cfg.ErrorMode == "" {
+		cfg.ErrorMode = DefaultTransformConfig.ErrorMode
+	}
+
+	logger = log.With(logger, "component", "stage", "type", "transform")
+
+	// Statements log the whole transform context at the debug level each time
+	// they're executed, which is too expensive for every log entry.
+	zapLogger := zap.NewNop()
+	if Debug {
+		zapLogger = zapadapter.New(logger)
+	}
+	parser, err := ottllog.NewParser(ottlfuncs.StandardFuncs[ottllog.TransformContext](), otelcomponent.TelemetrySettings{Logger: zapLogger})
+	if err != nil {
+		return nil, err
+	}
+	statements, err := parser.ParseStatements(cfg.Statements)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", ErrTransformInvalidStatements, err)
+	}
+
+	return &transformStage{
+		logger:     logger,
+		errorMode:  cfg.ErrorMode,
+		statements: statements,
+	}, nil
+}
+
+// Name implements Stage.
+func (*transformStage) Name() string {
+	return StageTypeTransform
+}
+
+// Cleanup implements Stage.
+func (*transformStage) Cleanup() {
+	// no-op
+}
+
+// Run implements Stage.
+func (t *transformStage) Run(in chan Entry) chan Entry {
+	return RunWith(in, t.transform)
+}
+
+func (t *transformStage) transform(e Entry) Entry {
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+	record := scopeLogs.LogRecords().AppendEmpty()
+
+	record.Body().SetStr(e.Line)
+	record.SetTimestamp(pcommon.NewTimestampFromTime(e.Timestamp))
+	for _, m := range e.StructuredMetadata {
+		record.Attributes().PutStr(m.Name, m.Value)
+	}
+	for name, value := range e.Labels {
+		resourceLogs.Resource().Attributes().PutStr(string(name), string(value))
+	}
+	cache := pcommon.NewMap()
+	for k, v := range e.Extracted {
+		if err := cache.PutEmpty(k).FromRaw(v); err != nil {
+			cache.PutStr(k, fmt.Sprint(v))
+		}
+	}
+
+	tCtx := ottllog.NewTransformContext(record, scopeLogs.Scope(), resourceLogs.Resource(), scopeLogs, resourceLogs, ottllog.WithCache(&cache))
+	for _, statement := range t.statements {
+		if _, _, err := statement.Execute(context.Background(), tCtx); err != nil {
+			switch t.errorMode {
+			case ottl.PropagateError:
+				level.Warn(t.logger).Log("msg", "failed to execute statement, sending the entry unchanged", "err", err)
+				return e
+			case ottl.IgnoreError:
+				level.Warn(t.logger).Log("msg", "failed to execute statement", "err", err)
+			}
+		}
+	}
+
+	// Maps and slices, such as the ones returned by ParseJSON, are encoded to
+	// JSON.
+	e.Line = record.Body().AsString()
+	if ts := record.Timestamp(); ts != pcommon.NewTimestampFromTime(e.Timestamp) {
+		e.Timestamp = ts.AsTime()
+	}
+	e.StructuredMetadata = nil
+	record.Attributes().Range(func(k string, v pcommon.Value) bool {
+		e.StructuredMetadata = append(e.StructuredMetadata, logproto.LabelAdapter{Name: k, Value: v.AsString()})
+		return true
+	})
+	e.Labels = t.labels(resourceLogs.Resource().Attributes())
+	e.Extracted = make(map[string]interface{}, cache.Len())
+	cache.Range(func(k string, v pcommon.Value) bool {
+		e.Extracted[k] = v.AsRaw()
+		return true
+	})
+	return e
+}
+
+// labels converts resource attributes to labels, dropping the attributes
+// which aren't valid labels.
+func (t *transformStage) labels(attrs pcommon.Map) model.LabelSet {
+	labels := make(model.LabelSet, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		name, value := model.LabelName(k), model.LabelValue(v.AsString())
+		if !name.IsValid() || !value.IsValid() || value == "" {
+			if Debug {
+				level.Debug(t.logger).Log("msg", "dropping invalid label", "name", name, "value", value)
+			}
+			return true
+		}
+		labels[name] = value
+		return true
+	})
+	return labels
+}
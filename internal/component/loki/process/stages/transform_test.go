@@ -0,0 +1,154 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+var testTransformAlloy = `
+stage.json {
+	expressions = { user = "" }
+}
+
+stage.transform {
+	statements = [
+		"set(body, ParseJSON(body))",
+		"delete_key(body, \"password\")",
+		"flatten(body)",
+		"set(resource.attributes[\"level\"], body[\"level\"]) where body[\"level\"] != nil",
+		"set(attributes[\"user\"], cache[\"user\"])",
+		"delete_key(resource.attributes, \"filename\")",
+	]
+}
+`
+
+func TestPipeline_Transform(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testTransformAlloy), nil, prometheus.DefaultRegisterer, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+
+	labels := model.LabelSet{"filename": "/var/log/app.log", "job": "app"}
+	line := `{"level":"warn","user":"frank","password":"secret","http":{"method":"GET","status":404}}`
+	out := processEntries(pl, newEntry(nil, labels, line, time.Now()))[0]
+
+	require.Equal(t, `{"http.method":"GET","http.status":404,"level":"warn","user":"frank"}`, out.Line)
+	require.Equal(t, model.LabelSet{"job": "app", "level": "warn"}, out.Labels)
+	require.Equal(t, push.LabelsAdapter{{Name: "user", Value: "frank"}}, out.StructuredMetadata)
+}
+
+func TestPipeline_TransformStability(t *testing.T) {
+	_, err := NewPipeline(util_log.Logger, loadConfig(testTransformAlloy), nil, prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+	require.ErrorContains(t, err, `stage "transform" is at stability level "experimental"`)
+}
+
+func TestTransformStage(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		config       TransformConfig
+		entry        Entry
+		expectedLine string
+		expectedTime time.Time
+		expectedExtr map[string]interface{}
+		expectedMeta push.LabelsAdapter
+	}{
+		"conditional set of the line": {
+			config: TransformConfig{Statements: []string{
+				`set(body, Concat(["[", cache["level"], "] ", body], "")) where cache["level"] != nil`,
+			}},
+			entry:        newEntry(map[string]interface{}{"level": "info"}, nil, "hello", ts),
+			expectedLine: "[info] hello",
+			expectedTime: ts,
+			expectedExtr: map[string]interface{}{"level": "info"},
+		},
+		"condition not met": {
+			config: TransformConfig{Statements: []string{
+				`set(body, "replaced") where cache["level"] == "debug"`,
+			}},
+			entry:        newEntry(map[string]interface{}{"level": "info"}, nil, "hello", ts),
+			expectedLine: "hello",
+			expectedTime: ts,
+			expectedExtr: map[string]interface{}{"level": "info"},
+		},
+		"edit extracted values and structured metadata": {
+			config: TransformConfig{Statements: []string{
+				`set(cache["count"], 3)`,
+				`delete_key(cache, "user")`,
+				`set(attributes["trace_id"], "abc")`,
+				`delete_key(attributes, "pod")`,
+			}},
+			entry: func() Entry {
+				e := newEntry(map[string]interface{}{"user": "frank", "size": 12.5}, nil, "hello", ts)
+				e.StructuredMetadata = push.LabelsAdapter{{Name: "pod", Value: "app-0"}}
+				return e
+			}(),
+			expectedLine: "hello",
+			expectedTime: ts,
+			expectedExtr: map[string]interface{}{"count": int64(3), "size": 12.5},
+			expectedMeta: push.LabelsAdapter{{Name: "trace_id", Value: "abc"}},
+		},
+		"set the timestamp": {
+			config: TransformConfig{Statements: []string{
+				`set(time_unix_nano, 1719705600000000000)`,
+			}},
+			entry:        newEntry(nil, nil, "hello", ts),
+			expectedLine: "hello",
+			expectedTime: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+			expectedExtr: map[string]interface{}{},
+		},
+		"ignored error": {
+			config: TransformConfig{ErrorMode: ottl.IgnoreError, Statements: []string{
+				`set(body, "first")`,
+				`set(body, ParseJSON(body))`,
+				`set(cache["done"], true)`,
+			}},
+			entry:        newEntry(nil, nil, "hello", ts),
+			expectedLine: "first",
+			expectedTime: ts,
+			expectedExtr: map[string]interface{}{"done": true},
+		},
+		"propagated error": {
+			config: TransformConfig{ErrorMode: ottl.PropagateError, Statements: []string{
+				`set(body, "first")`,
+				`set(body, ParseJSON(body))`,
+				`set(cache["done"], true)`,
+			}},
+			entry:        newEntry(nil, nil, "hello", ts),
+			expectedLine: "hello",
+			expectedTime: ts,
+			expectedExtr: map[string]interface{}{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := newTransformStage(util_log.Logger, tt.config)
+			require.NoError(t, err)
+
+			out := processEntries(s, tt.entry)[0]
+			require.Equal(t, tt.expectedLine, out.Line)
+			require.True(t, tt.expectedTime.Equal(out.Timestamp), "expected %s, got %s", tt.expectedTime, out.Timestamp)
+			require.Equal(t, tt.expectedExtr, out.Extracted)
+			require.Equal(t, tt.expectedMeta, out.StructuredMetadata)
+		})
+	}
+}
+
+func TestTransformStage_InvalidStatements(t *testing.T) {
+	_, err := newTransformStage(util_log.Logger, TransformConfig{})
+	require.ErrorIs(t, err, ErrTransformStatementsRequired)
+
+	_, err = newTransformStage(util_log.Logger, TransformConfig{Statements: []string{`set(body, `}})
+	require.ErrorContains(t, err, ErrTransformInvalidStatements.Error())
+
+	_, err = newTransformStage(util_log.Logger, TransformConfig{Statements: []string{`unknown_function(body)`}})
+	require.ErrorContains(t, err, ErrTransformInvalidStatements.Error())
+}
@@ -62,6 +62,16 @@ func (n *noopPositions) Put(path string, labels string, pos int64) {}
 
 func (n *noopPositions) PutString(path string, labels string, pos string) {}
 
+func (n *noopPositions) PutFingerprint(path string, labels string, pos int64, head []byte) {}
+
+func (n *noopPositions) GetFingerprint(path string, labels string) (positions.Fingerprint, bool) {
+	return positions.Fingerprint{}, false
+}
+
+func (n *noopPositions) FindFingerprint(labels string, head []byte) (string, int64, bool) {
+	return "", 0, false
+}
+
 func (n *noopPositions) Remove(path string, labels string) {}
 
 func (n *noopPositions) Stop() {}
@@ -47,6 +47,7 @@ type Arguments struct {
 	Encoding            string              `alloy:"encoding,attr,optional"`
 	DecompressionConfig DecompressionConfig `alloy:"decompression,block,optional"`
 	FileWatch           FileWatch           `alloy:"file_watch,block,optional"`
+	Fingerprint         Fingerprint         `alloy:"fingerprint,block,optional"`
 	TailFromEnd         bool                `alloy:"tail_from_end,attr,optional"`
 	LegacyPositionsFile string              `alloy:"legacy_positions_file,attr,optional"`
 }
@@ -56,11 +57,29 @@ type FileWatch struct {
 	MaxPollFrequency time.Duration `alloy:"max_poll_frequency,attr,optional"`
 }
 
+// Fingerprint configures the identification of files by the fingerprint of
+// their first bytes rather than by their path.
+type Fingerprint struct {
+	Enabled bool `alloy:"enabled,attr"`
+	Size    int  `alloy:"size,attr,optional"`
+}
+
+// DefaultFingerprint holds the default settings of the fingerprint block.
+var DefaultFingerprint = Fingerprint{
+	Size: 1024,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (f *Fingerprint) SetToDefault() {
+	*f = DefaultFingerprint
+}
+
 var DefaultArguments = Arguments{
 	FileWatch: FileWatch{
 		MinPollFrequency: 250 * time.Millisecond,
 		MaxPollFrequency: 250 * time.Millisecond,
 	},
+	Fingerprint: DefaultFingerprint,
 }
 
 // SetToDefault implements syntax.Defaulter.
@@ -68,6 +87,14 @@ func (a *Arguments) SetToDefault() {
 	*a = DefaultArguments
 }
 
+// Validate implements syntax.Validator.
+func (a *Arguments) Validate() error {
+	if a.Fingerprint.Enabled && a.Fingerprint.Size <= 0 {
+		return fmt.Errorf("fingerprint size must be greater than 0")
+	}
+	return nil
+}
+
 type DecompressionConfig struct {
 	Enabled      bool              `alloy:"enabled,attr"`
 	InitialDelay time.Duration     `alloy:"initial_delay,attr,optional"`
@@ -319,6 +346,10 @@ func (c *Component) createReader(path string, labels model.LabelSet) (reader, er
 			MinPollFrequency: c.args.FileWatch.MinPollFrequency,
 			MaxPollFrequency: c.args.FileWatch.MaxPollFrequency,
 		}
+		var fingerprintSize int
+		if c.args.Fingerprint.Enabled {
+			fingerprintSize = c.args.Fingerprint.Size
+		}
 		tailer, err := newTailer(
 			c.metrics,
 			c.opts.Logger,
@@ -329,6 +360,7 @@ func (c *Component) createReader(path string, labels model.LabelSet) (reader, er
 			c.args.Encoding,
 			pollOptions,
 			c.args.TailFromEnd,
+			fingerprintSize,
 			c.IsStopping,
 		)
 		if err != nil {
@@ -21,6 +21,7 @@ import (
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/common/loki/positions"
 	"github.com/grafana/alloy/internal/component/discovery"
 	"github.com/grafana/alloy/internal/runtime/componenttest"
 	"github.com/grafana/alloy/internal/util"
@@ -465,3 +466,77 @@ func checkMsg(t *testing.T, ch loki.LogsReceiver, msg string, timeout time.Durat
 		require.FailNow(t, "failed waiting for log line")
 	}
 }
+
+func TestFingerprintRenamedFile(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("go.opencensus.io/stats/view.(*worker).start"))
+
+	ctx, cancel := context.WithCancel(componenttest.TestContext(t))
+	defer cancel()
+
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "app.log")
+	renamedPath := filepath.Join(tempDir, "app.log.1")
+	require.NoError(t, os.WriteFile(path, []byte("first line\nsecond line\n"), 0600))
+
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "loki.source.file")
+	require.NoError(t, err)
+
+	ch1 := loki.NewLogsReceiver()
+	args := func(path string) Arguments {
+		args := DefaultArguments
+		args.Targets = []discovery.Target{discovery.NewTargetFromMap(map[string]string{
+			"__path__": path,
+			"foo":      "bar",
+		})}
+		args.ForwardTo = []loki.LogsReceiver{ch1}
+		args.Fingerprint = Fingerprint{Enabled: true, Size: 16}
+		return args
+	}
+
+	go func() {
+		err := ctrl.Run(ctx, args(path))
+		require.NoError(t, err)
+	}()
+
+	ctrl.WaitRunning(time.Minute)
+
+	labels := model.LabelSet{"filename": model.LabelValue(path), "foo": "bar"}
+	checkMsg(t, ch1, "first line", 5*time.Second, labels)
+	checkMsg(t, ch1, "second line", 5*time.Second, labels)
+
+	// Sync the position of the file.
+	c, err := ctrl.GetComponent()
+	require.NoError(t, err)
+	posFile := c.(*Component).posFile
+	c.(*Component).mut.RLock()
+	tailer := c.(*Component).tasks[positions.Entry{Path: path, Labels: `{foo="bar"}`}].reader.(*tailer)
+	c.(*Component).mut.RUnlock()
+	require.EventuallyWithT(t, func(collect *assert.CollectT) {
+		assert.NoError(collect, tailer.markPositionAndSize())
+		assert.Equal(collect, "23", posFile.GetString(path, `{foo="bar"}`))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Rename the file and stop reading it, like when a rotated file is
+	// discovered at its new path after its previous path is gone.
+	require.NoError(t, os.Rename(path, renamedPath))
+	withoutTargets := args(path)
+	withoutTargets.Targets = nil
+	require.NoError(t, ctrl.Update(withoutTargets))
+	require.EventuallyWithT(t, func(collect *assert.CollectT) {
+		assert.Empty(collect, posFile.GetString(path, `{foo="bar"}`))
+		_, pos, ok := posFile.FindFingerprint(`{foo="bar"}`, []byte("first line\nsecond line\n"))
+		assert.True(collect, ok)
+		assert.Equal(collect, int64(23), pos)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	f, err := os.OpenFile(renamedPath, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("third line\n"))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.Update(args(renamedPath)))
+
+	// The renamed file is resumed rather than read again.
+	labels = model.LabelSet{"filename": model.LabelValue(renamedPath), "foo": "bar"}
+	checkMsg(t, ch1, "third line", 5*time.Second, labels)
+}
@@ -6,6 +6,7 @@ package file
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -41,9 +42,20 @@ type tailer struct {
 
 	tailFromEnd bool
 	pollOptions watch.PollingFileWatcherOptions
+	// fingerprintSize is the number of bytes at the start of the file used to
+	// identify it. Files are identified by their path if it's zero.
+	fingerprintSize int
 
 	posAndSizeMtx sync.Mutex
 
+	// file is the file being tailed when files are identified by their
+	// fingerprint, opened separately to fingerprint it even if another file
+	// replaces it at its path. head is its first bytes and lastPos its last
+	// recorded position, both protected by posAndSizeMtx.
+	file    *os.File
+	head    []byte
+	lastPos int64
+
 	running *atomic.Bool
 
 	componentStopping func() bool
@@ -53,7 +65,7 @@ type tailer struct {
 }
 
 func newTailer(metrics *metrics, logger log.Logger, receiver loki.LogsReceiver, positions positions.Positions, path string,
-	labels model.LabelSet, encoding string, pollOptions watch.PollingFileWatcherOptions, tailFromEnd bool, componentStopping func() bool) (*tailer, error) {
+	labels model.LabelSet, encoding string, pollOptions watch.PollingFileWatcherOptions, tailFromEnd bool, fingerprintSize int, componentStopping func() bool) (*tailer, error) {
 
 	tailer := &tailer{
 		metrics:           metrics,
@@ -66,6 +78,7 @@ func newTailer(metrics *metrics, logger log.Logger, receiver loki.LogsReceiver,
 		running:           atomic.NewBool(false),
 		tailFromEnd:       tailFromEnd,
 		pollOptions:       pollOptions,
+		fingerprintSize:   fingerprintSize,
 		componentStopping: componentStopping,
 	}
 
@@ -138,6 +151,10 @@ func getLastLinePosition(path string) (int64, error) {
 	}
 }
 
+// errFileRewritten is returned when recording the position of a fingerprinted
+// file which was truncated and written again past the position read.
+var errFileRewritten = errors.New("file was truncated and written again")
+
 func (t *tailer) Run(ctx context.Context) {
 	// Check if context was canceled between two calls to Run.
 	select {
@@ -152,6 +169,7 @@ func (t *tailer) Run(ctx context.Context) {
 		return
 	}
 	defer handler.Stop()
+	defer t.closeFile()
 
 	t.metrics.filesActive.Add(1.)
 
@@ -170,8 +188,20 @@ func (t *tailer) Run(ctx context.Context) {
 	t.stop(done)
 }
 
-func (t *tailer) initRun() (loki.EntryHandler, error) {
-	fi, err := os.Stat(t.path)
+func (t *tailer) initRun() (_ loki.EntryHandler, err error) {
+	if t.fingerprintSize > 0 {
+		t.file, err = os.Open(t.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tail file: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				t.closeFile()
+			}
+		}()
+	}
+
+	fi, err := t.stat()
 	if err != nil {
 		return nil, fmt.Errorf("failed to tail file: %w", err)
 	}
@@ -181,12 +211,17 @@ func (t *tailer) initRun() (loki.EntryHandler, error) {
 		return nil, fmt.Errorf("failed to get file position: %w", err)
 	}
 
-	// NOTE: The code assumes that if a position is available and that the file is bigger than the position, then
-	// the tail should start from the position. This may not be always desired in situation where the file was rotated
-	// with a file that has the same name but different content and a bigger size that the previous one. This problem would
-	// mostly show up on Windows because on Unix systems, the readlines function is not exited on file rotation.
-	// If this ever becomes a problem, we may want to consider saving and comparing file creation timestamps.
-	if fi.Size() < pos {
+	if t.fingerprintSize > 0 {
+		pos, err = t.fingerprintPosition(fi.Size(), pos)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fingerprint file: %w", err)
+		}
+	} else if fi.Size() < pos {
+		// NOTE: The code assumes that if a position is available and that the file is bigger than the position, then
+		// the tail should start from the position. This may not be always desired in situation where the file was rotated
+		// with a file that has the same name but different content and a bigger size that the previous one. This problem would
+		// mostly show up on Windows because on Unix systems, the readlines function is not exited on file rotation.
+		// Identifying files by their fingerprint solves it.
 		t.positions.Remove(t.path, t.labelsStr)
 	}
 
@@ -195,16 +230,22 @@ func (t *tailer) initRun() (loki.EntryHandler, error) {
 		pos, err = getLastLinePosition(t.path)
 		if err != nil {
 			level.Error(t.logger).Log("msg", "failed to get a position from the end of the file, default to start of file", err)
+		} else if err := t.putPosition(pos); err != nil {
+			level.Error(t.logger).Log("msg", "failed to store the position of the last line", "err", err)
 		} else {
-			t.positions.Put(t.path, t.labelsStr, pos)
 			level.Info(t.logger).Log("msg", "retrieved and stored the position of the last line")
 		}
 	}
 
+	t.lastPos = pos
+
 	tail, err := tail.TailFile(t.path, tail.Config{
-		Follow:    true,
-		Poll:      true,
-		ReOpen:    true,
+		Follow: true,
+		Poll:   true,
+		// Fingerprinted files aren't reopened when they're moved or deleted:
+		// the tail stops at the end of the file, and the file replacing it is
+		// identified when the tail is restarted.
+		ReOpen:    t.fingerprintSize == 0,
 		MustExist: true,
 		Location: &tail.SeekInfo{
 			Offset: pos,
@@ -226,6 +267,76 @@ func (t *tailer) initRun() (loki.EntryHandler, error) {
 	return handler, nil
 }
 
+// fingerprintPosition returns the position to start reading the file from,
+// given the position recorded for its path, by identifying the file with its
+// fingerprint:
+//
+//   - A file matching the fingerprint recorded for its path is resumed, unless
+//     it's smaller than the position, which means it was truncated.
+//   - A file recorded without fingerprint, such as in a positions file written
+//     before fingerprints were enabled, is resumed if it's not smaller than the
+//     position, like when files are identified by their path.
+//   - Otherwise, the file is new, was renamed, or replaced a file at its path.
+//     A renamed file is resumed from the position recorded for its fingerprint
+//     at its previous path, and other files are read from the start.
+func (t *tailer) fingerprintPosition(size, pos int64) (int64, error) {
+	head, err := readHead(t.file, t.fingerprintSize)
+	if err != nil {
+		return 0, err
+	}
+	t.head = head
+
+	var (
+		fp, fingerprinted = t.positions.GetFingerprint(t.path, t.labelsStr)
+		recorded          = t.positions.GetString(t.path, t.labelsStr) != ""
+	)
+	switch {
+	case fingerprinted && fp.Matches(head) && size >= pos:
+		return pos, nil
+	case !fingerprinted && recorded && size >= pos:
+		return pos, nil
+	}
+
+	if previousPath, previousPos, ok := t.positions.FindFingerprint(t.labelsStr, head); ok && size >= previousPos {
+		level.Info(t.logger).Log("msg", "resuming renamed file", "path", t.path, "previous_path", previousPath, "position", previousPos)
+		t.positions.PutFingerprint(t.path, t.labelsStr, previousPos, head)
+		return previousPos, nil
+	}
+
+	if recorded {
+		level.Info(t.logger).Log("msg", "file was truncated or replaced, reading it from the start", "path", t.path)
+		// The previous file is kept in the positions in case it was renamed.
+		t.positions.PutFingerprint(t.path, t.labelsStr, 0, head)
+	}
+	return 0, nil
+}
+
+// readHead returns the first n bytes of f, or all of its content if it's
+// smaller.
+func readHead(f *os.File, n int) ([]byte, error) {
+	head := make([]byte, n)
+	read, err := f.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return head[:read], nil
+}
+
+// stat returns the file info of the file being tailed.
+func (t *tailer) stat() (os.FileInfo, error) {
+	if t.file != nil {
+		return t.file.Stat()
+	}
+	return os.Stat(t.path)
+}
+
+func (t *tailer) closeFile() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
 // updatePosition is run in a goroutine and checks the current size of the file
 // and saves it to the positions file at a regular interval. If there is ever
 // an error it stops the tailer and exits, the tailer will be re-opened by the
@@ -245,7 +356,16 @@ func (t *tailer) updatePosition(posquit chan struct{}) {
 		select {
 		case <-positionWait.C:
 			err := t.markPositionAndSize()
-			if err != nil {
+			if errors.Is(err, errFileRewritten) {
+				// The tail only detects truncated files if they're smaller
+				// than the position. It's restarted to read the file from the
+				// start, as its fingerprint doesn't match anymore.
+				level.Info(t.logger).Log("msg", "position timer: file was truncated and written again, stopping tailer to read it from the start", "path", t.path)
+				if err := t.tail.Stop(); err != nil {
+					level.Error(t.logger).Log("msg", "position timer: error stopping tailer", "path", t.path, "error", err)
+				}
+				return
+			} else if err != nil {
 				level.Error(t.logger).Log("msg", "position timer: error getting tail position and/or size, stopping tailer", "path", t.path, "error", err)
 				err := t.tail.Stop()
 				if err != nil {
@@ -345,8 +465,34 @@ func (t *tailer) markPositionAndSize() error {
 	// Update metrics and positions file all together to avoid race conditions when `t.tail` is stopped.
 	t.metrics.totalBytes.WithLabelValues(t.path).Set(float64(size))
 	t.metrics.readBytes.WithLabelValues(t.path).Set(float64(pos))
-	t.positions.Put(t.path, t.labelsStr, pos)
+	err = t.putPosition(pos)
+	if os.IsNotExist(err) {
+		level.Info(t.logger).Log("msg", "skipping update of position for a file which does not currently exist", "path", t.path)
+		return nil
+	}
+	return err
+}
+
+// putPosition records the position of the file, along with its fingerprint if
+// files are identified by their fingerprint. It returns errFileRewritten if the
+// file doesn't start with the same bytes anymore while the position didn't go
+// back, which means that the tail missed that the file was truncated.
+func (t *tailer) putPosition(pos int64) error {
+	if t.fingerprintSize == 0 {
+		t.positions.Put(t.path, t.labelsStr, pos)
+		return nil
+	}
 
+	head, err := readHead(t.file, t.fingerprintSize)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(head, t.head) && pos >= t.lastPos {
+		return errFileRewritten
+	}
+	// If the position went back, the tail already reopened the truncated file.
+	t.head, t.lastPos = head, pos
+	t.positions.PutFingerprint(t.path, t.labelsStr, pos, head)
 	return nil
 }
 
@@ -354,7 +500,7 @@ func (t *tailer) stop(done chan struct{}) {
 	// Save the current position before shutting down tailer to ensure that if the file is tailed again
 	// it start where it left off.
 	err := t.markPositionAndSize()
-	if err != nil {
+	if err != nil && !errors.Is(err, errFileRewritten) {
 		level.Error(t.logger).Log("msg", "error marking file position when stopping tailer", "path", t.path, "error", err)
 	}
 
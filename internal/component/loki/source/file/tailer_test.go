@@ -122,6 +122,7 @@ func TestTailer(t *testing.T) {
 			MaxPollFrequency: 25 * time.Millisecond,
 		},
 		false,
+		0,
 		func() bool { return true },
 	)
 	require.NoError(t, err)
@@ -217,6 +218,7 @@ func TestTailerPositionFileEntryDeleted(t *testing.T) {
 			MaxPollFrequency: 25 * time.Millisecond,
 		},
 		false,
+		0,
 		func() bool { return false },
 	)
 	require.NoError(t, err)
@@ -281,6 +283,7 @@ func TestTailerDeleteFileInstant(t *testing.T) {
 			MaxPollFrequency: 25 * time.Millisecond,
 		},
 		false,
+		0,
 		func() bool { return true },
 	)
 	require.NoError(t, err)
@@ -302,3 +305,107 @@ func TestTailerDeleteFileInstant(t *testing.T) {
 		t.Fatal("tailer deadlocked")
 	}
 }
+
+func TestTailerFingerprintPosition(t *testing.T) {
+	l := util.TestLogger(t)
+	tempDir := t.TempDir()
+	positionsFile, err := positions.New(l, positions.Config{
+		SyncPeriod:    time.Minute,
+		PositionsFile: filepath.Join(tempDir, "positions.yaml"),
+	})
+	require.NoError(t, err)
+	defer positionsFile.Stop()
+
+	labels := model.LabelSet{"foo": "bar"}
+	newFingerprintTailer := func(path string) *tailer {
+		tailer, err := newTailer(newMetrics(nil), l, loki.NewLogsReceiver(), positionsFile, path, labels, "",
+			watch.PollingFileWatcherOptions{}, false, 16, func() bool { return true })
+		require.NoError(t, err)
+		return tailer
+	}
+	position := func(tailer *tailer) int64 {
+		tailer.file, err = os.Open(tailer.path)
+		require.NoError(t, err)
+		defer tailer.closeFile()
+		fi, err := tailer.stat()
+		require.NoError(t, err)
+		pos, err := positionsFile.Get(tailer.path, tailer.labelsStr)
+		require.NoError(t, err)
+		pos, err = tailer.fingerprintPosition(fi.Size(), pos)
+		require.NoError(t, err)
+		return pos
+	}
+
+	path := filepath.Join(tempDir, "app.log")
+	content := []byte("first line of the file\nsecond line\n")
+	require.NoError(t, os.WriteFile(path, content, 0600))
+	tailer := newFingerprintTailer(path)
+
+	// Positions recorded without fingerprint are kept.
+	positionsFile.Put(path, labels.String(), 23)
+	require.Equal(t, int64(23), position(tailer))
+
+	// The file matches its fingerprint.
+	positionsFile.PutFingerprint(path, labels.String(), 23, content[:16])
+	require.Equal(t, int64(23), position(tailer))
+
+	// A position beyond the end of the file means that it was truncated.
+	positionsFile.PutFingerprint(path, labels.String(), 100, content[:16])
+	require.Equal(t, int64(0), position(tailer))
+
+	// The file was rotated and replaced by a bigger file.
+	positionsFile.PutFingerprint(path, labels.String(), 23, content[:16])
+	rotatedPath := filepath.Join(tempDir, "app.log.1")
+	require.NoError(t, os.Rename(path, rotatedPath))
+	require.NoError(t, os.WriteFile(path, []byte("a new file with more lines than the rotated file\n"), 0600))
+	require.Equal(t, int64(0), position(tailer))
+
+	// The rotated file is resumed.
+	require.Equal(t, int64(23), position(newFingerprintTailer(rotatedPath)))
+
+	// A new file is read from the start.
+	newPath := filepath.Join(tempDir, "other.log")
+	require.NoError(t, os.WriteFile(newPath, []byte("another file\n"), 0600))
+	require.Equal(t, int64(0), position(newFingerprintTailer(newPath)))
+}
+
+func TestTailerFingerprintRewrittenFile(t *testing.T) {
+	l := util.TestLogger(t)
+	tempDir := t.TempDir()
+	positionsFile, err := positions.New(l, positions.Config{
+		SyncPeriod:    time.Minute,
+		PositionsFile: filepath.Join(tempDir, "positions.yaml"),
+	})
+	require.NoError(t, err)
+	defer positionsFile.Stop()
+
+	path := filepath.Join(tempDir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first line\n"), 0600))
+
+	tailer, err := newTailer(newMetrics(nil), l, loki.NewLogsReceiver(), positionsFile, path, model.LabelSet{"foo": "bar"}, "",
+		watch.PollingFileWatcherOptions{}, false, 16, func() bool { return true })
+	require.NoError(t, err)
+	tailer.file, err = os.Open(path)
+	require.NoError(t, err)
+	defer tailer.closeFile()
+	tailer.head, err = readHead(tailer.file, tailer.fingerprintSize)
+	require.NoError(t, err)
+
+	// The file grows.
+	require.NoError(t, os.WriteFile(path, []byte("first line\nsecond line\n"), 0600))
+	require.NoError(t, tailer.putPosition(11))
+	require.Equal(t, []byte("first line\nsecon"), tailer.head)
+
+	// The file is truncated and written again, and the tail reopened it.
+	require.NoError(t, os.WriteFile(path, []byte("new line\n"), 0600))
+	require.NoError(t, tailer.putPosition(9))
+	fp, ok := positionsFile.GetFingerprint(path, tailer.labelsStr)
+	require.True(t, ok)
+	require.Equal(t, positions.NewFingerprint([]byte("new line\n")), fp)
+
+	// The file is truncated and written again past the position, which the
+	// tail can't detect.
+	require.NoError(t, os.WriteFile(path, []byte("another new line\n"), 0600))
+	require.ErrorIs(t, tailer.putPosition(9), errFileRewritten)
+	require.Equal(t, "9", positionsFile.GetString(path, tailer.labelsStr))
+}
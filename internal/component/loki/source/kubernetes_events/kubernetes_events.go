@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runner"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/oklog/run"
 	"k8s.io/client-go/rest"
 )
@@ -49,6 +50,8 @@ type Arguments struct {
 
 	// Client settings to connect to Kubernetes.
 	Client kubernetes.ClientArguments `alloy:"client,block,optional"`
+
+	Clustering cluster.SingletonBlock `alloy:"clustering,block,optional"`
 }
 
 // DefaultArguments holds default settings for loki.source.kubernetes_events.
@@ -81,6 +84,7 @@ func (args *Arguments) Validate() error {
 type Component struct {
 	log        log.Logger
 	opts       component.Options
+	cluster    cluster.Cluster
 	positions  positions.Positions
 	handler    loki.LogsReceiver
 	runner     *runner.Runner[eventControllerTask]
@@ -89,6 +93,7 @@ type Component struct {
 	mut        sync.Mutex
 	args       Arguments
 	restConfig *rest.Config
+	singleton  *cluster.Singleton // Set when the component runs on a single node of the cluster.
 
 	tasksMut sync.RWMutex
 	tasks    []eventControllerTask
@@ -100,6 +105,7 @@ type Component struct {
 var (
 	_ component.Component      = (*Component)(nil)
 	_ component.DebugComponent = (*Component)(nil)
+	_ cluster.Component        = (*Component)(nil)
 )
 
 // New creates a new loki.source.kubernetes_events component.
@@ -116,9 +122,15 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	data, err := o.GetServiceData(cluster.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Component{
 		log:       o.Logger,
 		opts:      o,
+		cluster:   data.(cluster.Cluster),
 		positions: positionsFile,
 		handler:   loki.NewLogsReceiver(),
 		runner: runner.New(func(t eventControllerTask) runner.Worker {
@@ -139,6 +151,7 @@ func (c *Component) Run(ctx context.Context) error {
 
 	defer c.positions.Stop()
 	defer c.runner.Stop()
+	defer c.closeSingleton()
 
 	var rg run.Group
 
@@ -149,9 +162,7 @@ func (c *Component) Run(ctx context.Context) error {
 			case <-ctx.Done():
 				return nil
 			case <-c.newTasksCh:
-				c.tasksMut.RLock()
-				tasks := c.tasks
-				c.tasksMut.RUnlock()
+				tasks := c.activeTasks()
 
 				if err := c.runner.ApplyTasks(ctx, tasks); err != nil {
 					level.Error(c.log).Log("msg", "failed to apply event watchers", "err", err)
@@ -226,14 +237,78 @@ func (c *Component) Update(args component.Arguments) error {
 	c.tasks = newTasks
 	c.tasksMut.Unlock()
 
+	if newArgs.Clustering.Singleton && c.singleton == nil {
+		c.singleton = cluster.NewSingleton(c.cluster, c.opts.ID)
+		c.updateSingleton()
+	} else if !newArgs.Clustering.Singleton && c.singleton != nil {
+		c.singleton.Close()
+		c.singleton = nil
+	}
+
+	c.reloadTasks()
+
+	c.args = newArgs
+	return nil
+}
+
+// NotifyClusterChange implements cluster.Component.
+func (c *Component) NotifyClusterChange() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.singleton == nil {
+		return
+	}
+	if c.updateSingleton() {
+		c.reloadTasks()
+	}
+}
+
+// updateSingleton checks whether the local node owns the component, and
+// returns true if ownership changed. updateSingleton must only be called when
+// c.mut is held.
+func (c *Component) updateSingleton() bool {
+	changed, err := c.singleton.Update()
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to check ownership of the component", "err", err)
+	}
+	if changed {
+		level.Info(c.log).Log("msg", "ownership of the component changed", "is_owner", c.singleton.IsOwner())
+	}
+	return changed
+}
+
+func (c *Component) closeSingleton() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.singleton != nil {
+		c.singleton.Close()
+		c.singleton = nil
+	}
+}
+
+// activeTasks returns the tasks to run. No task runs when the component runs
+// on a single node of the cluster which isn't the local node.
+func (c *Component) activeTasks() []eventControllerTask {
+	c.mut.Lock()
+	singleton := c.singleton
+	c.mut.Unlock()
+	if singleton != nil && !singleton.IsOwner() {
+		return nil
+	}
+
+	c.tasksMut.RLock()
+	defer c.tasksMut.RUnlock()
+	return c.tasks
+}
+
+func (c *Component) reloadTasks() {
 	select {
 	case c.newTasksCh <- struct{}{}:
 	default:
 		// no-op: task reload already queued.
 	}
-
-	c.args = newArgs
-	return nil
 }
 
 // getNamespaces gets a list of namespaces to watch from the arguments. If the
@@ -9,14 +9,12 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
-	"github.com/grafana/ckit/shard"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/instrument"
 	promExternalVersions "github.com/prometheus-operator/prometheus-operator/pkg/client/informers/externalversions"
 	promListers "github.com/prometheus-operator/prometheus-operator/pkg/client/listers/monitoring/v1"
 	promVersioned "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
 	"github.com/prometheus/client_golang/prometheus"
-	"go.uber.org/atomic"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
@@ -191,6 +189,8 @@ func newNoInit(o component.Options, args Arguments) (*Component, error) {
 }
 
 func (c *Component) Run(ctx context.Context) error {
+	defer c.leader.close()
+
 	c.startupWithRetries(ctx, c.leader, c, c)
 
 	for {
@@ -501,41 +501,39 @@ type leadership interface {
 
 	// isLeader returns true if this component instance is the leader, false otherwise.
 	isLeader() bool
+
+	// close stops taking part in the leadership election.
+	close()
 }
 
-// componentLeadership implements leadership based on checking ownership of a specific
-// key using a cluster.Cluster service.
+// componentLeadership implements leadership based on checking ownership of the
+// component ID using a cluster.Singleton.
 type componentLeadership struct {
-	id      string
-	logger  log.Logger
-	cluster cluster.Cluster
-	leader  atomic.Bool
+	logger    log.Logger
+	singleton *cluster.Singleton
 }
 
-func newComponentLeadership(id string, logger log.Logger, cluster cluster.Cluster) *componentLeadership {
+func newComponentLeadership(id string, logger log.Logger, c cluster.Cluster) *componentLeadership {
 	return &componentLeadership{
-		id:      id,
-		logger:  logger,
-		cluster: cluster,
+		logger:    logger,
+		singleton: cluster.NewSingleton(c, id),
 	}
 }
 
 func (l *componentLeadership) update() (bool, error) {
-	// NOTE: since this is leader election, it is okay to NOT check if cluster is ready.
-	peers, err := l.cluster.Lookup(shard.StringKey(l.id), 1, shard.OpReadWrite)
+	changed, err := l.singleton.Update()
 	if err != nil {
-		return false, fmt.Errorf("unable to determine leader for %s: %w", l.id, err)
-	}
-
-	if len(peers) != 1 {
-		return false, fmt.Errorf("unexpected peers from leadership check: %+v", peers)
+		return false, err
 	}
 
-	isLeader := peers[0].Self
-	level.Info(l.logger).Log("msg", "checked leadership of component", "is_leader", isLeader)
-	return l.leader.Swap(isLeader) != isLeader, nil
+	level.Info(l.logger).Log("msg", "checked leadership of component", "is_leader", l.singleton.IsOwner())
+	return changed, nil
 }
 
 func (l *componentLeadership) isLeader() bool {
-	return l.leader.Load()
+	return l.singleton.IsOwner()
+}
+
+func (l *componentLeadership) close() {
+	l.singleton.Close()
 }
@@ -134,6 +134,8 @@ func (f *fakeLeadership) isLeader() bool {
 	return f.leader
 }
 
+func (f *fakeLeadership) close() {}
+
 type fakeLifecycle struct {
 	updateCalled    atomic.Bool
 	startupCalled   atomic.Bool
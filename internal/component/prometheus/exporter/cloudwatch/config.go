@@ -11,6 +11,7 @@ import (
 	yaceModel "github.com/nerdswords/yet-another-cloudwatch-exporter/pkg/model"
 
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/internal/static/integrations/cloudwatch_exporter"
 	"github.com/grafana/alloy/syntax"
 )
@@ -31,15 +32,21 @@ var defaults = Arguments{
 
 // Arguments are the Alloy based options to configure the embedded CloudWatch exporter.
 type Arguments struct {
-	STSRegion             string                `alloy:"sts_region,attr"`
-	FIPSDisabled          bool                  `alloy:"fips_disabled,attr,optional"`
-	Debug                 bool                  `alloy:"debug,attr,optional"`
-	DiscoveryExportedTags TagsPerNamespace      `alloy:"discovery_exported_tags,attr,optional"`
-	Discovery             []DiscoveryJob        `alloy:"discovery,block,optional"`
-	Static                []StaticJob           `alloy:"static,block,optional"`
-	CustomNamespace       []CustomNamespaceJob  `alloy:"custom_namespace,block,optional"`
-	DecoupledScrape       DecoupledScrapeConfig `alloy:"decoupled_scraping,block,optional"`
-	UseAWSSDKVersion2     bool                  `alloy:"aws_sdk_version_v2,attr,optional"`
+	STSRegion             string                 `alloy:"sts_region,attr"`
+	FIPSDisabled          bool                   `alloy:"fips_disabled,attr,optional"`
+	Debug                 bool                   `alloy:"debug,attr,optional"`
+	DiscoveryExportedTags TagsPerNamespace       `alloy:"discovery_exported_tags,attr,optional"`
+	Discovery             []DiscoveryJob         `alloy:"discovery,block,optional"`
+	Static                []StaticJob            `alloy:"static,block,optional"`
+	CustomNamespace       []CustomNamespaceJob   `alloy:"custom_namespace,block,optional"`
+	DecoupledScrape       DecoupledScrapeConfig  `alloy:"decoupled_scraping,block,optional"`
+	UseAWSSDKVersion2     bool                   `alloy:"aws_sdk_version_v2,attr,optional"`
+	Clustering            cluster.SingletonBlock `alloy:"clustering,block,optional"`
+}
+
+// ClusteringSingleton implements exporter.SingletonArguments.
+func (a Arguments) ClusteringSingleton() bool {
+	return a.Clustering.Singleton
 }
 
 // DecoupledScrapeConfig is the configuration for decoupled scraping feature.
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/discovery"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/cluster"
 	http_service "github.com/grafana/alloy/internal/service/http"
 	"github.com/grafana/alloy/internal/static/integrations"
 )
@@ -26,6 +27,14 @@ type Exports struct {
 	Targets []discovery.Target `alloy:"targets,attr"`
 }
 
+// SingletonArguments is implemented by the arguments of exporters which can
+// be configured to run on a single node of the cluster.
+type SingletonArguments interface {
+	// ClusteringSingleton returns true if the exporter runs only on the node
+	// of the cluster owning it.
+	ClusteringSingleton() bool
+}
+
 type Component struct {
 	opts component.Options
 
@@ -39,8 +48,15 @@ type Component struct {
 
 	exporter       integrations.Integration
 	metricsHandler http.Handler
+
+	// singleton is set when the exporter runs on a single node of the
+	// cluster. Other nodes don't run the exporter and export no targets.
+	singleton *cluster.Singleton
+	targets   []discovery.Target
 }
 
+var _ cluster.Component = (*Component)(nil)
+
 // New creates a new exporter component.
 func New(creator Creator, name string) func(component.Options, component.Arguments) (component.Component, error) {
 	return newExporter(creator, name, nil)
@@ -54,6 +70,8 @@ func NewWithTargetBuilder(creator Creator, name string, targetBuilderFunc func(d
 
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
+	defer c.closeSingleton()
+
 	var cancel context.CancelFunc
 	for {
 		select {
@@ -74,7 +92,13 @@ func (c *Component) Run(ctx context.Context) error {
 			c.mut.Lock()
 			exporter := c.exporter
 			c.metricsHandler = c.getHttpHandler(exporter)
+			owner := c.singleton == nil || c.singleton.IsOwner()
 			c.mut.Unlock()
+
+			// only the owner of a singleton exporter runs it
+			if !owner {
+				continue
+			}
 			go func() {
 				if err := exporter.Run(newCtx); err != nil && err != context.Canceled {
 					level.Error(c.opts.Logger).Log("msg", "error running exporter", "err", err)
@@ -98,22 +122,99 @@ func (c *Component) Update(args component.Arguments) error {
 		c.baseTarget = tb.Target()
 	}
 
-	var targets []discovery.Target
 	if c.targetBuilderFunc == nil {
-		targets = []discovery.Target{c.baseTarget}
+		c.targets = []discovery.Target{c.baseTarget}
 	} else {
-		targets = c.targetBuilderFunc(c.baseTarget, args)
+		c.targets = c.targetBuilderFunc(c.baseTarget, args)
+	}
+
+	if err := c.updateSingleton(args); err != nil {
+		c.mut.Unlock()
+		return err
+	}
+	c.exportTargets()
+	c.mut.Unlock()
+	c.triggerReload()
+	return err
+}
+
+// NotifyClusterChange implements cluster.Component.
+func (c *Component) NotifyClusterChange() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.singleton == nil {
+		return
+	}
+	if c.checkOwnership() {
+		c.exportTargets()
+		c.triggerReload()
+	}
+}
+
+// updateSingleton starts or stops electing an owner for the exporter
+// depending on args. updateSingleton must only be called when c.mut is held.
+func (c *Component) updateSingleton(args component.Arguments) error {
+	sa, ok := args.(SingletonArguments)
+	singleton := ok && sa.ClusteringSingleton()
+
+	switch {
+	case singleton && c.singleton == nil:
+		data, err := c.opts.GetServiceData(cluster.ServiceName)
+		if err != nil {
+			return fmt.Errorf("failed to get cluster information: %w", err)
+		}
+		c.singleton = cluster.NewSingleton(data.(cluster.Cluster), c.opts.ID)
+		c.checkOwnership()
+	case !singleton && c.singleton != nil:
+		c.singleton.Close()
+		c.singleton = nil
 	}
+	return nil
+}
 
+// checkOwnership checks whether the local node owns the exporter, and returns
+// true if ownership changed. checkOwnership must only be called when c.mut is
+// held.
+func (c *Component) checkOwnership() bool {
+	changed, err := c.singleton.Update()
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to check ownership of the exporter", "err", err)
+	}
+	if changed {
+		level.Info(c.opts.Logger).Log("msg", "ownership of the exporter changed", "is_owner", c.singleton.IsOwner())
+	}
+	return changed
+}
+
+// exportTargets exports the targets of the exporter, or no targets if another
+// node of the cluster runs the exporter. exportTargets must only be called
+// when c.mut is held.
+func (c *Component) exportTargets() {
+	targets := c.targets
+	if c.singleton != nil && !c.singleton.IsOwner() {
+		targets = []discovery.Target{}
+	}
 	c.opts.OnStateChange(Exports{
 		Targets: targets,
 	})
-	c.mut.Unlock()
+}
+
+func (c *Component) closeSingleton() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.singleton != nil {
+		c.singleton.Close()
+		c.singleton = nil
+	}
+}
+
+func (c *Component) triggerReload() {
 	select {
 	case c.reload <- struct{}{}:
 	default:
 	}
-	return err
 }
 
 // Handler serves metrics endpoint from the integration implementation.
@@ -0,0 +1,132 @@
+// Package cluster_kv implements the remote.cluster_kv component.
+package cluster_kv
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service/cluster"
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "remote.cluster_kv",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments control the remote.cluster_kv component.
+type Arguments struct {
+	Prefix   string `alloy:"prefix,attr,optional"`
+	IsSecret bool   `alloy:"is_secret,attr,optional"`
+}
+
+// Exports holds settings exported by remote.cluster_kv.
+type Exports struct {
+	Data map[string]alloytypes.OptionalSecret `alloy:"data,attr"`
+}
+
+// Component implements the remote.cluster_kv component.
+type Component struct {
+	opts component.Options
+	kv   *cluster.KV
+
+	mut         sync.Mutex
+	args        Arguments
+	lastExports Exports
+
+	// updated is written to whenever args updates.
+	updated chan struct{}
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New returns a new, unstarted, remote.cluster_kv component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	data, err := opts.GetServiceData(cluster.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get information about cluster: %w", err)
+	}
+	kv, ok := cluster.KVStore(data.(cluster.Cluster))
+	if !ok {
+		return nil, fmt.Errorf("the cluster doesn't provide a KV store")
+	}
+
+	c := &Component{
+		opts:    opts,
+		kv:      kv,
+		updated: make(chan struct{}, 1),
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run starts the remote.cluster_kv component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		// Get the channel before exporting so that changes made in between
+		// aren't missed.
+		changed := c.kv.Changed()
+		c.export()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-c.updated:
+		}
+	}
+}
+
+// export exports the keys of the KV store matching the prefix, if they
+// changed since the last export.
+func (c *Component) export() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	values := c.kv.List(c.args.Prefix)
+	newExports := Exports{Data: make(map[string]alloytypes.OptionalSecret, len(values))}
+	for key, value := range values {
+		newExports.Data[strings.TrimPrefix(key, c.args.Prefix)] = alloytypes.OptionalSecret{
+			IsSecret: c.args.IsSecret,
+			Value:    value,
+		}
+	}
+
+	// Only send a state change event if the exports have changed.
+	if c.lastExports.Data != nil && maps.Equal(c.lastExports.Data, newExports.Data) {
+		return
+	}
+	c.lastExports = newExports
+	c.opts.OnStateChange(newExports)
+}
+
+// Update updates the remote.cluster_kv component. The exports are updated
+// before Update returns, so that downstream components are evaluated with the
+// current content of the KV store.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	c.args = args.(Arguments)
+	c.mut.Unlock()
+
+	c.export()
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
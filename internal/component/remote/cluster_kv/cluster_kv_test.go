@@ -0,0 +1,79 @@
+package cluster_kv_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/remote/cluster_kv"
+	"github.com/grafana/alloy/internal/service/cluster"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/stretchr/testify/require"
+)
+
+func Test(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := cluster.Mock()
+	kv, ok := cluster.KVStore(c)
+	require.True(t, ok)
+	require.NoError(t, kv.Set("cursors/a", "1"))
+	require.NoError(t, kv.Set("other", "2"))
+
+	exports := make(chan component.Exports, 10)
+	opts := component.Options{
+		ID:            "remote.cluster_kv.test",
+		Logger:        util.TestLogger(t),
+		OnStateChange: func(e component.Exports) { exports <- e },
+		GetServiceData: func(name string) (interface{}, error) {
+			switch name {
+			case cluster.ServiceName:
+				return c, nil
+			default:
+				return nil, fmt.Errorf("service %q does not exist", name)
+			}
+		},
+	}
+
+	comp, err := cluster_kv.New(opts, cluster_kv.Arguments{Prefix: "cursors/"})
+	require.NoError(t, err)
+	go func() { _ = comp.Run(ctx) }()
+
+	requireExports := func(expect map[string]alloytypes.OptionalSecret) {
+		select {
+		case e := <-exports:
+			require.Equal(t, cluster_kv.Exports{Data: expect}, e)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for exports")
+		}
+	}
+
+	// The exports are set when the component is built, without the prefix.
+	requireExports(map[string]alloytypes.OptionalSecret{
+		"a": {Value: "1"},
+	})
+
+	// Writes to the KV store are exported.
+	require.NoError(t, kv.Set("cursors/b", "3"))
+	requireExports(map[string]alloytypes.OptionalSecret{
+		"a": {Value: "1"},
+		"b": {Value: "3"},
+	})
+
+	kv.Delete("cursors/a")
+	requireExports(map[string]alloytypes.OptionalSecret{
+		"b": {Value: "3"},
+	})
+
+	// Writes to other keys don't change the exports.
+	require.NoError(t, kv.Set("other", "4"))
+	require.NoError(t, comp.Update(cluster_kv.Arguments{Prefix: "cursors/", IsSecret: true}))
+	requireExports(map[string]alloytypes.OptionalSecret{
+		"b": {Value: "3", IsSecret: true},
+	})
+	require.Empty(t, exports)
+}
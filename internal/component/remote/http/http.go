@@ -95,6 +95,7 @@ type Component struct {
 	cli         *http.Client
 	lastPoll    time.Time
 	lastExports Exports // Used for determining whether exports should be updated
+	verify      Verifier
 
 	// Updated is written to whenever args updates.
 	updated chan struct{}
@@ -108,11 +109,23 @@ var (
 	_ component.HealthComponent = (*Component)(nil)
 )
 
+// Verifier verifies the body of a response before it's exported. cli is the
+// HTTP client of the component, which can be used to fetch additional data
+// such as signatures.
+type Verifier func(ctx context.Context, cli *http.Client, body []byte) error
+
 // New returns a new, unstarted, remote.http component.
 func New(opts component.Options, args Arguments) (*Component, error) {
+	return NewVerified(opts, args, nil)
+}
+
+// NewVerified returns a new, unstarted, remote.http component which only
+// exports the responses accepted by verify.
+func NewVerified(opts component.Options, args Arguments, verify Verifier) (*Component, error) {
 	c := &Component{
-		log:  opts.Logger,
-		opts: opts,
+		log:    opts.Logger,
+		opts:   opts,
+		verify: verify,
 
 		updated: make(chan struct{}, 1),
 
@@ -228,6 +241,13 @@ func (c *Component) pollError() error {
 		return fmt.Errorf("unexpected status code %s", resp.Status)
 	}
 
+	if c.verify != nil {
+		if err := c.verify(ctx, c.cli, bb); err != nil {
+			level.Error(c.log).Log("msg", "failed to verify response", "err", err)
+			return fmt.Errorf("verifying response: %w", err)
+		}
+	}
+
 	stringContent := strings.TrimSpace(string(bb))
 
 	newExports := Exports{
@@ -0,0 +1,50 @@
+package component
+
+import (
+	"github.com/grafana/alloy/syntax/jsonschema"
+)
+
+// Extensions used in the schemas of components.
+const (
+	// SchemaExtStability holds the stability level of the component.
+	SchemaExtStability = "x-alloy-stability"
+	// SchemaExtCommunity is true for community components.
+	SchemaExtCommunity = "x-alloy-community"
+	// SchemaExtExports holds the schema of the exports of the component.
+	SchemaExtExports = "x-alloy-exports"
+)
+
+// Schema returns a JSON Schema describing the arguments of the component. The
+// schema reflects the attributes and blocks of the arguments along with their
+// defaults, and describes the stability level of the component and its
+// exports with extension keywords.
+func (r Registration) Schema() *jsonschema.Schema {
+	var s *jsonschema.Schema
+	if r.Args != nil {
+		s = jsonschema.Body(r.Args)
+	} else {
+		s = &jsonschema.Schema{Type: "object", AdditionalProperties: false}
+	}
+	s.Schema = jsonschema.Draft
+	s.Title = r.Name
+
+	if r.Community {
+		s.SetExtension(SchemaExtCommunity, true)
+	} else {
+		s.SetExtension(SchemaExtStability, r.Stability.Name())
+	}
+	if r.Exports != nil {
+		s.SetExtension(SchemaExtExports, jsonschema.Body(r.Exports))
+	}
+	return s
+}
+
+// AllSchemas returns the schemas of all registered components, keyed by the
+// component name.
+func AllSchemas() map[string]*jsonschema.Schema {
+	schemas := make(map[string]*jsonschema.Schema, len(registered))
+	for name, reg := range registered {
+		schemas[name] = reg.Schema()
+	}
+	return schemas
+}
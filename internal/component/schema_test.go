@@ -0,0 +1,66 @@
+package component
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaArgs struct {
+	URL     string        `alloy:"url,attr"`
+	Timeout time.Duration `alloy:"timeout,attr,optional"`
+}
+
+func (a *schemaArgs) SetToDefault() {
+	*a = schemaArgs{Timeout: 10 * time.Second}
+}
+
+type schemaExports struct {
+	Content string `alloy:"content,attr"`
+}
+
+func TestRegistration_Schema(t *testing.T) {
+	reg := Registration{
+		Name:      "remote.test",
+		Stability: featuregate.StabilityPublicPreview,
+		Args:      schemaArgs{},
+		Exports:   schemaExports{},
+	}
+
+	bb, err := json.Marshal(reg.Schema())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "remote.test",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["url"],
+		"properties": {
+			"url":     {"type": "string"},
+			"timeout": {"type": "string", "default": "10s"}
+		},
+		"x-alloy-stability": "public-preview",
+		"x-alloy-exports": {
+			"type": "object",
+			"additionalProperties": false,
+			"required": ["content"],
+			"properties": {
+				"content": {"type": "string"}
+			}
+		}
+	}`, string(bb))
+
+	reg = Registration{Name: "community.test", Community: true}
+	bb, err = json.Marshal(reg.Schema())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "community.test",
+		"type": "object",
+		"additionalProperties": false,
+		"x-alloy-community": true
+	}`, string(bb))
+}
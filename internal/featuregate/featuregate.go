@@ -82,6 +82,12 @@ func (s Stability) String() string {
 	return "<invalid_stability_level>"
 }
 
+// Name returns the name of the stability level as accepted by the --stability.level command-line flag, without
+// quotes. An empty string is returned for undefined stability levels.
+func (s Stability) Name() string {
+	return stabilityToString[s]
+}
+
 // Set implements the pflag.Value interface.
 func (s *Stability) Set(str string) error {
 	for k, v := range stabilityToString {
@@ -0,0 +1,224 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/argument"
+	"github.com/grafana/alloy/internal/nodeconf/export"
+	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+// declareBlockName is the name of the blocks declaring custom components.
+const declareBlockName = "declare"
+
+// configBlocks holds the arguments of the config blocks which aren't
+// components or services.
+var configBlocks = map[string]any{
+	argument.BlockName: argument.Arguments{},
+	export.BlockName:   export.Arguments{},
+	foreach.BlockName:  foreach.Arguments{},
+	function.BlockName: function.Arguments{},
+}
+
+// scope describes the location of a position in a document.
+type scope struct {
+	// path holds the blocks enclosing the position, outermost first.
+	path []*ast.BlockStmt
+	// body is the body of the innermost block, or the document's body.
+	body ast.Body
+
+	// args is the Go type of the innermost block when the position is inside
+	// a block with a known schema. args is nil when the position is in a body
+	// holding components, such as the document's body or the body of a
+	// declare block.
+	args reflect.Type
+	// unknown is true if the position is inside a block with an unknown
+	// schema.
+	unknown bool
+}
+
+// scopeAt returns the scope of the position at offset in f.
+func (s *Server) scopeAt(f *ast.File, offset int) scope {
+	sc := scope{body: f.Body}
+
+	for {
+		var inner *ast.BlockStmt
+		for _, stmt := range sc.body {
+			b, ok := stmt.(*ast.BlockStmt)
+			if ok && b.LCurlyPos.Valid() && b.LCurlyPos.Offset() < offset && offset <= b.RCurlyPos.Offset() {
+				inner = b
+				break
+			}
+		}
+		if inner == nil {
+			return sc
+		}
+
+		sc.path = append(sc.path, inner)
+		sc.body = inner.Body
+		if sc.unknown {
+			continue
+		}
+
+		name := inner.GetBlockName()
+		switch {
+		case sc.args == nil && name == declareBlockName:
+			// Declare blocks hold components.
+		case sc.args == reflect.TypeFor[foreach.Arguments]() && name == foreach.TypeTemplate:
+			sc.args = nil
+		case sc.args == nil:
+			sc.args = s.blockArgs(name)
+			sc.unknown = sc.args == nil
+		default:
+			parent := sc.args
+			sc.args = nil
+			for _, field := range typecheck.Fields(parent) {
+				if field.Block && field.Name == name {
+					sc.args = field.Type
+				}
+			}
+			sc.unknown = sc.args == nil
+		}
+	}
+}
+
+// blockArgs returns the Go type of the arguments of a top-level block, such
+// as a component or a service. nil is returned for unknown blocks.
+func (s *Server) blockArgs(name string) reflect.Type {
+	if args, ok := configBlocks[name]; ok {
+		return reflect.TypeOf(args)
+	}
+	for _, def := range s.opts.ServiceDefinitions {
+		if def.Name == name && def.ConfigType != nil {
+			return reflect.TypeOf(def.ConfigType)
+		}
+	}
+	if s.opts.ComponentRegistry == nil {
+		return nil
+	}
+	reg, err := s.opts.ComponentRegistry.Get(name)
+	if err != nil || reg.Args == nil {
+		return nil
+	}
+	return reflect.TypeOf(reg.Args)
+}
+
+// completion returns the completion items at pos: the attributes and blocks
+// of the enclosing block, or the components which can be declared when pos
+// isn't inside a block.
+func (s *Server) completion(doc *document, pos Position) []CompletionItem {
+	offset := doc.Offset(pos)
+	f := doc.ParseAround(offset)
+	if f == nil {
+		return []CompletionItem{}
+	}
+
+	sc := s.scopeAt(f, offset)
+	switch {
+	case sc.unknown:
+		return []CompletionItem{}
+	case sc.args != nil:
+		items := fieldItems(sc.args)
+		if sc.args == reflect.TypeFor[foreach.Arguments]() {
+			items = append(items, CompletionItem{
+				Label:            foreach.TypeTemplate,
+				Kind:             completionKindStruct,
+				Detail:           "block",
+				InsertText:       foreach.TypeTemplate + " {\n\t$0\n}",
+				InsertTextFormat: insertTextFormatSnippet,
+			})
+		}
+		return items
+	default:
+		return s.componentItems(f, sc)
+	}
+}
+
+// fieldItems returns the completion items of the attributes and blocks of
+// the arguments type args.
+func fieldItems(args reflect.Type) []CompletionItem {
+	fields := typecheck.Fields(args)
+
+	items := make([]CompletionItem, 0, len(fields))
+	for _, field := range fields {
+		var (
+			detail = "attribute"
+			insert = field.Name + " = $0"
+		)
+		if field.Block {
+			detail = "block"
+			insert = field.Name + " {\n\t$0\n}"
+		}
+		if !field.Optional {
+			detail = "required " + detail
+		}
+
+		kind := completionKindField
+		if field.Block {
+			kind = completionKindStruct
+		}
+		items = append(items, CompletionItem{
+			Label:            field.Name,
+			Kind:             kind,
+			Detail:           detail,
+			InsertText:       insert,
+			InsertTextFormat: insertTextFormatSnippet,
+		})
+	}
+	return items
+}
+
+// componentItems returns the completion items of the components which can be
+// used in the scope sc: builtin components and custom components declared in
+// the scope.
+func (s *Server) componentItems(f *ast.File, sc scope) []CompletionItem {
+	var items []CompletionItem
+	for _, name := range s.opts.ComponentNames {
+		if s.opts.ComponentRegistry == nil {
+			break
+		}
+		reg, err := s.opts.ComponentRegistry.Get(name)
+		if err != nil {
+			continue
+		}
+		items = append(items, componentItem(name, stabilityDetail(reg)))
+	}
+
+	// Custom components declared in enclosing bodies can be used as well.
+	for _, body := range visibleBodies(sc, f) {
+		for _, stmt := range body {
+			if b, ok := stmt.(*ast.BlockStmt); ok && b.GetBlockName() == declareBlockName && b.Label != "" {
+				items = append(items, componentItem(b.Label, "custom component"))
+			}
+		}
+	}
+
+	if items == nil {
+		items = []CompletionItem{}
+	}
+	return items
+}
+
+func componentItem(name string, detail string) CompletionItem {
+	return CompletionItem{
+		Label:            name,
+		Kind:             completionKindModule,
+		Detail:           detail,
+		InsertText:       fmt.Sprintf("%s \"${1:default}\" {\n\t$0\n}", name),
+		InsertTextFormat: insertTextFormatSnippet,
+	}
+}
+
+// stabilityDetail describes the stability of a component, such as
+// "generally-available component".
+func stabilityDetail(reg component.Registration) string {
+	if reg.Community {
+		return "community component"
+	}
+	return reg.Stability.Name() + " component"
+}
@@ -0,0 +1,139 @@
+package lsp
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// document is an open text document.
+type document struct {
+	uri  string
+	text []byte
+
+	// lines holds the byte offset of the start of each line.
+	lines []int
+
+	// file is the parsed content of the document, or nil if the document
+	// can't be parsed.
+	file     *ast.File
+	parseErr error
+}
+
+func newDocument(uri string, text []byte) *document {
+	doc := &document{uri: uri, text: text, lines: []int{0}}
+	for i, b := range text {
+		if b == '\n' {
+			doc.lines = append(doc.lines, i+1)
+		}
+	}
+	doc.file, doc.parseErr = parser.ParseFile(uri, text)
+	return doc
+}
+
+// Offset converts pos to a byte offset into the document. Positions past the
+// end of a line are clamped to the end of the line.
+func (d *document) Offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	start, end := d.lineBounds(pos.Line)
+	offset, units := start, 0
+	for offset < end && units < pos.Character {
+		r, size := utf8.DecodeRune(d.text[offset:end])
+		offset += size
+		units += utf16.RuneLen(r)
+	}
+	return offset
+}
+
+// Position converts a byte offset into the document to a Position.
+func (d *document) Position(offset int) Position {
+	offset = max(0, min(offset, len(d.text)))
+
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	start := d.lines[line]
+
+	units := 0
+	for i := start; i < offset; {
+		r, size := utf8.DecodeRune(d.text[i:offset])
+		i += size
+		units += utf16.RuneLen(r)
+	}
+	return Position{Line: line, Character: units}
+}
+
+// Range returns the range covering the bytes from start up to and including
+// end. A position can be invalid for nodes which couldn't be parsed, in which
+// case the range is empty.
+func (d *document) Range(start, end token.Pos) Range {
+	if !start.Valid() {
+		return Range{}
+	}
+	if !end.Valid() || end.Offset() < start.Offset() {
+		end = start
+	}
+	return Range{
+		Start: d.Position(start.Offset()),
+		End:   d.Position(end.Offset() + 1),
+	}
+}
+
+// NodeRange returns the range of an AST node.
+func (d *document) NodeRange(n ast.Node) Range {
+	return d.Range(ast.StartPos(n), ast.EndPos(n))
+}
+
+// FullRange returns the range of the whole document.
+func (d *document) FullRange() Range {
+	return Range{End: d.Position(len(d.text))}
+}
+
+// ParseAround parses the document for requests made at offset. If the
+// document can't be parsed, it's parsed again with the line containing offset
+// blanked out, as the line being edited is often incomplete. nil is returned
+// if the document still can't be parsed.
+func (d *document) ParseAround(offset int) *ast.File {
+	if d.file != nil {
+		return d.file
+	}
+
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	start, end := d.lineBounds(line)
+
+	// Replace the line with spaces so the offsets of the rest of the document
+	// don't change.
+	text := make([]byte, len(d.text))
+	copy(text, d.text)
+	for i := start; i < end; i++ {
+		text[i] = ' '
+	}
+
+	f, err := parser.ParseFile(d.uri, text)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// lineBounds returns the byte offsets of the start and the end of a line,
+// excluding the line terminator.
+func (d *document) lineBounds(line int) (start, end int) {
+	start = d.lines[line]
+	end = len(d.text)
+	if line+1 < len(d.lines) {
+		end = d.lines[line+1] - 1
+	}
+	if end > start && d.text[end-1] == '\r' {
+		end--
+	}
+	return start, end
+}
@@ -0,0 +1,30 @@
+package lsp
+
+import (
+	"bytes"
+
+	"github.com/grafana/alloy/syntax/printer"
+)
+
+// format formats doc the same way as the fmt command. The result replaces the
+// whole document.
+func (s *Server) format(doc *document) ([]TextEdit, error) {
+	if doc.parseErr != nil {
+		return nil, doc.parseErr
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, doc.file); err != nil {
+		return nil, err
+	}
+	// Add a newline at the end of the file.
+	_ = buf.WriteByte('\n')
+
+	if bytes.Equal(buf.Bytes(), doc.text) {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{
+		Range:   doc.FullRange(),
+		NewText: buf.String(),
+	}}, nil
+}
@@ -0,0 +1,225 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/syntax/ast"
+)
+
+// target is the symbol found at a position of a document: either the name of
+// a block or a reference to a value.
+type target struct {
+	offset int
+
+	block     *ast.BlockStmt
+	traversal []*ast.Ident
+	call      bool // traversal is the name of a called function.
+}
+
+var _ ast.Visitor = (*target)(nil)
+
+// Visit implements ast.Visitor.
+func (t *target) Visit(node ast.Node) ast.Visitor {
+	if node == nil || t.block != nil || t.traversal != nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *ast.File, ast.Body, *ast.AttributeStmt:
+		return t
+
+	case *ast.BlockStmt:
+		nameEnd := n.NamePos.Offset() + len(n.GetBlockName())
+		if n.NamePos.Valid() && n.NamePos.Offset() <= t.offset && t.offset <= nameEnd {
+			t.block = n
+			return nil
+		}
+		if n.LCurlyPos.Valid() && n.LCurlyPos.Offset() < t.offset && t.offset <= n.RCurlyPos.Offset() {
+			return t
+		}
+		return nil
+
+	case *ast.CallExpr:
+		if !t.contains(n) {
+			return nil
+		}
+		if traversal, ok := ast.Traversal(n.Value); ok && t.contains(n.Value) {
+			t.traversal, t.call = traversal, true
+			return nil
+		}
+		return t
+
+	case ast.Expr:
+		if !t.contains(n) {
+			return nil
+		}
+		if traversal, ok := ast.Traversal(n); ok {
+			t.traversal = traversal
+			return nil
+		}
+		return t
+	}
+	return nil
+}
+
+// contains reports whether the offset of t is within n or right after it.
+func (t *target) contains(n ast.Node) bool {
+	start, end := ast.StartPos(n), ast.EndPos(n)
+	return start.Valid() && start.Offset() <= t.offset && t.offset <= end.Offset()+1
+}
+
+func findTarget(f *ast.File, offset int) *target {
+	t := &target{offset: offset}
+	ast.Walk(t, f)
+	return t
+}
+
+// definition returns the location of the block defining the symbol at pos:
+// the component referenced by an expression, the declare block of a custom
+// component or the function block of a called function.
+func (s *Server) definition(doc *document, pos Position) *Location {
+	offset := doc.Offset(pos)
+	f := doc.ParseAround(offset)
+	if f == nil {
+		return nil
+	}
+
+	var def *ast.BlockStmt
+	switch t := findTarget(f, offset); {
+	case t.block != nil:
+		def = findBlock(s.scopeAt(f, offset), f, declareBlockName, t.block.GetBlockName())
+	case t.call:
+		if len(t.traversal) == 1 {
+			def = findBlock(s.scopeAt(f, offset), f, function.BlockName, t.traversal[0].Name)
+		}
+	case t.traversal != nil:
+		def, _ = findReference(s.scopeAt(f, offset), f, t.traversal)
+	}
+	if def == nil {
+		return nil
+	}
+
+	return &Location{
+		URI:   doc.uri,
+		Range: doc.Range(def.NamePos, def.NamePos.Add(len(def.GetBlockName())-1)),
+	}
+}
+
+// hover returns information about the component at pos, such as its
+// stability level.
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	offset := doc.Offset(pos)
+	f := doc.ParseAround(offset)
+	if f == nil {
+		return nil
+	}
+
+	var (
+		block *ast.BlockStmt
+		rng   Range
+	)
+	switch t := findTarget(f, offset); {
+	case t.block != nil:
+		// Only blocks declaring components are described.
+		if sc := s.scopeAt(f, offset); sc.args != nil || sc.unknown {
+			return nil
+		}
+		block = t.block
+		rng = doc.Range(block.NamePos, block.NamePos.Add(len(block.GetBlockName())-1))
+	case t.traversal != nil && !t.call:
+		var n int
+		block, n = findReference(s.scopeAt(f, offset), f, t.traversal)
+		if block != nil {
+			rng = doc.Range(ast.StartPos(t.traversal[0]), ast.EndPos(t.traversal[n-1]))
+		}
+	}
+	if block == nil {
+		return nil
+	}
+
+	text := s.describeComponent(f, s.scopeAt(f, offset), block.GetBlockName())
+	if text == "" {
+		return nil
+	}
+	return &Hover{
+		Contents: markupContent{Kind: "markdown", Value: text},
+		Range:    &rng,
+	}
+}
+
+// describeComponent returns a Markdown description of the component called
+// name, or an empty string if name isn't a component.
+func (s *Server) describeComponent(f *ast.File, sc scope, name string) string {
+	if decl := findBlock(sc, f, declareBlockName, name); decl != nil {
+		return fmt.Sprintf("**%s**\n\nCustom component declared on line %d.", name, decl.NamePos.Position().Line)
+	}
+	if _, ok := configBlocks[name]; ok {
+		return ""
+	}
+	if s.opts.ComponentRegistry == nil {
+		return ""
+	}
+
+	reg, err := s.opts.ComponentRegistry.Get(name)
+	if err != nil {
+		return fmt.Sprintf("**%s**\n\n%s", name, err)
+	}
+	if reg.Community {
+		return fmt.Sprintf("**%s**\n\nCommunity component.", name)
+	}
+	return fmt.Sprintf("**%s**\n\nStability: %s", name, reg.Stability.Name())
+}
+
+// findBlock returns the block called name with the given label which is
+// visible in the scope sc of f.
+func findBlock(sc scope, f *ast.File, name string, label string) *ast.BlockStmt {
+	bodies := visibleBodies(sc, f)
+	for i := len(bodies) - 1; i >= 0; i-- {
+		for _, stmt := range bodies[i] {
+			if b, ok := stmt.(*ast.BlockStmt); ok && b.GetBlockName() == name && b.Label == label {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// findReference returns the block referenced by the shortest prefix of
+// traversal, such as the component prometheus.scrape.default for the
+// reference prometheus.scrape.default.targets, along with the length of the
+// prefix.
+func findReference(sc scope, f *ast.File, traversal []*ast.Ident) (*ast.BlockStmt, int) {
+	bodies := visibleBodies(sc, f)
+	for i := len(bodies) - 1; i >= 0; i-- {
+		for n := 1; n <= len(traversal); n++ {
+			id := identsID(traversal[:n])
+			for _, stmt := range bodies[i] {
+				b, ok := stmt.(*ast.BlockStmt)
+				if ok && b.Label != "" && b.GetBlockName()+"."+b.Label == id {
+					return b, n
+				}
+			}
+		}
+	}
+	return nil, 0
+}
+
+// visibleBodies returns the bodies whose blocks are visible from the scope
+// sc, outermost first.
+func visibleBodies(sc scope, f *ast.File) []ast.Body {
+	bodies := []ast.Body{f.Body}
+	for _, b := range sc.path {
+		bodies = append(bodies, b.Body)
+	}
+	return bodies
+}
+
+func identsID(idents []*ast.Ident) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ".")
+}
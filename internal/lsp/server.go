@@ -0,0 +1,307 @@
+// Package lsp implements a language server for Alloy configuration files,
+// speaking the Language Server Protocol over a stream such as stdio.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/validator"
+	"github.com/grafana/alloy/syntax/diag"
+)
+
+// Options configures a Server.
+type Options struct {
+	// ComponentRegistry is used to look up the components used by documents.
+	ComponentRegistry component.Registry
+	// ComponentNames are the names of the components offered for completion.
+	// Components which can't be retrieved from ComponentRegistry are ignored.
+	ComponentNames []string
+	// ServiceDefinitions is used to validate the configuration of services.
+	ServiceDefinitions []service.Definition
+	// MinStability is the minimum stability level of the features which can be
+	// used by documents.
+	MinStability featuregate.Stability
+}
+
+// Server is a language server for Alloy configuration files.
+type Server struct {
+	opts Options
+
+	mut  sync.Mutex
+	docs map[string]*document
+}
+
+// New creates a new Server.
+func New(opts Options) *Server {
+	return &Server{
+		opts: opts,
+		docs: make(map[string]*document),
+	}
+}
+
+// errExit is returned by handle when the client asks the server to exit.
+var errExit = errors.New("exit")
+
+// Serve serves the protocol, reading requests from r and writing responses to
+// w. Serve returns nil once the client asks the server to exit or r is
+// closed, or when ctx is canceled. r is closed when Serve returns to stop
+// reading messages.
+func (s *Server) Serve(ctx context.Context, r io.ReadCloser, w io.Writer) error {
+	c := newConn(r, w)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	msgs := make(chan *message)
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			msg, err := c.Read()
+			var perr *responseError
+			if errors.As(err, &perr) {
+				// Skip messages which aren't valid JSON: they can't be replied
+				// to without an ID.
+				continue
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Stop reading messages once Serve returns. Closing r unblocks the pending
+	// read.
+	defer func() {
+		cancel()
+		_ = r.Close()
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case msg := <-msgs:
+			if err := s.handle(c, msg); err != nil {
+				if errors.Is(err, errExit) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+// handle handles a single message, writing the response to c. Errors are
+// only returned when c can't be written to.
+func (s *Server) handle(c *conn, msg *message) error {
+	if msg.Method == "" {
+		// Responses to requests made by the server are ignored, since the
+		// server doesn't make any.
+		return nil
+	}
+
+	var (
+		result any
+		err    error
+	)
+	switch msg.Method {
+	case "initialize":
+		result = s.initialize()
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace":
+		// Nothing to do: requests are handled synchronously and the server
+		// doesn't hold resources to release before exiting.
+	case "exit":
+		return errExit
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = unmarshalParams(msg.Params, &params); err == nil {
+			doc := s.open(params.TextDocument.URI, []byte(params.TextDocument.Text))
+			return c.Notify("textDocument/publishDiagnostics", s.diagnostics(doc))
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = unmarshalParams(msg.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			text := params.ContentChanges[len(params.ContentChanges)-1].Text
+			doc := s.open(params.TextDocument.URI, []byte(text))
+			return c.Notify("textDocument/publishDiagnostics", s.diagnostics(doc))
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = unmarshalParams(msg.Params, &params); err == nil {
+			s.close(params.TextDocument.URI)
+			return c.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+				URI:         params.TextDocument.URI,
+				Diagnostics: []Diagnostic{},
+			})
+		}
+
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err = unmarshalParams(msg.Params, &params); err == nil {
+			result, err = s.withDocument(params.TextDocument.URI, func(doc *document) (any, error) {
+				return s.completion(doc, params.Position), nil
+			})
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = unmarshalParams(msg.Params, &params); err == nil {
+			result, err = s.withDocument(params.TextDocument.URI, func(doc *document) (any, error) {
+				if loc := s.definition(doc, params.Position); loc != nil {
+					return loc, nil
+				}
+				return nil, nil
+			})
+		}
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = unmarshalParams(msg.Params, &params); err == nil {
+			result, err = s.withDocument(params.TextDocument.URI, func(doc *document) (any, error) {
+				if hover := s.hover(doc, params.Position); hover != nil {
+					return hover, nil
+				}
+				return nil, nil
+			})
+		}
+	case "textDocument/formatting":
+		var params formattingParams
+		if err = unmarshalParams(msg.Params, &params); err == nil {
+			result, err = s.withDocument(params.TextDocument.URI, func(doc *document) (any, error) {
+				edits, err := s.format(doc)
+				return edits, err
+			})
+		}
+
+	default:
+		err = &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not supported", msg.Method)}
+	}
+
+	if msg.isNotification() {
+		return nil
+	}
+	return c.Reply(msg.ID, result, err)
+}
+
+func (s *Server) initialize() initializeResult {
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync: textDocumentSyncFull,
+			CompletionProvider: completionOptions{
+				TriggerCharacters: []string{"."},
+			},
+			DefinitionProvider:         true,
+			HoverProvider:              true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: serverInfo{
+			Name:    "alloy",
+			Version: build.Version,
+		},
+	}
+}
+
+func (s *Server) open(uri string, text []byte) *document {
+	doc := newDocument(uri, text)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.docs[uri] = doc
+	return doc
+}
+
+func (s *Server) close(uri string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.docs, uri)
+}
+
+func (s *Server) withDocument(uri string, f func(doc *document) (any, error)) (any, error) {
+	s.mut.Lock()
+	doc, ok := s.docs[uri]
+	s.mut.Unlock()
+
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document %s is not open", uri)}
+	}
+	return f(doc)
+}
+
+// diagnostics validates doc the same way as the validate command with the
+// --strict flag, and returns the diagnostics to publish for doc.
+func (s *Server) diagnostics(doc *document) publishDiagnosticsParams {
+	res := publishDiagnosticsParams{URI: doc.uri, Diagnostics: []Diagnostic{}}
+
+	err := doc.parseErr
+	if err == nil {
+		err = validator.Validate(validator.Options{
+			Sources:            map[string][]byte{doc.uri: doc.text},
+			ServiceDefinitions: s.opts.ServiceDefinitions,
+			ComponentRegistry:  s.opts.ComponentRegistry,
+			MinStability:       s.opts.MinStability,
+			Strict:             true,
+		})
+	}
+	if err == nil {
+		return res
+	}
+
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		res.Diagnostics = append(res.Diagnostics, Diagnostic{
+			Severity: severityError,
+			Source:   "alloy",
+			Message:  err.Error(),
+		})
+		return res
+	}
+
+	for _, d := range diags {
+		severity := severityError
+		if d.Severity == diag.SeverityLevelWarn {
+			severity = severityWarning
+		}
+
+		start := doc.Position(d.StartPos.Offset)
+		end := start
+		if d.EndPos.Offset > d.StartPos.Offset {
+			end = doc.Position(d.EndPos.Offset + 1)
+		}
+		res.Diagnostics = append(res.Diagnostics, Diagnostic{
+			Range:    Range{Start: start, End: end},
+			Severity: severity,
+			Source:   "alloy",
+			Message:  d.Message,
+		})
+	}
+	return res
+}
+
+func unmarshalParams(raw json.RawMessage, v any) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+type testArgs struct {
+	Targets []map[string]string `alloy:"targets,attr"`
+	Timeout string              `alloy:"timeout,attr,optional"`
+	Auth    *testAuth           `alloy:"auth,block,optional"`
+}
+
+type testAuth struct {
+	Username string `alloy:"username,attr"`
+	Password string `alloy:"password,attr,optional"`
+}
+
+type testExports struct {
+	Targets []map[string]string `alloy:"targets,attr"`
+}
+
+func newTestServer() *Server {
+	registrations := map[string]component.Registration{
+		"test.discover": {
+			Name:      "test.discover",
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Args:      testArgs{},
+			Exports:   testExports{},
+		},
+		"test.experimental": {
+			Name:      "test.experimental",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testArgs{},
+		},
+	}
+	return New(Options{
+		ComponentRegistry: component.NewRegistryMap(featuregate.StabilityExperimental, false, registrations),
+		ComponentNames:    []string{"test.discover", "test.experimental"},
+		MinStability:      featuregate.StabilityExperimental,
+	})
+}
+
+// positionOf returns the position of the first occurrence of marker in text,
+// offset by delta bytes.
+func positionOf(t *testing.T, doc *document, marker string, delta int) Position {
+	t.Helper()
+	idx := strings.Index(string(doc.text), marker)
+	require.GreaterOrEqual(t, idx, 0, "marker %q not found", marker)
+	return doc.Position(idx + delta)
+}
+
+func itemLabels(items []CompletionItem) []string {
+	labels := make([]string, 0, len(items))
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestCompletion(t *testing.T) {
+	s := newTestServer()
+
+	doc := newDocument("file:///test.alloy", []byte(`declare "custom" {
+	test.discover "inner" {
+
+	}
+}
+
+test.discover "default" {
+	auth {
+
+	}
+}
+`))
+
+	t.Run("components", func(t *testing.T) {
+		items := s.completion(doc, Position{Line: 5, Character: 0})
+		require.Equal(t, []string{"test.discover", "test.experimental", "custom"}, itemLabels(items))
+		require.Equal(t, "generally-available component", items[0].Detail)
+		require.Equal(t, "experimental component", items[1].Detail)
+		require.Equal(t, "custom component", items[2].Detail)
+	})
+
+	t.Run("attributes and blocks", func(t *testing.T) {
+		items := s.completion(doc, Position{Line: 2, Character: 0})
+		require.Equal(t, []string{"auth", "targets", "timeout"}, itemLabels(items))
+		require.Equal(t, "block", items[0].Detail)
+		require.Equal(t, "required attribute", items[1].Detail)
+		require.Equal(t, "timeout = $0", items[2].InsertText)
+	})
+
+	t.Run("nested block", func(t *testing.T) {
+		items := s.completion(doc, Position{Line: 8, Character: 0})
+		require.Equal(t, []string{"password", "username"}, itemLabels(items))
+	})
+
+	t.Run("incomplete line", func(t *testing.T) {
+		doc := newDocument("file:///test.alloy", []byte("test.discover \"default\" {\n\ttimeo\n}\n"))
+		require.Error(t, doc.parseErr)
+
+		items := s.completion(doc, Position{Line: 1, Character: 6})
+		require.Equal(t, []string{"auth", "targets", "timeout"}, itemLabels(items))
+	})
+}
+
+func TestDefinition(t *testing.T) {
+	s := newTestServer()
+
+	doc := newDocument("file:///test.alloy", []byte(`function "double" {
+	params = ["value"]
+	result = value * 2
+}
+
+declare "custom" {
+	argument "input" { }
+
+	test.discover "inner" {
+		targets = argument.input.value
+	}
+}
+
+test.discover "default" {
+	targets = []
+}
+
+custom "default" {
+	input = double(test.discover.default.targets)
+}
+`))
+
+	tests := []struct {
+		desc     string
+		pos      Position
+		expected *Location
+	}{
+		{
+			desc:     "component reference",
+			pos:      positionOf(t, doc, "discover.default.targets", 0),
+			expected: &Location{URI: doc.uri, Range: Range{Start: Position{13, 0}, End: Position{13, 13}}},
+		},
+		{
+			desc:     "argument reference",
+			pos:      positionOf(t, doc, "argument.input.value", 12),
+			expected: &Location{URI: doc.uri, Range: Range{Start: Position{6, 1}, End: Position{6, 9}}},
+		},
+		{
+			desc:     "custom component",
+			pos:      positionOf(t, doc, `custom "default"`, 2),
+			expected: &Location{URI: doc.uri, Range: Range{Start: Position{5, 0}, End: Position{5, 7}}},
+		},
+		{
+			desc:     "function call",
+			pos:      positionOf(t, doc, "double(", 1),
+			expected: &Location{URI: doc.uri, Range: Range{Start: Position{0, 0}, End: Position{0, 8}}},
+		},
+		{
+			desc: "literal",
+			pos:  positionOf(t, doc, `"value"`, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			require.Equal(t, tt.expected, s.definition(doc, tt.pos))
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+	s := newTestServer()
+
+	doc := newDocument("file:///test.alloy", []byte(`test.experimental "default" {
+	targets = test.discover.default.targets
+}
+
+test.discover "default" {
+	targets = []
+}
+`))
+
+	hover := s.hover(doc, Position{Line: 0, Character: 3})
+	require.NotNil(t, hover)
+	require.Equal(t, "**test.experimental**\n\nStability: experimental", hover.Contents.Value)
+	require.Equal(t, &Range{Start: Position{0, 0}, End: Position{0, 17}}, hover.Range)
+
+	hover = s.hover(doc, positionOf(t, doc, "discover.default.targets", 0))
+	require.NotNil(t, hover)
+	require.Equal(t, "**test.discover**\n\nStability: generally-available", hover.Contents.Value)
+	require.Equal(t, &Range{Start: Position{1, 11}, End: Position{1, 32}}, hover.Range)
+
+	require.Nil(t, s.hover(doc, positionOf(t, doc, "targets = []", 0)))
+}
+
+func TestFormat(t *testing.T) {
+	s := newTestServer()
+
+	doc := newDocument("file:///test.alloy", []byte("test.discover \"default\" {\ntargets=[]\n}"))
+	edits, err := s.format(doc)
+	require.NoError(t, err)
+	require.Equal(t, []TextEdit{{
+		Range:   Range{End: Position{Line: 2, Character: 1}},
+		NewText: "test.discover \"default\" {\n\ttargets = []\n}\n",
+	}}, edits)
+
+	doc = newDocument("file:///test.alloy", []byte(edits[0].NewText))
+	edits, err = s.format(doc)
+	require.NoError(t, err)
+	require.Empty(t, edits)
+
+	doc = newDocument("file:///test.alloy", []byte("test.discover \"default\" {"))
+	_, err = s.format(doc)
+	require.Error(t, err)
+}
+
+func TestDocumentPositions(t *testing.T) {
+	doc := newDocument("file:///test.alloy", []byte("a = \"héllo 😀\"\r\nb = 1\n"))
+
+	// "😀" takes 4 bytes in UTF-8 and 2 code units in UTF-16.
+	emoji := strings.Index(string(doc.text), "😀")
+	require.Equal(t, Position{Line: 0, Character: 11}, doc.Position(emoji))
+	require.Equal(t, Position{Line: 0, Character: 13}, doc.Position(emoji+4))
+	require.Equal(t, emoji, doc.Offset(Position{Line: 0, Character: 11}))
+
+	// Positions past the end of a line are clamped before the line terminator.
+	require.Equal(t, strings.Index(string(doc.text), "\r"), doc.Offset(Position{Line: 0, Character: 100}))
+	require.Equal(t, Position{Line: 1, Character: 0}, doc.Position(strings.Index(string(doc.text), "b")))
+	require.Equal(t, len(doc.text), doc.Offset(Position{Line: 5, Character: 0}))
+}
+
+func TestServe(t *testing.T) {
+	var in bytes.Buffer
+	writeRequest := func(id int, method string, params any) {
+		msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+		if id != 0 {
+			msg["id"] = id
+		}
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	uri := "file:///test.alloy"
+	writeRequest(1, "initialize", map[string]any{})
+	writeRequest(0, "initialized", map[string]any{})
+	writeRequest(0, "textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "version": 1, "text": "test.discover \"default\" {\n\tunknown = 1\n}\n"},
+	})
+	writeRequest(2, "textDocument/hover", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": 0, "character": 0},
+	})
+	writeRequest(3, "textDocument/unknown", map[string]any{})
+	writeRequest(4, "shutdown", nil)
+	writeRequest(0, "exit", nil)
+
+	var out bytes.Buffer
+	require.NoError(t, newTestServer().Serve(context.Background(), io.NopCloser(&in), &out))
+
+	r := textproto.NewReader(bufio.NewReader(&out))
+	readMessage := func() map[string]any {
+		header, err := r.ReadMIMEHeader()
+		require.NoError(t, err)
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+		body := make([]byte, length)
+		_, err = io.ReadFull(r.R, body)
+		require.NoError(t, err)
+
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal(body, &msg))
+		return msg
+	}
+
+	initialize := readMessage()
+	require.EqualValues(t, 1, initialize["id"])
+	capabilities := initialize["result"].(map[string]any)["capabilities"].(map[string]any)
+	require.Equal(t, true, capabilities["documentFormattingProvider"])
+
+	diagnostics := readMessage()
+	require.Equal(t, "textDocument/publishDiagnostics", diagnostics["method"])
+	var messages []string
+	for _, d := range diagnostics["params"].(map[string]any)["diagnostics"].([]any) {
+		messages = append(messages, d.(map[string]any)["message"].(string))
+	}
+	require.Contains(t, messages, `unrecognized attribute name "unknown"`)
+
+	hover := readMessage()
+	require.EqualValues(t, 2, hover["id"])
+	require.Contains(t, hover["result"].(map[string]any)["contents"].(map[string]any)["value"], "generally-available")
+
+	unknown := readMessage()
+	require.EqualValues(t, 3, unknown["id"])
+	require.EqualValues(t, codeMethodNotFound, unknown["error"].(map[string]any)["code"])
+
+	shutdown := readMessage()
+	require.EqualValues(t, 4, shutdown["id"])
+	require.Contains(t, shutdown, "result")
+	require.Nil(t, shutdown["result"])
+}
+
+func TestServe_Cancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	// The reader blocks until it's closed, like stdin with an idle client.
+	r, w := io.Pipe()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		done <- newTestServer().Serve(ctx, r, io.Discard)
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after the context was canceled")
+	}
+}
@@ -0,0 +1,18 @@
+package function
+
+const (
+	// BlockName is the block name for function blocks.
+	BlockName = "function"
+	// ParamsAttr is the attribute holding the names of the parameters.
+	ParamsAttr = "params"
+	// ResultAttr is the attribute holding the expression evaluated on each call.
+	ResultAttr = "result"
+)
+
+// Arguments describes the attributes of a function block. The result
+// expression is never decoded into Result: it is kept unevaluated and
+// evaluated each time the function is called.
+type Arguments struct {
+	Params []string `alloy:"params,attr,optional"`
+	Result any      `alloy:"result,attr"`
+}
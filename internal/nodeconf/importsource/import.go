@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
@@ -15,6 +16,8 @@ const (
 	String
 	Git
 	HTTP
+	S3
+	OCI
 )
 
 const (
@@ -22,6 +25,8 @@ const (
 	BlockNameString = "import.string"
 	BlockNameHTTP   = "import.http"
 	BlockNameGit    = "import.git"
+	BlockNameS3     = "import.s3"
+	BlockNameOCI    = "import.oci"
 )
 
 const ModulePath = "module_path"
@@ -42,7 +47,8 @@ type ImportSource interface {
 
 // NewImportSource creates a new ImportSource depending on the type.
 // onContentChange is used by the source when it receives new content.
-func NewImportSource(sourceType SourceType, managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) ImportSource {
+// lockFile is the path of the lock file of module imports, if any.
+func NewImportSource(sourceType SourceType, managedOpts component.Options, eval *vm.Evaluator, lockFile string, onContentChange func(map[string]string)) ImportSource {
 	switch sourceType {
 	case File:
 		return NewImportFile(managedOpts, eval, onContentChange)
@@ -51,11 +57,25 @@ func NewImportSource(sourceType SourceType, managedOpts component.Options, eval
 	case HTTP:
 		return NewImportHTTP(managedOpts, eval, onContentChange)
 	case Git:
-		return NewImportGit(managedOpts, eval, onContentChange)
+		return NewImportGit(managedOpts, eval, lockFile, onContentChange)
+	case S3:
+		return NewImportS3(managedOpts, eval, onContentChange)
+	case OCI:
+		return NewImportOCI(managedOpts, eval, onContentChange)
 	}
 	panic(fmt.Errorf("unsupported source type: %v", sourceType))
 }
 
+// Stability returns the stability level of an import block.
+func Stability(blockName string) featuregate.Stability {
+	switch blockName {
+	case BlockNameS3, BlockNameOCI:
+		return featuregate.StabilityExperimental
+	default:
+		return featuregate.StabilityGenerallyAvailable
+	}
+}
+
 // GetSourceType returns a SourceType matching a source name.
 func GetSourceType(fullName string) SourceType {
 	switch fullName {
@@ -67,6 +87,10 @@ func GetSourceType(fullName string) SourceType {
 		return HTTP
 	case BlockNameGit:
 		return Git
+	case BlockNameS3:
+		return S3
+	case BlockNameOCI:
+		return OCI
 	}
 	panic(fmt.Errorf("name does not map to a known source type: %v", fullName))
 }
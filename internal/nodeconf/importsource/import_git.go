@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -17,6 +19,7 @@ import (
 	"github.com/grafana/alloy/internal/vcs"
 	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/vm"
+	"golang.org/x/crypto/ssh"
 )
 
 // ImportGit imports a module from a git repository.
@@ -30,6 +33,9 @@ type ImportGit struct {
 	repoOpts        vcs.GitRepoOptions
 	args            GitArguments
 	repoPath        string
+	lockFile        string
+	locked          *LockedModule // Module in the lock file matching the import, if any.
+	vendorPath      string        // Path of the vendored module, if it's vendored.
 	onContentChange func(map[string]string)
 
 	argsChanged chan struct{}
@@ -47,13 +53,34 @@ var (
 type GitArguments struct {
 	Repository    string            `alloy:"repository,attr"`
 	Revision      string            `alloy:"revision,attr,optional"`
+	Version       string            `alloy:"version,attr,optional"`
 	Path          string            `alloy:"path,attr"`
 	PullFrequency time.Duration     `alloy:"pull_frequency,attr,optional"`
 	GitAuthConfig vcs.GitAuthConfig `alloy:",squash"`
+
+	Verify *GitVerifyArguments `alloy:"verify,block,optional"`
+}
+
+// GitVerifyArguments configures the verification of modules before they're
+// loaded.
+type GitVerifyArguments struct {
+	SHA256        string   `alloy:"sha256,attr,optional"`
+	GPGKeyRing    string   `alloy:"gpg_keyring,attr,optional"`
+	SSHPublicKeys []string `alloy:"ssh_public_keys,attr,optional"`
+}
+
+func (args *GitVerifyArguments) signingKeys() vcs.SigningKeys {
+	return vcs.SigningKeys{
+		GPGKeyRing:    args.GPGKeyRing,
+		SSHPublicKeys: args.SSHPublicKeys,
+	}
 }
 
+// defaultGitRevision is the revision used when neither revision nor version
+// is set.
+const defaultGitRevision = "main"
+
 var DefaultGitArguments = GitArguments{
-	Revision:      "main",
 	PullFrequency: time.Minute,
 }
 
@@ -69,6 +96,31 @@ func (args *GitArguments) Validate() error {
 		return fmt.Errorf("revision cannot be a special git reference such as HEAD, FETCH_HEAD, ORIG_HEAD, MERGE_HEAD, or CHERRY_PICK_HEAD")
 	}
 
+	if args.Version != "" {
+		if args.Revision != "" {
+			return fmt.Errorf("revision and version are mutually exclusive")
+		}
+		if err := vcs.ValidateVersion(args.Version); err != nil {
+			return fmt.Errorf("invalid version %q: %w", args.Version, err)
+		}
+	}
+
+	if args.Verify != nil {
+		if args.Verify.SHA256 == "" && args.Verify.signingKeys().Empty() {
+			return fmt.Errorf("verify must set at least one of sha256, gpg_keyring or ssh_public_keys")
+		}
+		if args.Verify.SHA256 != "" {
+			if err := validateSHA256(args.Verify.SHA256); err != nil {
+				return err
+			}
+		}
+		for _, key := range args.Verify.SSHPublicKeys {
+			if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil {
+				return fmt.Errorf("invalid SSH public key %q: %w", key, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -77,11 +129,14 @@ func (args *GitArguments) SetToDefault() {
 	*args = DefaultGitArguments
 }
 
-func NewImportGit(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportGit {
+// NewImportGit creates a new ImportGit. Imports with a version constraint
+// use the modules locked in lockFile, if it's set.
+func NewImportGit(managedOpts component.Options, eval *vm.Evaluator, lockFile string, onContentChange func(map[string]string)) *ImportGit {
 	return &ImportGit{
 		opts:            managedOpts,
 		log:             managedOpts.Logger,
 		eval:            eval,
+		lockFile:        lockFile,
 		argsChanged:     make(chan struct{}, 1),
 		onContentChange: onContentChange,
 	}
@@ -121,6 +176,10 @@ func (im *ImportGit) Run(ctx context.Context) error {
 		case <-im.argsChanged:
 			im.mut.Lock()
 			pullFrequency := im.args.PullFrequency
+			if im.locked != nil {
+				// Locked modules never change.
+				pullFrequency = 0
+			}
 			im.mut.Unlock()
 			ticker, tickerC = im.updateTicker(pullFrequency, ticker, tickerC)
 
@@ -203,12 +262,36 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 	repoOpts := vcs.GitRepoOptions{
 		Repository: newArgs.Repository,
 		Revision:   newArgs.Revision,
+		Version:    newArgs.Version,
 		Auth:       newArgs.GitAuthConfig,
 	}
+	if repoOpts.Revision == "" && repoOpts.Version == "" {
+		repoOpts.Revision = defaultGitRevision
+	}
+
+	// Imports with a version constraint load the locked commit, from the
+	// vendor directory if the module is vendored. The locked tag is kept to
+	// verify its signature.
+	im.locked, im.vendorPath = nil, ""
+	if newArgs.Version != "" && im.lockFile != "" {
+		lock, err := ReadLock(im.lockFile)
+		if err != nil {
+			return err
+		}
+		if m, ok := lock.Find(newArgs.Repository, newArgs.Path, newArgs.Version); ok {
+			im.locked = &m
+			repoOpts.Revision, repoOpts.Version, repoOpts.Tag = m.Commit, "", m.Tag
+
+			vendorPath := VendorPath(im.lockFile, m)
+			if fi, err := os.Stat(vendorPath); err == nil && fi.IsDir() {
+				im.vendorPath = vendorPath
+			}
+		}
+	}
 
 	// Create or update the repo field.
 	// Failure to update repository makes the module loader temporarily use cached contents on disk
-	if im.repo == nil || !equality.DeepEqual(repoOpts, im.repoOpts) {
+	if im.vendorPath == "" && (im.repo == nil || !equality.DeepEqual(repoOpts, im.repoOpts)) {
 		r, err := vcs.NewGitRepo(context.Background(), im.repoPath, repoOpts)
 		if err != nil {
 			if errors.As(err, &vcs.UpdateFailedError{}) {
@@ -246,27 +329,102 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 // pollFile fetches the latest content from the repository and updates the
 // controller. pollFile must only be called with im.mut held.
 func (im *ImportGit) pollFile(ctx context.Context, args GitArguments) error {
-	// Make sure our repo is up-to-date.
-	if err := im.repo.Update(ctx); err != nil {
-		return err
+	var files moduleFiles = im.repo
+	if im.vendorPath != "" {
+		// Vendored modules are loaded without accessing the repository.
+		files = vendorFiles(im.vendorPath)
+	} else {
+		// Make sure our repo is up-to-date.
+		if err := im.repo.Update(ctx); err != nil {
+			return err
+		}
+
+		// Refuse the revision if it isn't signed by a trusted key. The previous
+		// content stays loaded.
+		if args.Verify != nil && !args.Verify.signingKeys().Empty() {
+			if err := im.repo.VerifySignature(args.Verify.signingKeys()); err != nil {
+				return err
+			}
+		}
 	}
 
-	info, err := im.repo.Stat(args.Path)
+	content, hash, err := readModule(files, args.Path)
 	if err != nil {
 		return err
 	}
 
-	if info.IsDir() {
-		return im.handleDirectory(args.Path)
+	if args.Verify != nil && args.Verify.SHA256 != "" {
+		if err := compareSHA256(hash, args.Verify.SHA256); err != nil {
+			return fmt.Errorf("verifying %s: %w", args.Path, err)
+		}
 	}
+	if im.locked != nil {
+		if err := compareSHA256(hash, im.locked.SHA256); err != nil {
+			return fmt.Errorf("verifying %s against the lock file: %w", args.Path, err)
+		}
+	}
+
+	im.onContentChange(content)
+	return nil
+}
+
+// moduleFiles gives access to the files of a module imported from a
+// repository.
+type moduleFiles interface {
+	Stat(path string) (fs.FileInfo, error)
+	ReadDir(path string) ([]fs.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+}
+
+var _ moduleFiles = (*vcs.GitRepo)(nil)
+
+// vendorFiles gives access to the files of a vendored module.
+type vendorFiles string
 
-	return im.handleFile(args.Path)
+func (dir vendorFiles) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(filepath.Join(string(dir), path))
 }
 
-func (im *ImportGit) handleDirectory(path string) error {
-	filesInfo, err := im.repo.ReadDir(path)
+func (dir vendorFiles) ReadDir(path string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(string(dir), path))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (dir vendorFiles) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(dir), path))
+}
+
+// readModule reads the module at path, which is either a file or a directory
+// of .alloy files. The content of the module is returned along with its
+// hex-encoded hash, as verified by the sha256 attribute of the verify block.
+func readModule(files moduleFiles, path string) (map[string]string, string, error) {
+	info, err := files.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !info.IsDir() {
+		bb, err := files.ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return map[string]string{path: string(bb)}, sha256Hex(bb), nil
+	}
+
+	filesInfo, err := files.ReadDir(path)
+	if err != nil {
+		return nil, "", err
 	}
 
 	content := make(map[string]string)
@@ -274,23 +432,13 @@ func (im *ImportGit) handleDirectory(path string) error {
 		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".alloy") {
 			continue
 		}
-		bb, err := im.repo.ReadFile(filepath.Join(path, fi.Name()))
+		bb, err := files.ReadFile(filepath.Join(path, fi.Name()))
 		if err != nil {
-			return err
+			return nil, "", err
 		}
 		content[fi.Name()] = string(bb)
 	}
-	im.onContentChange(content)
-	return nil
-}
-
-func (im *ImportGit) handleFile(path string) error {
-	bb, err := im.repo.ReadFile(path)
-	if err != nil {
-		return err
-	}
-	im.onContentChange(map[string]string{path: string(bb)})
-	return nil
+	return content, directorySHA256(content), nil
 }
 
 // CurrentHealth implements component.HealthComponent.
@@ -306,5 +454,8 @@ func (im *ImportGit) SetEval(eval *vm.Evaluator) {
 }
 
 func (im *ImportGit) ModulePath() string {
+	if im.vendorPath != "" {
+		return im.vendorPath
+	}
 	return im.repoPath
 }
@@ -3,14 +3,17 @@ package importsource
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/grafana/alloy/internal/component"
 	common_config "github.com/grafana/alloy/internal/component/common/config"
 	remote_http "github.com/grafana/alloy/internal/component/remote/http"
 	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
@@ -20,6 +23,12 @@ type ImportHTTP struct {
 	arguments         HTTPArguments
 	managedOpts       component.Options
 	eval              *vm.Evaluator
+
+	verifyMut    sync.Mutex
+	verify       *HTTPVerifyArguments
+	verifyKey    signatureVerifier
+	signatureURL string
+	headers      map[string]string
 }
 
 var _ ImportSource = (*ImportHTTP)(nil)
@@ -46,6 +55,16 @@ type HTTPArguments struct {
 	Body    string            `alloy:"body,attr,optional"`
 
 	Client common_config.HTTPClientConfig `alloy:"client,block,optional"`
+
+	Verify *HTTPVerifyArguments `alloy:"verify,block,optional"`
+}
+
+// HTTPVerifyArguments configures the verification of modules before they're
+// loaded.
+type HTTPVerifyArguments struct {
+	SHA256       string `alloy:"sha256,attr,optional"`
+	PublicKey    string `alloy:"public_key,attr,optional"`
+	SignatureURL string `alloy:"signature_url,attr,optional"`
 }
 
 // DefaultHTTPArguments holds default settings for HTTPArguments.
@@ -56,11 +75,40 @@ var DefaultHTTPArguments = HTTPArguments{
 	Method:        http.MethodGet,
 }
 
+var (
+	_ syntax.Validator = (*HTTPArguments)(nil)
+	_ syntax.Defaulter = (*HTTPArguments)(nil)
+)
+
 // SetToDefault implements syntax.Defaulter.
 func (args *HTTPArguments) SetToDefault() {
 	*args = DefaultHTTPArguments
 }
 
+// Validate implements syntax.Validator.
+func (args *HTTPArguments) Validate() error {
+	if args.Verify == nil {
+		return nil
+	}
+
+	if args.Verify.SHA256 == "" && args.Verify.PublicKey == "" {
+		return fmt.Errorf("verify must set at least one of sha256 or public_key")
+	}
+	if args.Verify.SHA256 != "" {
+		if err := validateSHA256(args.Verify.SHA256); err != nil {
+			return err
+		}
+	}
+	if args.Verify.PublicKey != "" {
+		if _, err := parsePublicKey(args.Verify.PublicKey); err != nil {
+			return err
+		}
+	} else if args.Verify.SignatureURL != "" {
+		return fmt.Errorf("signature_url can only be set with public_key")
+	}
+	return nil
+}
+
 func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	var arguments HTTPArguments
 	if err := im.eval.Evaluate(scope, &arguments); err != nil {
@@ -75,9 +123,13 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 		Body:          arguments.Body,
 		Client:        arguments.Client,
 	}
+	if err := im.setVerify(arguments); err != nil {
+		return err
+	}
+
 	if im.managedRemoteHTTP == nil {
 		var err error
-		im.managedRemoteHTTP, err = remote_http.New(im.managedOpts, remoteHttpArguments)
+		im.managedRemoteHTTP, err = remote_http.NewVerified(im.managedOpts, remoteHttpArguments, im.verifyContent)
 		if err != nil {
 			return fmt.Errorf("creating http component: %w", err)
 		}
@@ -96,6 +148,85 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	return nil
 }
 
+// setVerify sets how the content is verified before being loaded.
+func (im *ImportHTTP) setVerify(arguments HTTPArguments) error {
+	var (
+		verify       = arguments.Verify
+		key          signatureVerifier
+		signatureURL string
+	)
+	if verify != nil && verify.PublicKey != "" {
+		var err error
+		if key, err = parsePublicKey(verify.PublicKey); err != nil {
+			return err
+		}
+
+		// The signature is expected next to the module by default.
+		signatureURL = verify.SignatureURL
+		if signatureURL == "" {
+			signatureURL = arguments.URL + ".sig"
+		}
+	}
+
+	im.verifyMut.Lock()
+	defer im.verifyMut.Unlock()
+	im.verify, im.verifyKey = verify, key
+	im.signatureURL, im.headers = signatureURL, arguments.Headers
+	return nil
+}
+
+// verifyContent implements remote_http.Verifier. Content which can't be
+// verified is never loaded.
+func (im *ImportHTTP) verifyContent(ctx context.Context, cli *http.Client, content []byte) error {
+	im.verifyMut.Lock()
+	verify, key := im.verify, im.verifyKey
+	signatureURL, headers := im.signatureURL, im.headers
+	im.verifyMut.Unlock()
+
+	if verify == nil {
+		return nil
+	}
+	if verify.SHA256 != "" {
+		if err := verifySHA256(content, verify.SHA256); err != nil {
+			return err
+		}
+	}
+	if key != nil {
+		signature, err := fetchSignature(ctx, cli, signatureURL, headers)
+		if err != nil {
+			return fmt.Errorf("fetching signature: %w", err)
+		}
+		if err := key.Verify(content, signature); err != nil {
+			return fmt.Errorf("verifying signature: %w", err)
+		}
+	}
+	return nil
+}
+
+// fetchSignature fetches the detached signature of a module. The headers of
+// the module request are sent as well, as they may be required for
+// authentication.
+func fetchSignature(ctx context.Context, cli *http.Client, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 func (im *ImportHTTP) Run(ctx context.Context) error {
 	return im.managedRemoteHTTP.Run(ctx)
 }
@@ -0,0 +1,288 @@
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// ociPullTimeout is the maximum duration to pull an artifact.
+const ociPullTimeout = time.Minute
+
+// ImportOCI imports a module from an OCI registry.
+type ImportOCI struct {
+	opts            component.Options
+	log             log.Logger
+	eval            *vm.Evaluator
+	onContentChange func(map[string]string)
+
+	mut        sync.RWMutex
+	args       OCIArguments
+	client     *oci.Client
+	lastDigest digest.Digest
+
+	argsChanged chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var _ ImportSource = (*ImportOCI)(nil)
+
+// OCIArguments holds values which are used to configure the import.oci
+// block.
+type OCIArguments struct {
+	Reference     string         `alloy:"reference,attr"`
+	PullFrequency time.Duration  `alloy:"pull_frequency,attr,optional"`
+	Insecure      bool           `alloy:"insecure,attr,optional"`
+	BasicAuth     *oci.BasicAuth `alloy:"basic_auth,block,optional"`
+}
+
+// DefaultOCIArguments holds default settings for OCIArguments.
+var DefaultOCIArguments = OCIArguments{
+	PullFrequency: time.Minute,
+}
+
+var (
+	_ syntax.Validator = (*OCIArguments)(nil)
+	_ syntax.Defaulter = (*OCIArguments)(nil)
+)
+
+// SetToDefault implements syntax.Defaulter.
+func (args *OCIArguments) SetToDefault() {
+	*args = DefaultOCIArguments
+}
+
+// Validate implements syntax.Validator.
+func (args *OCIArguments) Validate() error {
+	if args.PullFrequency < 0 {
+		return fmt.Errorf("pull_frequency must not be negative")
+	}
+	_, err := oci.NewClient(args.clientOptions())
+	return err
+}
+
+func (args *OCIArguments) clientOptions() oci.ClientOptions {
+	return oci.ClientOptions{
+		Reference: args.Reference,
+		BasicAuth: args.BasicAuth,
+		Insecure:  args.Insecure,
+	}
+}
+
+func NewImportOCI(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportOCI {
+	return &ImportOCI{
+		opts:            managedOpts,
+		log:             managedOpts.Logger,
+		eval:            eval,
+		argsChanged:     make(chan struct{}, 1),
+		onContentChange: onContentChange,
+	}
+}
+
+func (im *ImportOCI) Evaluate(scope *vm.Scope) error {
+	var arguments OCIArguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+
+	im.mut.RLock()
+	unchanged := im.client != nil && equality.DeepEqual(im.args, arguments)
+	im.mut.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := im.update(arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+	return nil
+}
+
+// update pulls the artifact referenced by args. The artifact is pulled
+// synchronously so that errors are reported to the import block.
+func (im *ImportOCI) update(args OCIArguments) (err error) {
+	defer func() {
+		im.updateHealth(err)
+	}()
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	client, err := oci.NewClient(args.clientOptions())
+	if err != nil {
+		return err
+	}
+	if im.client != nil {
+		im.client.Close()
+	}
+	im.client = client
+	im.args = args
+	im.lastDigest = ""
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case im.argsChanged <- struct{}{}:
+	default:
+	}
+
+	return im.pull(context.Background())
+}
+
+func (im *ImportOCI) Run(ctx context.Context) error {
+	var (
+		ticker  *time.Ticker
+		tickerC <-chan time.Time
+	)
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			im.mut.Lock()
+			if im.client != nil {
+				im.client.Close()
+			}
+			im.mut.Unlock()
+			return nil
+
+		case <-im.argsChanged:
+			im.mut.RLock()
+			pullFrequency := im.args.PullFrequency
+			if im.client.Pinned() {
+				// Artifacts referenced by digest never change.
+				pullFrequency = 0
+			}
+			im.mut.RUnlock()
+
+			if ticker != nil {
+				ticker.Stop()
+			}
+			ticker, tickerC = nil, nil
+			if pullFrequency > 0 {
+				ticker = time.NewTicker(pullFrequency)
+				tickerC = ticker.C
+			}
+
+		case <-tickerC:
+			im.mut.Lock()
+			err := im.pull(ctx)
+			reference := im.args.Reference
+			im.mut.Unlock()
+
+			im.updateHealth(err)
+			if err != nil {
+				level.Error(im.log).Log("msg", "failed to pull artifact", "reference", reference, "err", err)
+			}
+		}
+	}
+}
+
+// pull pulls the artifact if its digest changed and updates the controller.
+// pull must only be called with im.mut held.
+func (im *ImportOCI) pull(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, ociPullTimeout)
+	defer cancel()
+
+	dgst, err := im.client.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+	if dgst == im.lastDigest {
+		return nil
+	}
+
+	artifact, err := im.client.Pull(ctx, dgst)
+	if err != nil {
+		return err
+	}
+
+	content := make(map[string]string)
+	for name, bb := range artifact.Files {
+		if strings.HasSuffix(name, ".alloy") {
+			content[name] = string(bb)
+		}
+	}
+	if len(content) == 0 {
+		return fmt.Errorf("artifact %s doesn't contain any .alloy file", dgst)
+	}
+
+	// Store the files on disk so that the module can import other modules
+	// relative to module_path.
+	if err := im.writeFiles(content); err != nil {
+		return err
+	}
+
+	level.Info(im.log).Log("msg", "pulled artifact", "reference", im.args.Reference, "digest", dgst)
+	im.onContentChange(content)
+	im.lastDigest = dgst
+	return nil
+}
+
+func (im *ImportOCI) writeFiles(content map[string]string) error {
+	dir := im.ModulePath()
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	for name, data := range content {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *ImportOCI) updateHealth(err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	if err != nil {
+		im.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		im.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (im *ImportOCI) CurrentHealth() component.Health {
+	im.healthMut.RLock()
+	defer im.healthMut.RUnlock()
+	return im.health
+}
+
+// Update the evaluator.
+func (im *ImportOCI) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
+
+func (im *ImportOCI) ModulePath() string {
+	return filepath.Join(im.opts.DataPath, "artifact")
+}
@@ -0,0 +1,139 @@
+package importsource
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// LockFileName is the name of the lock file of a configuration, stored
+	// next to the configuration.
+	LockFileName = "alloy.lock"
+
+	// VendorDirName is the name of the directory where modules are vendored,
+	// next to the lock file.
+	VendorDirName = "vendor"
+)
+
+// LockFilePath returns the path of the lock file of the configuration at
+// configPath, which is either a file or a directory of files.
+func LockFilePath(configPath string) string {
+	if fi, err := os.Stat(configPath); err == nil && fi.IsDir() {
+		return filepath.Join(configPath, LockFileName)
+	}
+	return filepath.Join(filepath.Dir(configPath), LockFileName)
+}
+
+// Lock records the commit and the content hash each module imported with
+// a version constraint resolved to. Imports matching a locked module load
+// the locked commit instead of resolving the constraint again.
+type Lock struct {
+	Modules []LockedModule `json:"modules"`
+}
+
+// LockedModule is a module import resolved to a commit.
+type LockedModule struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	// Version is the version constraint of the import.
+	Version string `json:"version"`
+	// Tag is the tag the version constraint resolved to.
+	Tag    string `json:"tag"`
+	Commit string `json:"commit"`
+	// SHA256 is the hash of the module, computed like the sha256 attribute of
+	// the verify block.
+	SHA256 string `json:"sha256"`
+}
+
+// ReadLock reads the lock file at path. An empty Lock is returned if the
+// file doesn't exist.
+func ReadLock(path string) (*Lock, error) {
+	bb, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Lock{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(bb, &lock); err != nil {
+		return nil, fmt.Errorf("decoding lock file %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// Write writes the lock file at path.
+func (l *Lock) Write(path string) error {
+	slices.SortFunc(l.Modules, func(a, b LockedModule) int {
+		return cmp.Or(
+			strings.Compare(a.Repository, b.Repository),
+			strings.Compare(a.Path, b.Path),
+			strings.Compare(a.Version, b.Version),
+		)
+	})
+
+	bb, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bb, '\n'), 0644)
+}
+
+// Find returns the locked module imported from repository and path with the
+// version constraint.
+func (l *Lock) Find(repository, path, version string) (LockedModule, bool) {
+	for _, m := range l.Modules {
+		if m.Repository == repository && m.Path == path && m.Version == version {
+			return m, true
+		}
+	}
+	return LockedModule{}, false
+}
+
+// Set adds m to the lock, replacing the module with the same import if any.
+func (l *Lock) Set(m LockedModule) {
+	for i, existing := range l.Modules {
+		if existing.Repository == m.Repository && existing.Path == m.Path && existing.Version == m.Version {
+			l.Modules[i] = m
+			return
+		}
+	}
+	l.Modules = append(l.Modules, m)
+}
+
+// VendorPath returns the directory where the commit of m is vendored, for
+// the lock file at lockFile.
+func VendorPath(lockFile string, m LockedModule) string {
+	return filepath.Join(filepath.Dir(lockFile), VendorDirName, vendorRepositoryPath(m.Repository), m.Commit)
+}
+
+// vendorRepositoryPath converts a repository URL such as
+// https://github.com/org/repo.git or git@github.com:org/repo.git to a
+// relative path such as github.com/org/repo.git.
+func vendorRepositoryPath(repository string) string {
+	if _, rest, ok := strings.Cut(repository, "://"); ok {
+		repository = rest
+	}
+	// Drop the credentials or the user.
+	host, rest, _ := strings.Cut(repository, "/")
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	repository = strings.ReplaceAll(host, ":", "/") + "/" + rest
+
+	var elems []string
+	for _, elem := range strings.Split(repository, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			continue
+		}
+		elems = append(elems, elem)
+	}
+	return filepath.Join(elems...)
+}
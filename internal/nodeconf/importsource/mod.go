@@ -0,0 +1,119 @@
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/grafana/alloy/internal/vcs"
+)
+
+// LockGitModule resolves the version constraint of a module imported with
+// import.git, cloning the repository to storagePath. The module is verified
+// according to the verify block of the import, if any.
+func LockGitModule(ctx context.Context, storagePath string, args GitArguments) (LockedModule, error) {
+	if args.Version == "" {
+		return LockedModule{}, fmt.Errorf("the import of %s from %s has no version constraint", args.Path, args.Repository)
+	}
+
+	repo, err := vcs.NewGitRepo(ctx, storagePath, vcs.GitRepoOptions{
+		Repository: args.Repository,
+		Version:    args.Version,
+		Auth:       args.GitAuthConfig,
+	})
+	if err != nil {
+		return LockedModule{}, err
+	}
+
+	if args.Verify != nil && !args.Verify.signingKeys().Empty() {
+		if err := repo.VerifySignature(args.Verify.signingKeys()); err != nil {
+			return LockedModule{}, err
+		}
+	}
+
+	_, hash, err := readModule(repo, args.Path)
+	if err != nil {
+		return LockedModule{}, err
+	}
+	if args.Verify != nil && args.Verify.SHA256 != "" {
+		if err := compareSHA256(hash, args.Verify.SHA256); err != nil {
+			return LockedModule{}, fmt.Errorf("verifying %s: %w", args.Path, err)
+		}
+	}
+
+	commit, err := repo.CurrentRevision()
+	if err != nil {
+		return LockedModule{}, err
+	}
+	return LockedModule{
+		Repository: args.Repository,
+		Path:       args.Path,
+		Version:    args.Version,
+		Tag:        repo.Revision(),
+		Commit:     commit,
+		SHA256:     hash,
+	}, nil
+}
+
+// VendorGitModule copies the locked commit of m to its vendor directory
+// next to lockFile, cloning the repository to storagePath. The whole
+// repository is vendored, so that the module can import other modules of
+// the repository.
+func VendorGitModule(ctx context.Context, storagePath, lockFile string, m LockedModule, auth vcs.GitAuthConfig) error {
+	repo, err := vcs.NewGitRepo(ctx, storagePath, vcs.GitRepoOptions{
+		Repository: m.Repository,
+		Revision:   m.Commit,
+		Auth:       auth,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, hash, err := readModule(repo, m.Path)
+	if err != nil {
+		return err
+	}
+	if err := compareSHA256(hash, m.SHA256); err != nil {
+		return fmt.Errorf("verifying %s against the lock file: %w", m.Path, err)
+	}
+
+	vendorPath := VendorPath(lockFile, m)
+	if err := os.RemoveAll(vendorPath); err != nil {
+		return err
+	}
+	return copyWorktree(storagePath, vendorPath)
+}
+
+// copyWorktree copies the files of the worktree at src to dst, skipping the
+// git directory.
+func copyWorktree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir() && d.Name() == git.GitDirName:
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(filepath.Join(dst, rel), 0750)
+		case !d.Type().IsRegular():
+			// Symbolic links may point outside of the repository.
+			return nil
+		}
+
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), bb, 0640)
+	})
+}
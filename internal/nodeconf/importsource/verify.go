@@ -0,0 +1,180 @@
+package importsource
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// validateSHA256 validates a hex-encoded SHA-256 hash.
+func validateSHA256(hash string) error {
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("sha256 must be a hex-encoded SHA-256 hash")
+	}
+	return nil
+}
+
+// verifySHA256 verifies that the SHA-256 hash of content is the hex-encoded
+// expected hash.
+func verifySHA256(content []byte, expected string) error {
+	return compareSHA256(sha256Hex(content), expected)
+}
+
+func sha256Hex(content []byte) string {
+	actual := sha256.Sum256(content)
+	return hex.EncodeToString(actual[:])
+}
+
+// directorySHA256 returns the hex-encoded hash of the files of a directory.
+//
+// The hash of a directory is the SHA-256 hash of the output of sha256sum for
+// each file, sorted by file name, such that it can be computed with
+// `sha256sum *.alloy | sha256sum`.
+func directorySHA256(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sums bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256([]byte(files[name])), name)
+	}
+	return sha256Hex(sums.Bytes())
+}
+
+func compareSHA256(actual, expected string) error {
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("sha256 mismatch: expected %s, got %s", strings.ToLower(expected), actual)
+	}
+	return nil
+}
+
+// signatureVerifier verifies detached signatures.
+type signatureVerifier interface {
+	Verify(content, signature []byte) error
+}
+
+// parsePublicKey parses a public key used to verify detached signatures. The
+// key can either be a PEM-encoded ECDSA or Ed25519 public key, as used by
+// cosign, or a minisign public key.
+func parsePublicKey(publicKey string) (signatureVerifier, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	if block, _ := pem.Decode([]byte(publicKey)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key: %w", err)
+		}
+		switch key := key.(type) {
+		case *ecdsa.PublicKey, ed25519.PublicKey:
+			return pkixKey{key: key}, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+	return parseMinisignKey(publicKey)
+}
+
+// pkixKey verifies base64-encoded signatures of content, as created by
+// `cosign sign-blob`.
+type pkixKey struct {
+	key any
+}
+
+func (k pkixKey) Verify(content, signature []byte) error {
+	// Signatures are usually base64-encoded, but raw signatures are accepted
+	// as well.
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature))); err == nil {
+		signature = decoded
+	}
+
+	var ok bool
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(content)
+		ok = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, content, signature)
+	}
+	if !ok {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// minisignKey verifies signatures created by minisign.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+func parseMinisignKey(publicKey string) (minisignKey, error) {
+	// Public key files start with an untrusted comment, followed by the
+	// base64-encoded key.
+	lines := strings.Split(publicKey, "\n")
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return minisignKey{}, fmt.Errorf("public key must be a PEM-encoded public key or a minisign public key")
+	}
+
+	var k minisignKey
+	copy(k.id[:], b[2:10])
+	k.key = ed25519.PublicKey(b[10:])
+	return k, nil
+}
+
+func (k minisignKey) Verify(content, signature []byte) error {
+	// Signature files are made of four lines: an untrusted comment, the
+	// signature of the content, a trusted comment and the signature of the
+	// signature and trusted comment.
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 {
+		return fmt.Errorf("invalid minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	if !bytes.Equal(sig[2:10], k.id[:]) {
+		return fmt.Errorf("signed with an untrusted key %X", sig[2:10])
+	}
+
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		// The content is pre-hashed.
+		digest := blake2b.Sum512(content)
+		content = digest[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+	if !ed25519.Verify(k.key, content, sig[10:]) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	trustedComment, ok := strings.CutPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if !ok {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	if !ed25519.Verify(k.key, slices.Concat(sig[10:], []byte(trustedComment)), globalSig) {
+		return fmt.Errorf("invalid trusted comment signature")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package importsource
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestDirectorySHA256(t *testing.T) {
+	files := map[string]string{
+		"b.alloy": "b\n",
+		"a.alloy": "a\n",
+	}
+
+	// Equivalent to `sha256sum *.alloy | sha256sum`.
+	sums := fmt.Sprintf("%x  a.alloy\n%x  b.alloy\n", sha256.Sum256([]byte("a\n")), sha256.Sum256([]byte("b\n")))
+	expected := fmt.Sprintf("%X", sha256.Sum256([]byte(sums)))
+
+	require.NoError(t, compareSHA256(directorySHA256(files), expected))
+
+	files["b.alloy"] = "changed\n"
+	require.ErrorContains(t, compareSHA256(directorySHA256(files), expected), "sha256 mismatch")
+}
+
+func TestVerifySignature_PKIX(t *testing.T) {
+	content := []byte("declare \"test\" {}\n")
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256(content)
+	ecdsaSig, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+	require.NoError(t, err)
+
+	ed25519Pub, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ed25519Sig := ed25519.Sign(ed25519Key, content)
+
+	tt := []struct {
+		name      string
+		publicKey crypto.PublicKey
+		signature []byte
+	}{
+		{"ecdsa base64", &ecdsaKey.PublicKey, []byte(base64.StdEncoding.EncodeToString(ecdsaSig) + "\n")},
+		{"ed25519 base64", ed25519Pub, []byte(base64.StdEncoding.EncodeToString(ed25519Sig))},
+		{"ed25519 raw", ed25519Pub, ed25519Sig},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			der, err := x509.MarshalPKIXPublicKey(tc.publicKey)
+			require.NoError(t, err)
+			key, err := parsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+			require.NoError(t, err)
+
+			require.NoError(t, key.Verify(content, tc.signature))
+			require.Error(t, key.Verify([]byte("tampered"), tc.signature))
+		})
+	}
+}
+
+func TestVerifySignature_Minisign(t *testing.T) {
+	content := []byte("declare \"test\" {}\n")
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte("01234567")
+	publicKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(slices.Concat([]byte("Ed"), keyID, pub))
+
+	sign := func(algorithm string, message []byte) []byte {
+		sig := ed25519.Sign(priv, message)
+		trustedComment := "timestamp:1700000000"
+		globalSig := ed25519.Sign(priv, slices.Concat(sig, []byte(trustedComment)))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(slices.Concat([]byte(algorithm), keyID, sig)) + "\n" +
+			"trusted comment: " + trustedComment + "\n" +
+			base64.StdEncoding.EncodeToString(globalSig) + "\n")
+	}
+
+	key, err := parsePublicKey(publicKey)
+	require.NoError(t, err)
+
+	prehashed := blake2b.Sum512(content)
+	require.NoError(t, key.Verify(content, sign("ED", prehashed[:])))
+	require.NoError(t, key.Verify(content, sign("Ed", content)))
+	require.Error(t, key.Verify([]byte("tampered"), sign("ED", prehashed[:])))
+
+	// Signatures of other keys are refused.
+	otherKey, err := parsePublicKey(base64.StdEncoding.EncodeToString(slices.Concat([]byte("Ed"), []byte("76543210"), pub)))
+	require.NoError(t, err)
+	require.ErrorContains(t, otherKey.Verify(content, sign("ED", prehashed[:])), "untrusted key")
+}
@@ -0,0 +1,387 @@
+// Package oci implements a client to pull artifacts from OCI registries.
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/distribution/reference"
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// maxManifestSize is the maximum size of a manifest.
+	maxManifestSize = 4 << 20
+
+	// mediaTypeDockerManifest is the media type of Docker image manifests,
+	// which have the same layout as OCI image manifests.
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// BasicAuth configures the credentials used to authenticate to a registry.
+type BasicAuth struct {
+	Username string            `alloy:"username,attr"`
+	Password alloytypes.Secret `alloy:"password,attr"`
+}
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// Reference of the artifact, in the same format as image references, such
+	// as registry.example.com/modules/math:v1.0.0 or
+	// registry.example.com/modules/math@sha256:<digest>.
+	Reference string
+	BasicAuth *BasicAuth
+	// Insecure uses plain HTTP to connect to the registry.
+	Insecure bool
+}
+
+// Client pulls an artifact from an OCI registry.
+type Client struct {
+	opts       ClientOptions
+	cli        *http.Client
+	baseURL    string        // Base URL of the repository in the registry API.
+	repository string        // Name of the repository in the registry.
+	tag        string        // Tag of the artifact. Empty if pinned.
+	pinned     digest.Digest // Digest of the artifact if it's pinned.
+
+	mut           sync.Mutex
+	authorization string // Value of the Authorization header.
+}
+
+// Artifact is an artifact pulled from an OCI registry.
+type Artifact struct {
+	// Digest of the manifest of the artifact.
+	Digest digest.Digest
+	// Files holds the content of the layers of the artifact, by their title.
+	Files map[string][]byte
+}
+
+// NewClient creates a new Client.
+func NewClient(opts ClientOptions) (*Client, error) {
+	named, err := reference.ParseNormalizedNamed(opts.Reference)
+	if err != nil {
+		return nil, InvalidReferenceError{Reference: opts.Reference, Inner: err}
+	}
+
+	c := &Client{
+		opts:       opts,
+		cli:        &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+		repository: reference.Path(named),
+	}
+
+	switch ref := named.(type) {
+	case reference.Digested:
+		c.pinned = ref.Digest()
+	case reference.Tagged:
+		c.tag = ref.Tag()
+	default:
+		c.tag = "latest"
+	}
+
+	host := reference.Domain(named)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if opts.Insecure {
+		scheme = "http"
+	}
+	c.baseURL = fmt.Sprintf("%s://%s/v2/%s", scheme, host, c.repository)
+	return c, nil
+}
+
+// Close closes the idle connections to the registry.
+func (c *Client) Close() {
+	c.cli.CloseIdleConnections()
+}
+
+// Pinned returns true if the reference of the artifact is a digest, in which
+// case the artifact never changes.
+func (c *Client) Pinned() bool {
+	return c.pinned != ""
+}
+
+// Resolve returns the digest of the manifest the reference points to.
+func (c *Client) Resolve(ctx context.Context) (digest.Digest, error) {
+	if c.pinned != "" {
+		return c.pinned, nil
+	}
+
+	resp, err := c.get(ctx, c.baseURL+"/manifests/"+c.tag, http.MethodHead, manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	dgst, err := digest.Parse(resp.Header.Get("Docker-Content-Digest"))
+	if err == nil {
+		return dgst, nil
+	}
+
+	// Registries may not return the digest of manifests, in which case it has
+	// to be computed.
+	_, dgst, err = c.fetchManifest(ctx, c.tag)
+	return dgst, err
+}
+
+// Pull pulls the artifact whose manifest has the given digest.
+func (c *Client) Pull(ctx context.Context, dgst digest.Digest) (*Artifact, error) {
+	manifest, _, err := c.fetchManifest(ctx, dgst.String())
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &Artifact{
+		Digest: dgst,
+		Files:  make(map[string][]byte, len(manifest.Layers)),
+	}
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ocispec.AnnotationTitle]
+		if title == "" {
+			continue
+		}
+		if strings.ContainsAny(title, `/\`) || title == "." || title == ".." {
+			return nil, fmt.Errorf("invalid layer title %q", title)
+		}
+
+		content, err := c.fetchBlob(ctx, layer)
+		if err != nil {
+			return nil, fmt.Errorf("fetching layer %q: %w", title, err)
+		}
+		artifact.Files[title] = content
+	}
+	return artifact, nil
+}
+
+var manifestMediaTypes = []string{ocispec.MediaTypeImageManifest, mediaTypeDockerManifest}
+
+// fetchManifest fetches a manifest by tag or digest. If ref is a digest, the
+// digest of the manifest is verified.
+func (c *Client) fetchManifest(ctx context.Context, ref string) (ocispec.Manifest, digest.Digest, error) {
+	var manifest ocispec.Manifest
+
+	resp, err := c.get(ctx, c.baseURL+"/manifests/"+ref, http.MethodGet, manifestMediaTypes)
+	if err != nil {
+		return manifest, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return manifest, "", err
+	} else if len(body) > maxManifestSize {
+		return manifest, "", fmt.Errorf("manifest is larger than %d bytes", maxManifestSize)
+	}
+
+	dgst := digest.FromBytes(body)
+	if expected, err := digest.Parse(ref); err == nil && expected != dgst {
+		return manifest, "", DigestMismatchError{Expected: expected, Actual: dgst}
+	}
+
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return manifest, "", fmt.Errorf("decoding manifest: %w", err)
+	}
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType = resp.Header.Get("Content-Type")
+	}
+	if mediaType != ocispec.MediaTypeImageManifest && mediaType != mediaTypeDockerManifest {
+		return manifest, "", fmt.Errorf("unsupported manifest media type %q", mediaType)
+	}
+	return manifest, dgst, nil
+}
+
+// fetchBlob fetches a blob, verifying its size and digest.
+func (c *Client) fetchBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.get(ctx, c.baseURL+"/blobs/"+desc.Digest.String(), http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, desc.Size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) != desc.Size {
+		return nil, fmt.Errorf("expected %d bytes, got %d", desc.Size, len(content))
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(content); actual != desc.Digest {
+		return nil, DigestMismatchError{Expected: desc.Digest, Actual: actual}
+	}
+	return content, nil
+}
+
+// get sends a request to the registry, authenticating if the registry
+// requires it. The response is returned only if its status is 200.
+func (c *Client) get(ctx context.Context, url, method string, accept []string) (*http.Response, error) {
+	resp, err := c.send(ctx, url, method, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, challenge); err != nil {
+			return nil, fmt.Errorf("authenticating to the registry: %w", err)
+		}
+		if resp, err = c.send(ctx, url, method, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status code %s", method, url, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *Client) send(ctx context.Context, url, method string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	c.mut.Lock()
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	c.mut.Unlock()
+
+	return c.cli.Do(req)
+}
+
+// authenticate handles an authentication challenge of the registry, as
+// described by the Docker registry token authentication specification.
+func (c *Client) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.opts.BasicAuth == nil {
+			return fmt.Errorf("the registry requires credentials")
+		}
+		credentials := c.opts.BasicAuth.Username + ":" + string(c.opts.BasicAuth.Password)
+		c.setAuthorization("Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)))
+		return nil
+
+	case "bearer":
+		token, err := c.fetchToken(ctx, params)
+		if err != nil {
+			return err
+		}
+		c.setAuthorization("Bearer " + token)
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+func (c *Client) setAuthorization(authorization string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.authorization = authorization
+}
+
+// fetchToken fetches a bearer token from the authorization service of the
+// registry.
+func (c *Client) fetchToken(ctx context.Context, params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", c.repository)
+	}
+	query := realm.Query()
+	query.Set("scope", scope)
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.opts.BasicAuth != nil {
+		req.SetBasicAuth(c.opts.BasicAuth.Username, string(c.opts.BasicAuth.Password))
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching token: unexpected status code %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", fmt.Errorf("the token response is empty")
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params = make(map[string]string)
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		var key string
+		key, rest, _ = strings.Cut(rest, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// Quoted values may contain commas.
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+			_, rest, _ = strings.Cut(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		params[key] = strings.TrimSpace(value)
+		rest = strings.TrimSpace(rest)
+	}
+	return scheme, params
+}
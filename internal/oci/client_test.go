@@ -0,0 +1,67 @@
+package oci_test
+
+import (
+	"testing"
+
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireAuth("user", "password")
+	v1 := registry.Push("modules/math", "v1", map[string]string{"math.alloy": "v1"})
+
+	c, err := oci.NewClient(oci.ClientOptions{
+		Reference: registry.Host() + "/modules/math:v1",
+		BasicAuth: &oci.BasicAuth{Username: "user", Password: "password"},
+		Insecure:  true,
+	})
+	require.NoError(t, err)
+	require.False(t, c.Pinned())
+
+	dgst, err := c.Resolve(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, v1, dgst)
+
+	artifact, err := c.Pull(t.Context(), dgst)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"math.alloy": []byte("v1")}, artifact.Files)
+
+	// Tags are resolved again, unlike digests.
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": "v2"})
+	dgst, err = c.Resolve(t.Context())
+	require.NoError(t, err)
+	require.NotEqual(t, v1, dgst)
+
+	pinned, err := oci.NewClient(oci.ClientOptions{
+		Reference: registry.Host() + "/modules/math@" + v1.String(),
+		BasicAuth: &oci.BasicAuth{Username: "user", Password: "password"},
+		Insecure:  true,
+	})
+	require.NoError(t, err)
+	require.True(t, pinned.Pinned())
+	dgst, err = pinned.Resolve(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, v1, dgst)
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireAuth("user", "password")
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": "v1"})
+
+	c, err := oci.NewClient(oci.ClientOptions{
+		Reference: registry.Host() + "/modules/math:v1",
+		Insecure:  true,
+	})
+	require.NoError(t, err)
+	_, err = c.Resolve(t.Context())
+	require.ErrorContains(t, err, "authenticating to the registry")
+}
+
+func TestNewClient_InvalidReference(t *testing.T) {
+	_, err := oci.NewClient(oci.ClientOptions{Reference: "Invalid Reference"})
+	require.ErrorAs(t, err, &oci.InvalidReferenceError{})
+}
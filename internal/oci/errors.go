@@ -0,0 +1,34 @@
+package oci
+
+import (
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// InvalidReferenceError is returned when the reference of an artifact can't
+// be parsed.
+type InvalidReferenceError struct {
+	Reference string
+	Inner     error
+}
+
+// Error returns the error string, denoting the invalid reference.
+func (err InvalidReferenceError) Error() string {
+	return fmt.Sprintf("invalid reference %q: %s", err.Reference, err.Inner)
+}
+
+// Unwrap returns the inner error.
+func (err InvalidReferenceError) Unwrap() error { return err.Inner }
+
+// DigestMismatchError is returned when the content pulled from a registry
+// doesn't match its expected digest.
+type DigestMismatchError struct {
+	Expected digest.Digest
+	Actual   digest.Digest
+}
+
+// Error returns the error string, denoting the mismatched digests.
+func (err DigestMismatchError) Error() string {
+	return fmt.Sprintf("digest mismatch: expected %s, got %s", err.Expected, err.Actual)
+}
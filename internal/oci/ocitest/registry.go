@@ -0,0 +1,161 @@
+// Package ocitest provides a fake OCI registry for tests.
+package ocitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Registry is a fake OCI registry serving artifacts from memory. Artifacts
+// are pushed the same way as with `oras push`: each file is a layer whose
+// title is the name of the file.
+type Registry struct {
+	srv *httptest.Server
+
+	mut       sync.Mutex
+	manifests map[string][]byte // Manifests by repository and digest.
+	tags      map[string]digest.Digest
+	blobs     map[digest.Digest][]byte
+	username  string
+	password  string
+	token     string
+}
+
+// NewRegistry starts a new Registry. The Registry is closed when the test
+// ends.
+func NewRegistry(t testing.TB) *Registry {
+	r := &Registry{
+		manifests: make(map[string][]byte),
+		tags:      make(map[string]digest.Digest),
+		blobs:     make(map[digest.Digest][]byte),
+	}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+// Host returns the host of the Registry, to use in references.
+func (r *Registry) Host() string {
+	u, _ := url.Parse(r.srv.URL)
+	return u.Host
+}
+
+// RequireAuth requires clients to authenticate with a bearer token, which
+// is given in exchange for the credentials.
+func (r *Registry) RequireAuth(username, password string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.username, r.password, r.token = username, password, "token-for-"+username
+}
+
+// Push pushes an artifact made of files to repository and tags it. The
+// digest of the manifest of the artifact is returned.
+func (r *Registry) Push(repository, tag string, files map[string]string) digest.Digest {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	config := []byte("{}")
+	r.blobs[digest.FromBytes(config)] = config
+
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.grafana.alloy.module",
+		Config:       ocispec.DescriptorEmptyJSON,
+	}
+	for _, name := range names {
+		content := []byte(files[name])
+		dgst := digest.FromBytes(content)
+		r.blobs[dgst] = content
+		manifest.Layers = append(manifest.Layers, ocispec.Descriptor{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar",
+			Digest:      dgst,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ocispec.AnnotationTitle: name},
+		})
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		panic(err)
+	}
+	dgst := digest.FromBytes(body)
+	r.manifests[repository+"@"+dgst.String()] = body
+	r.tags[repository+":"+tag] = dgst
+	return dgst
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if req.URL.Path == "/token" {
+		username, password, _ := req.BasicAuth()
+		if username != r.username || password != r.password {
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+		return
+	}
+
+	if r.token != "" && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="ocitest"`, r.srv.URL))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	if i := strings.LastIndex(path, "/manifests/"); i >= 0 {
+		repository, ref := path[:i], path[i+len("/manifests/"):]
+		dgst, err := digest.Parse(ref)
+		if err != nil {
+			dgst = r.tags[repository+":"+ref]
+		}
+		body, ok := r.manifests[repository+"@"+dgst.String()]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		if req.Method != http.MethodHead {
+			_, _ = w.Write(body)
+		}
+		return
+	}
+
+	if i := strings.LastIndex(path, "/blobs/"); i >= 0 {
+		blob, ok := r.blobs[digest.Digest(path[i+len("/blobs/"):])]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(blob)
+		return
+	}
+
+	http.NotFound(w, req)
+}
@@ -0,0 +1,191 @@
+// Package pipelinetest runs unit tests of Alloy configurations.
+//
+// A test file declares the configuration under test and a list of tests.
+// Each test replaces some components of the configuration with mocks which
+// capture the data sent to them, sends fixture log entries or samples to the
+// receivers exported by other components, and compares the captured data
+// with the expected data. Log entries, Prometheus samples and OTLP data are
+// supported.
+package pipelinetest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/grafana/alloy/syntax"
+)
+
+// FileExtension is the extension of test files.
+const FileExtension = ".alloytest"
+
+// File is a test file.
+type File struct {
+	// Path of the test file.
+	Path string
+
+	// Config is the path of the configuration under test, relative to the
+	// test file. It can be a file or a directory of .alloy files.
+	Config string `alloy:"config,attr"`
+	Tests  []Test `alloy:"test,block"`
+}
+
+// Test is a single test of a File.
+type Test struct {
+	Name string `alloy:",label"`
+
+	MockLogs    []Mock `alloy:"mock_logs,block,optional"`
+	MockMetrics []Mock `alloy:"mock_metrics,block,optional"`
+	MockOTLP    []Mock `alloy:"mock_otlp,block,optional"`
+
+	SendLogs    []SendLogs    `alloy:"send_logs,block,optional"`
+	SendMetrics []SendMetrics `alloy:"send_metrics,block,optional"`
+	SendOTLP    []SendOTLP    `alloy:"send_otlp,block,optional"`
+
+	ExpectLogs    []ExpectLogs    `alloy:"expect_logs,block,optional"`
+	ExpectMetrics []ExpectMetrics `alloy:"expect_metrics,block,optional"`
+	ExpectOTLP    []ExpectOTLP    `alloy:"expect_otlp,block,optional"`
+}
+
+// Mock replaces the component with the ID set in the component attribute. The mock
+// exports a receiver capturing the data sent to it.
+type Mock struct {
+	ID string `alloy:"component,attr"`
+}
+
+// SendLogs sends log entries to the receiver exported by a component. Target
+// is the receiver to use, such as loki.process.default.receiver.
+type SendLogs struct {
+	Target  string     `alloy:"receiver,attr"`
+	Entries []LogEntry `alloy:"entry,block,optional"`
+}
+
+// SendMetrics sends samples to the receiver exported by a component. Target
+// is the receiver to use, such as prometheus.relabel.default.receiver.
+type SendMetrics struct {
+	Target  string   `alloy:"receiver,attr"`
+	Samples []Sample `alloy:"sample,block,optional"`
+}
+
+// SendOTLP sends OTLP data to the input exported by an otelcol component.
+// Target is the input to use, such as otelcol.processor.batch.default.input.
+// Logs, Metrics and Traces are encoded in the OTLP JSON format.
+type SendOTLP struct {
+	Target string   `alloy:"receiver,attr"`
+	Data   OTLPData `alloy:",squash"`
+}
+
+// ExpectLogs holds the log entries expected to be captured by the mock of a
+// component, in order.
+type ExpectLogs struct {
+	ID      string     `alloy:"component,attr"`
+	Entries []LogEntry `alloy:"entry,block,optional"`
+}
+
+// ExpectMetrics holds the samples expected to be captured by the mock of a
+// component. Only the latest sample of each series is compared.
+type ExpectMetrics struct {
+	ID      string   `alloy:"component,attr"`
+	Samples []Sample `alloy:"sample,block,optional"`
+}
+
+// ExpectOTLP holds the OTLP data expected to be captured by the mock of a
+// component, encoded in the OTLP JSON format. Only the signals which are set
+// are compared. The resources of the batches captured by the mock are
+// compared in any order.
+type ExpectOTLP struct {
+	ID   string   `alloy:"component,attr"`
+	Data OTLPData `alloy:",squash"`
+}
+
+// OTLPData holds OTLP logs, metrics and traces encoded in the OTLP JSON
+// format.
+type OTLPData struct {
+	Logs    string `alloy:"logs,attr,optional"`
+	Metrics string `alloy:"metrics,attr,optional"`
+	Traces  string `alloy:"traces,attr,optional"`
+}
+
+// LogEntry is a log entry. When Timestamp isn't set, log entries are sent
+// with the time the test started at and captured timestamps aren't compared.
+// Captured structured metadata is only compared when StructuredMetadata is
+// set.
+type LogEntry struct {
+	Labels             map[string]string `alloy:"labels,attr,optional"`
+	StructuredMetadata map[string]string `alloy:"structured_metadata,attr,optional"`
+	Line               string            `alloy:"line,attr"`
+	Timestamp          time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+// Sample is a sample of a series. When Timestamp isn't set, samples are sent
+// with the time the test started at and captured timestamps aren't compared.
+type Sample struct {
+	Labels    map[string]string `alloy:"labels,attr"`
+	Value     float64           `alloy:"value,attr"`
+	Timestamp time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+// Validate implements syntax.Validator.
+func (t *Test) Validate() error {
+	mocks := make(map[string]struct{})
+	for _, m := range slices.Concat(t.MockLogs, t.MockMetrics, t.MockOTLP) {
+		if _, ok := mocks[m.ID]; ok {
+			return fmt.Errorf("component %s is mocked more than once", m.ID)
+		}
+		mocks[m.ID] = struct{}{}
+	}
+
+	for _, e := range t.ExpectLogs {
+		if !hasMock(t.MockLogs, e.ID) {
+			return fmt.Errorf("expect_logs for %s doesn't refer to a mock_logs block", e.ID)
+		}
+	}
+	for _, e := range t.ExpectMetrics {
+		if !hasMock(t.MockMetrics, e.ID) {
+			return fmt.Errorf("expect_metrics for %s doesn't refer to a mock_metrics block", e.ID)
+		}
+	}
+	for _, e := range t.ExpectOTLP {
+		if !hasMock(t.MockOTLP, e.ID) {
+			return fmt.Errorf("expect_otlp for %s doesn't refer to a mock_otlp block", e.ID)
+		}
+		if _, err := e.Data.decode(); err != nil {
+			return fmt.Errorf("expect_otlp for %s: %w", e.ID, err)
+		}
+	}
+	for _, s := range t.SendOTLP {
+		if _, err := s.Data.decode(); err != nil {
+			return fmt.Errorf("send_otlp to %s: %w", s.Target, err)
+		}
+	}
+	return nil
+}
+
+func hasMock(mocks []Mock, id string) bool {
+	return slices.ContainsFunc(mocks, func(m Mock) bool { return m.ID == id })
+}
+
+// LoadFile reads and decodes the test file at path.
+func LoadFile(path string) (*File, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := syntax.Unmarshal(bb, &f); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	f.Path = path
+	return &f, nil
+}
+
+// ConfigPath returns the path of the configuration under test.
+func (f *File) ConfigPath() string {
+	if filepath.IsAbs(f.Config) {
+		return f.Config
+	}
+	return filepath.Join(filepath.Dir(f.Path), f.Config)
+}
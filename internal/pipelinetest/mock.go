@@ -0,0 +1,140 @@
+package pipelinetest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/util/testappender"
+)
+
+// logsExports are the exports of a mock standing in for a component which
+// receives log entries, such as loki.write.
+type logsExports struct {
+	Receiver loki.LogsReceiver `alloy:"receiver,attr"`
+}
+
+// metricsExports are the exports of a mock standing in for a component which
+// receives samples, such as prometheus.remote_write.
+type metricsExports struct {
+	Receiver storage.Appendable `alloy:"receiver,attr"`
+}
+
+// logsMock captures the log entries sent to its receiver.
+type logsMock struct {
+	receiver loki.LogsReceiver
+
+	mut     sync.Mutex
+	entries []loki.Entry
+}
+
+// newLogsMock creates a logsMock capturing log entries until ctx is
+// canceled.
+func newLogsMock(ctx context.Context) *logsMock {
+	m := &logsMock{receiver: loki.NewLogsReceiver()}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry := <-m.receiver.Chan():
+				m.mut.Lock()
+				m.entries = append(m.entries, entry)
+				m.mut.Unlock()
+			}
+		}
+	}()
+	return m
+}
+
+// Entries returns the captured log entries.
+func (m *logsMock) Entries() []loki.Entry {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return append([]loki.Entry(nil), m.entries...)
+}
+
+// metricsMock captures the latest sample of each series appended to it.
+type metricsMock struct {
+	testappender.ConstantAppendable
+}
+
+var _ storage.Appendable = (*metricsMock)(nil)
+
+func newMetricsMock() *metricsMock {
+	return &metricsMock{
+		ConstantAppendable: testappender.ConstantAppendable{Inner: testappender.NewCollectingAppender()},
+	}
+}
+
+// Samples returns the latest sample of each captured series, by series.
+func (m *metricsMock) Samples() map[string]*testappender.MetricSample {
+	return m.Inner.CollectedSamples()
+}
+
+func compareLogs(expect ExpectLogs, got []loki.Entry) []string {
+	var failures []string
+	if len(got) != len(expect.Entries) {
+		failures = append(failures, fmt.Sprintf("%s: expected %d log entries, got %d", expect.ID, len(expect.Entries), len(got)))
+	}
+
+	for i := 0; i < min(len(got), len(expect.Entries)); i++ {
+		want := expect.Entries[i]
+		if got[i].Line != want.Line {
+			failures = append(failures, fmt.Sprintf("%s: entry %d: expected line %q, got %q", expect.ID, i, want.Line, got[i].Line))
+		}
+		if wantLabels := labelSet(want.Labels); !got[i].Labels.Equal(wantLabels) {
+			failures = append(failures, fmt.Sprintf("%s: entry %d: expected labels %s, got %s", expect.ID, i, wantLabels, got[i].Labels))
+		}
+		if want.StructuredMetadata != nil {
+			wantMetadata, gotMetadata := labelSet(want.StructuredMetadata), metadataLabelSet(got[i].StructuredMetadata)
+			if !gotMetadata.Equal(wantMetadata) {
+				failures = append(failures, fmt.Sprintf("%s: entry %d: expected structured metadata %s, got %s", expect.ID, i, wantMetadata, gotMetadata))
+			}
+		}
+		if !want.Timestamp.IsZero() && !got[i].Timestamp.Equal(want.Timestamp) {
+			failures = append(failures, fmt.Sprintf("%s: entry %d: expected timestamp %s, got %s", expect.ID, i, want.Timestamp, got[i].Timestamp))
+		}
+	}
+	return failures
+}
+
+func compareMetrics(expect ExpectMetrics, got map[string]*testappender.MetricSample) []string {
+	var (
+		failures []string
+		expected = make(map[string]bool, len(expect.Samples))
+	)
+	for _, want := range expect.Samples {
+		series := labels.FromMap(want.Labels).String()
+		expected[series] = true
+
+		sample, ok := got[series]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%s: expected series %s wasn't captured", expect.ID, series))
+			continue
+		}
+		if sample.Value != want.Value {
+			failures = append(failures, fmt.Sprintf("%s: series %s: expected value %v, got %v", expect.ID, series, want.Value, sample.Value))
+		}
+		if !want.Timestamp.IsZero() && sample.Timestamp != want.Timestamp.UnixMilli() {
+			failures = append(failures, fmt.Sprintf("%s: series %s: expected timestamp %d, got %d", expect.ID, series, want.Timestamp.UnixMilli(), sample.Timestamp))
+		}
+	}
+
+	var unexpected []string
+	for series := range got {
+		if !expected[series] {
+			unexpected = append(unexpected, series)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, series := range unexpected {
+		failures = append(failures, fmt.Sprintf("%s: unexpected series %s", expect.ID, series))
+	}
+	return failures
+}
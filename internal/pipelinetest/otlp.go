@@ -0,0 +1,226 @@
+package pipelinetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/plogtest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/pmetrictest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/ptracetest"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component/otelcol"
+	"github.com/grafana/alloy/internal/runtime"
+)
+
+// otlpExports are the exports of a mock standing in for a component which
+// receives OTLP data, such as otelcol.exporter.otlp.
+type otlpExports struct {
+	Input otelcol.Consumer `alloy:"input,attr"`
+}
+
+// otlpSignals holds decoded OTLP data. Signals which weren't set are nil.
+type otlpSignals struct {
+	logs    *plog.Logs
+	metrics *pmetric.Metrics
+	traces  *ptrace.Traces
+}
+
+// decode decodes the signals set in d.
+func (d OTLPData) decode() (otlpSignals, error) {
+	var s otlpSignals
+	if d.Logs != "" {
+		logs, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(d.Logs))
+		if err != nil {
+			return s, fmt.Errorf("decoding logs: %w", err)
+		}
+		s.logs = &logs
+	}
+	if d.Metrics != "" {
+		metrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(d.Metrics))
+		if err != nil {
+			return s, fmt.Errorf("decoding metrics: %w", err)
+		}
+		s.metrics = &metrics
+	}
+	if d.Traces != "" {
+		traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(d.Traces))
+		if err != nil {
+			return s, fmt.Errorf("decoding traces: %w", err)
+		}
+		s.traces = &traces
+	}
+	return s, nil
+}
+
+// otlpMock captures the OTLP data sent to it. The resources of all the
+// captured batches are appended to a single batch per signal.
+type otlpMock struct {
+	mut     sync.Mutex
+	logs    plog.Logs
+	metrics pmetric.Metrics
+	traces  ptrace.Traces
+}
+
+var _ otelcol.Consumer = (*otlpMock)(nil)
+
+func newOTLPMock() *otlpMock {
+	return &otlpMock{
+		logs:    plog.NewLogs(),
+		metrics: pmetric.NewMetrics(),
+		traces:  ptrace.NewTraces(),
+	}
+}
+
+// Capabilities implements otelcol.Consumer.
+func (m *otlpMock) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeLogs implements otelcol.Consumer.
+func (m *otlpMock) ConsumeLogs(_ context.Context, ld plog.Logs) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		ld.ResourceLogs().At(i).CopyTo(m.logs.ResourceLogs().AppendEmpty())
+	}
+	return nil
+}
+
+// ConsumeMetrics implements otelcol.Consumer.
+func (m *otlpMock) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		md.ResourceMetrics().At(i).CopyTo(m.metrics.ResourceMetrics().AppendEmpty())
+	}
+	return nil
+}
+
+// ConsumeTraces implements otelcol.Consumer.
+func (m *otlpMock) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		td.ResourceSpans().At(i).CopyTo(m.traces.ResourceSpans().AppendEmpty())
+	}
+	return nil
+}
+
+// Signals returns a copy of the captured data.
+func (m *otlpMock) Signals() otlpSignals {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	var (
+		logs    = plog.NewLogs()
+		metrics = pmetric.NewMetrics()
+		traces  = ptrace.NewTraces()
+	)
+	m.logs.CopyTo(logs)
+	m.metrics.CopyTo(metrics)
+	m.traces.CopyTo(traces)
+	return otlpSignals{logs: &logs, metrics: &metrics, traces: &traces}
+}
+
+// captured returns true if got holds at least as many log records, data
+// points and spans as expect.
+func (expect otlpSignals) captured(got otlpSignals) bool {
+	switch {
+	case expect.logs != nil && got.logs.LogRecordCount() < expect.logs.LogRecordCount():
+		return false
+	case expect.metrics != nil && got.metrics.DataPointCount() < expect.metrics.DataPointCount():
+		return false
+	case expect.traces != nil && got.traces.SpanCount() < expect.traces.SpanCount():
+		return false
+	}
+	return true
+}
+
+func compareOTLP(expect ExpectOTLP, got otlpSignals) []string {
+	want, err := expect.Data.decode()
+	if err != nil {
+		// The data is validated when loading the test file.
+		return []string{fmt.Sprintf("%s: %s", expect.ID, err)}
+	}
+
+	var failures []string
+	if want.logs != nil {
+		err := plogtest.CompareLogs(*want.logs, *got.logs,
+			plogtest.IgnoreResourceLogsOrder(),
+			plogtest.IgnoreScopeLogsOrder(),
+		)
+		failures = appendOTLPFailure(failures, expect.ID, "logs", err)
+	}
+	if want.metrics != nil {
+		err := pmetrictest.CompareMetrics(*want.metrics, *got.metrics,
+			pmetrictest.IgnoreResourceMetricsOrder(),
+			pmetrictest.IgnoreScopeMetricsOrder(),
+			pmetrictest.IgnoreMetricsOrder(),
+			pmetrictest.IgnoreMetricDataPointsOrder(),
+		)
+		failures = appendOTLPFailure(failures, expect.ID, "metrics", err)
+	}
+	if want.traces != nil {
+		err := ptracetest.CompareTraces(*want.traces, *got.traces,
+			ptracetest.IgnoreResourceSpansOrder(),
+			ptracetest.IgnoreScopeSpansOrder(),
+		)
+		failures = appendOTLPFailure(failures, expect.ID, "traces", err)
+	}
+	return failures
+}
+
+// appendOTLPFailure appends a failure for each of the differences reported by
+// err.
+func appendOTLPFailure(failures []string, id string, signal string, err error) []string {
+	if err == nil {
+		return failures
+	}
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return append(failures, fmt.Sprintf("%s: %s: %s", id, signal, err))
+	}
+	for _, err := range joined.Unwrap() {
+		failures = append(failures, fmt.Sprintf("%s: %s: %s", id, signal, err))
+	}
+	return failures
+}
+
+func sendOTLP(ctx context.Context, ctrl *runtime.Runtime, s SendOTLP) error {
+	export, err := lookupExport(ctrl, s.Target)
+	if err != nil {
+		return err
+	}
+	receiver, ok := export.(otelcol.Consumer)
+	if !ok {
+		return fmt.Errorf("%s isn't a receiver of OTLP data", s.Target)
+	}
+
+	data, err := s.Data.decode()
+	if err != nil {
+		return fmt.Errorf("sending OTLP data to %s: %w", s.Target, err)
+	}
+	if data.logs != nil {
+		if err := receiver.ConsumeLogs(ctx, *data.logs); err != nil {
+			return fmt.Errorf("sending logs to %s: %w", s.Target, err)
+		}
+	}
+	if data.metrics != nil {
+		if err := receiver.ConsumeMetrics(ctx, *data.metrics); err != nil {
+			return fmt.Errorf("sending metrics to %s: %w", s.Target, err)
+		}
+	}
+	if data.traces != nil {
+		if err := receiver.ConsumeTraces(ctx, *data.traces); err != nil {
+			return fmt.Errorf("sending traces to %s: %w", s.Target, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package pipelinetest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/grafana/alloy/internal/component/loki/process"
+	_ "github.com/grafana/alloy/internal/component/loki/write"
+	_ "github.com/grafana/alloy/internal/component/otelcol/exporter/otlp"
+	_ "github.com/grafana/alloy/internal/component/otelcol/processor/attributes"
+	_ "github.com/grafana/alloy/internal/component/prometheus/relabel"
+	_ "github.com/grafana/alloy/internal/component/prometheus/remotewrite"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+func TestRun(t *testing.T) {
+	f, err := LoadFile("testdata/pipeline.alloytest")
+	require.NoError(t, err)
+	require.Equal(t, "testdata/pipeline.alloy", f.ConfigPath())
+
+	config, err := os.ReadFile(f.ConfigPath())
+	require.NoError(t, err)
+	sources := map[string][]byte{f.ConfigPath(): config}
+
+	opts := Options{
+		MinStability: featuregate.StabilityGenerallyAvailable,
+		Timeout:      5 * time.Second,
+	}
+
+	results := make(map[string]Result)
+	for _, test := range f.Tests {
+		results[test.Name] = Run(t.Context(), f.ConfigPath(), sources, test, opts)
+	}
+
+	for _, name := range []string{"drops_debug_lines", "drops_dev_series", "keeps_trace_ids", "adds_env_attribute"} {
+		require.NoError(t, results[name].Err, name)
+		require.Empty(t, results[name].Failures, name)
+		require.True(t, results[name].Passed(), name)
+	}
+
+	failed := results["fails"]
+	require.NoError(t, failed.Err)
+	require.False(t, failed.Passed())
+	require.Contains(t, failed.Failures, `loki.write.default: entry 0: expected line "level=info msg=goodbye", got "level=info msg=hello"`)
+
+	failedOTLP := results["fails_otlp"]
+	require.NoError(t, failedOTLP.Err)
+	require.False(t, failedOTLP.Passed())
+	require.Contains(t, failedOTLP.Failures, `otelcol.exporter.otlp.default: logs: resource "map[]": scope "": unexpected log record: map[env:prod]`)
+}
+
+func TestRun_UnknownMock(t *testing.T) {
+	sources := map[string][]byte{"config.alloy": []byte(`loki.process "default" { forward_to = [] }`)}
+	test := Test{
+		Name:     "unknown",
+		MockLogs: []Mock{{ID: "loki.write.default"}},
+	}
+
+	res := Run(t.Context(), "config.alloy", sources, test, Options{Timeout: time.Second})
+	require.EqualError(t, res.Err, "mocked component loki.write.default isn't declared in the configuration")
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := t.TempDir() + "/invalid" + FileExtension
+	require.NoError(t, os.WriteFile(path, []byte(`
+		config = "config.alloy"
+
+		test "invalid" {
+			expect_logs {
+				component = "loki.write.default"
+			}
+		}
+	`), 0o644))
+
+	_, err := LoadFile(path)
+	require.ErrorContains(t, err, "expect_logs for loki.write.default doesn't refer to a mock_logs block")
+}
@@ -0,0 +1,403 @@
+package pipelinetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+// settleTime is how long a test waits once the expected data is captured, to
+// catch unexpected data sent afterwards.
+const settleTime = 100 * time.Millisecond
+
+// Options configures how tests are run.
+type Options struct {
+	// MinStability is the minimum stability level of the components which can
+	// be used by the configuration under test.
+	MinStability featuregate.Stability
+	// EnableCommunityComps enables the use of community components.
+	EnableCommunityComps bool
+	// Timeout is the maximum duration of a test.
+	Timeout time.Duration
+	// Logger is used by the components of the configuration under test. A
+	// no-op logger is used if Logger is nil.
+	Logger *logging.Logger
+}
+
+// Result is the result of a test.
+type Result struct {
+	Name     string
+	Duration time.Duration
+
+	// Failures describes the differences between the expected and the
+	// captured data.
+	Failures []string
+	// Err is set when the test couldn't run, for example because the
+	// configuration under test is invalid.
+	Err error
+}
+
+// Passed returns true if the test ran and captured the expected data.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Run runs test against the configuration made of sources, read from
+// configPath.
+func Run(ctx context.Context, configPath string, sources map[string][]byte, test Test, opts Options) Result {
+	start := time.Now()
+	res := Result{Name: test.Name}
+	res.Failures, res.Err = run(ctx, configPath, sources, test, opts, start)
+	res.Duration = time.Since(start)
+	return res
+}
+
+func run(ctx context.Context, configPath string, sources map[string][]byte, test Test, opts Options, start time.Time) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var (
+		variables = make(map[string]any)
+		mocked    = make(map[string]bool)
+
+		logs    = make(map[string]*logsMock, len(test.MockLogs))
+		metrics = make(map[string]*metricsMock, len(test.MockMetrics))
+		otlp    = make(map[string]*otlpMock, len(test.MockOTLP))
+	)
+	for _, m := range test.MockLogs {
+		mock := newLogsMock(ctx)
+		logs[m.ID] = mock
+		mocked[m.ID] = false
+		setVariable(variables, m.ID, logsExports{Receiver: mock.receiver})
+	}
+	for _, m := range test.MockMetrics {
+		mock := newMetricsMock()
+		metrics[m.ID] = mock
+		mocked[m.ID] = false
+		setVariable(variables, m.ID, metricsExports{Receiver: mock})
+	}
+	for _, m := range test.MockOTLP {
+		mock := newOTLPMock()
+		otlp[m.ID] = mock
+		mocked[m.ID] = false
+		setVariable(variables, m.ID, otlpExports{Input: mock})
+	}
+
+	source, err := mockedSource(sources, mocked)
+	if err != nil {
+		return nil, err
+	}
+
+	dataPath, err := os.MkdirTemp("", "alloy-test-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dataPath)
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.NewNop()
+	}
+
+	reg := prometheus.NewRegistry()
+	ctrl := runtime.New(runtime.Options{
+		Logger:               logger,
+		DataPath:             dataPath,
+		Reg:                  reg,
+		MinStability:         opts.MinStability,
+		EnableCommunityComps: opts.EnableCommunityComps,
+		Services: []service.Service{
+			labelstore.New(nil, reg),
+			livedebugging.New(),
+		},
+	})
+	if err := ctrl.LoadSourceWithVariables(source, nil, configPath, variables); err != nil {
+		return nil, fmt.Errorf("loading configuration: %w", err)
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run(runCtx)
+	}()
+	defer func() {
+		stop()
+		<-done
+	}()
+
+	for _, s := range test.SendLogs {
+		if err := sendLogs(ctx, ctrl, s, start); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range test.SendMetrics {
+		if err := sendMetrics(ctx, ctrl, s, start); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range test.SendOTLP {
+		if err := sendOTLP(ctx, ctrl, s); err != nil {
+			return nil, err
+		}
+	}
+
+	waitForData(ctx, test, logs, metrics, otlp)
+
+	var failures []string
+	for _, e := range test.ExpectLogs {
+		failures = append(failures, compareLogs(e, logs[e.ID].Entries())...)
+	}
+	for _, e := range test.ExpectMetrics {
+		failures = append(failures, compareMetrics(e, metrics[e.ID].Samples())...)
+	}
+	for _, e := range test.ExpectOTLP {
+		failures = append(failures, compareOTLP(e, otlp[e.ID].Signals())...)
+	}
+	return failures, nil
+}
+
+// mockedSource parses sources, removing the blocks of the mocked components.
+// The values of mocked are set to true once the block of the component is
+// found.
+func mockedSource(sources map[string][]byte, mocked map[string]bool) (*runtime.Source, error) {
+	filtered := make(map[string][]byte, len(sources))
+	for name, bb := range sources {
+		f, err := parser.ParseFile(name, bb)
+		if err != nil {
+			return nil, err
+		}
+
+		body := make(ast.Body, 0, len(f.Body))
+		for _, stmt := range f.Body {
+			if b, ok := stmt.(*ast.BlockStmt); ok {
+				id := blockID(b)
+				if _, ok := mocked[id]; ok {
+					mocked[id] = true
+					continue
+				}
+			}
+			body = append(body, stmt)
+		}
+		f.Body = body
+
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, f); err != nil {
+			return nil, err
+		}
+		filtered[name] = buf.Bytes()
+	}
+
+	for id, found := range mocked {
+		if !found {
+			return nil, fmt.Errorf("mocked component %s isn't declared in the configuration", id)
+		}
+	}
+	return runtime.ParseSources(filtered)
+}
+
+// blockID returns the ID of the component declared by b.
+func blockID(b *ast.BlockStmt) string {
+	if b.Label == "" {
+		return b.GetBlockName()
+	}
+	return b.GetBlockName() + "." + b.Label
+}
+
+// setVariable sets the value of the component id in variables, creating the
+// nested maps of the component name.
+func setVariable(variables map[string]any, id string, value any) {
+	parts := strings.Split(id, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := variables[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			variables[part] = next
+		}
+		variables = next
+	}
+	variables[parts[len(parts)-1]] = value
+}
+
+// lookupExport returns the export of a running component referred to by
+// target, such as loki.process.default.receiver.
+func lookupExport(ctrl *runtime.Runtime, target string) (any, error) {
+	idx := strings.LastIndex(target, ".")
+	if idx == -1 {
+		return nil, fmt.Errorf("%q isn't the export of a component", target)
+	}
+	id, name := target[:idx], target[idx+1:]
+
+	info, err := ctrl.GetComponent(component.ID{LocalID: id}, component.InfoOptions{GetExports: true})
+	if err != nil {
+		return nil, fmt.Errorf("looking up component %s: %w", id, err)
+	}
+
+	rv := reflect.ValueOf(info.Exports)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		for i := 0; i < rv.NumField(); i++ {
+			tag, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("alloy"), ",")
+			if tag == name {
+				return rv.Field(i).Interface(), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("component %s doesn't export %q", id, name)
+}
+
+func sendLogs(ctx context.Context, ctrl *runtime.Runtime, s SendLogs, start time.Time) error {
+	export, err := lookupExport(ctrl, s.Target)
+	if err != nil {
+		return err
+	}
+	receiver, ok := export.(loki.LogsReceiver)
+	if !ok {
+		return fmt.Errorf("%s isn't a receiver of log entries", s.Target)
+	}
+
+	for _, e := range s.Entries {
+		entry := loki.Entry{
+			Labels: labelSet(e.Labels),
+			Entry: logproto.Entry{
+				Timestamp:          timestampOr(e.Timestamp, start),
+				Line:               e.Line,
+				StructuredMetadata: labelsAdapter(e.StructuredMetadata),
+			},
+		}
+		select {
+		case receiver.Chan() <- entry:
+		case <-ctx.Done():
+			return fmt.Errorf("sending log entries to %s: %w", s.Target, ctx.Err())
+		}
+	}
+	return nil
+}
+
+func sendMetrics(ctx context.Context, ctrl *runtime.Runtime, s SendMetrics, start time.Time) error {
+	export, err := lookupExport(ctrl, s.Target)
+	if err != nil {
+		return err
+	}
+	receiver, ok := export.(storage.Appendable)
+	if !ok {
+		return fmt.Errorf("%s isn't a receiver of samples", s.Target)
+	}
+
+	app := receiver.Appender(ctx)
+	for _, sample := range s.Samples {
+		ts := timestampOr(sample.Timestamp, start).UnixMilli()
+		if _, err := app.Append(0, labels.FromMap(sample.Labels), ts, sample.Value); err != nil {
+			return errors.Join(fmt.Errorf("sending samples to %s: %w", s.Target, err), app.Rollback())
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return fmt.Errorf("sending samples to %s: %w", s.Target, err)
+	}
+	return nil
+}
+
+// waitForData waits until the mocks captured at least as much data as
+// expected by test, then waits for settleTime to catch unexpected data.
+func waitForData(ctx context.Context, test Test, logs map[string]*logsMock, metrics map[string]*metricsMock, otlp map[string]*otlpMock) {
+	captured := func() bool {
+		for _, e := range test.ExpectLogs {
+			if len(logs[e.ID].Entries()) < len(e.Entries) {
+				return false
+			}
+		}
+		for _, e := range test.ExpectMetrics {
+			if len(metrics[e.ID].Samples()) < len(e.Samples) {
+				return false
+			}
+		}
+		for _, e := range test.ExpectOTLP {
+			// The data is validated when loading the test file.
+			want, _ := e.Data.decode()
+			if !want.captured(otlp[e.ID].Signals()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !captured() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(settleTime):
+	}
+}
+
+func timestampOr(ts time.Time, fallback time.Time) time.Time {
+	if ts.IsZero() {
+		return fallback
+	}
+	return ts
+}
+
+// labelsAdapter returns the labels of m, sorted by name.
+func labelsAdapter(m map[string]string) push.LabelsAdapter {
+	if len(m) == 0 {
+		return nil
+	}
+	res := make(push.LabelsAdapter, 0, len(m))
+	for k, v := range m {
+		res = append(res, push.LabelAdapter{Name: k, Value: v})
+	}
+	slices.SortFunc(res, func(a, b push.LabelAdapter) int { return strings.Compare(a.Name, b.Name) })
+	return res
+}
+
+// metadataLabelSet returns the structured metadata of a log entry as a label
+// set.
+func metadataLabelSet(metadata push.LabelsAdapter) model.LabelSet {
+	set := make(model.LabelSet, len(metadata))
+	for _, l := range metadata {
+		set[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return set
+}
+
+func labelSet(m map[string]string) model.LabelSet {
+	set := make(model.LabelSet, len(m))
+	for k, v := range m {
+		set[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return set
+}
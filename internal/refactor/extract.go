@@ -0,0 +1,268 @@
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/scanner"
+)
+
+// ExtractOptions configures Extract.
+type ExtractOptions struct {
+	// Components are the IDs of the components to extract.
+	Components []string
+	// Name is the name of the custom component declared with the extracted
+	// components.
+	Name string
+	// Label is the label of the instance of the custom component replacing
+	// the extracted components.
+	Label string
+
+	// ModulePath, when set, is the path of the module file declaring the
+	// custom component. The module is imported with an import.file block
+	// whose label is ModuleNamespace, and whose filename is relative to the
+	// module_path of the configuration. When ModulePath is empty, the custom
+	// component is declared in the file the components are extracted from.
+	ModulePath      string
+	ModuleNamespace string
+}
+
+// ExtractResult holds the files written by Extract.
+type ExtractResult struct {
+	// Files holds the new content of the files of the configuration which
+	// changed, by name.
+	Files map[string][]byte
+	// Module is the content of the module file declaring the custom
+	// component. Module is nil if opts.ModulePath isn't set.
+	Module []byte
+}
+
+// Extract moves the components listed in opts into a declare block, and
+// replaces them with an instance of the declared custom component. The
+// components must be declared in the same file of the configuration. files
+// holds the source of the files of the configuration by name.
+//
+// References made by the extracted components to other components become
+// arguments of the custom component, and references made by other components
+// to the extracted components become exports of the custom component.
+func Extract(files map[string][]byte, opts ExtractOptions) (*ExtractResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	comps, declaredIn, err := components(parsed)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		extracted = make(map[string]*ast.BlockStmt, len(opts.Components))
+		others    = make(map[string]*ast.BlockStmt, len(comps))
+		filename  string
+	)
+	for _, id := range opts.Components {
+		b, err := lookupComponent(comps, id)
+		if err != nil {
+			return nil, err
+		}
+		if filename == "" {
+			filename = declaredIn[b]
+		} else if declaredIn[b] != filename {
+			return nil, fmt.Errorf("components %s and %s are declared in different files", opts.Components[0], id)
+		}
+		extracted[id] = b
+	}
+	for id, b := range comps {
+		if _, ok := extracted[id]; !ok {
+			others[id] = b
+		}
+	}
+	for _, f := range parsed {
+		for _, stmt := range f.Body {
+			b, ok := stmt.(*ast.BlockStmt)
+			if !ok {
+				continue
+			}
+			if b.GetBlockName() == opts.Name || (b.GetBlockName() == declareBlockName && b.Label == opts.Name) {
+				return nil, fmt.Errorf("a component named %s is already used", opts.Name)
+			}
+		}
+	}
+	src := files[filename]
+
+	instance := opts.Name
+	if opts.ModulePath != "" {
+		instance = opts.ModuleNamespace + "." + opts.Name
+	}
+
+	var (
+		edits     = make(map[string][]edit)
+		arguments = newNames()
+		exports   = newNames()
+	)
+
+	// Extracted components referring to other components get the exports of
+	// those components from arguments.
+	blocks := blocksByOffset(extracted)
+	for _, b := range blocks {
+		for _, ref := range findReferences(b, others) {
+			name := arguments.add(ref.text())
+			start, end := ref.span()
+			edits[filename] = append(edits[filename], edit{start: start, end: end, text: "argument." + name + ".value"})
+		}
+	}
+
+	// Other components referring to extracted components get their exports
+	// from the instance of the custom component.
+	for _, name := range sortedNames(parsed) {
+		for _, stmt := range parsed[name].Body {
+			if b, ok := stmt.(*ast.BlockStmt); ok && extracted[componentID(b)] == b {
+				continue
+			}
+			for _, ref := range findReferences(stmt, extracted) {
+				export := exports.add(ref.text())
+				start, end := ref.span()
+				edits[name] = append(edits[name], edit{start: start, end: end, text: instance + "." + opts.Label + "." + export})
+			}
+		}
+	}
+
+	// Build the declare block from the text of the extracted components.
+	var declare bytes.Buffer
+	fmt.Fprintf(&declare, "declare %q {\n", opts.Name)
+	for _, name := range arguments.order {
+		fmt.Fprintf(&declare, "\targument %q {\n\t\toptional = false\n\t}\n\n", name)
+	}
+	for _, b := range blocks {
+		start, end := blockSpan(src, b)
+		declare.Write(applyEdits(src, start, end, edits[filename]))
+		declare.WriteString("\n")
+	}
+	for _, name := range exports.order {
+		fmt.Fprintf(&declare, "\texport %q {\n\t\tvalue = %s\n\t}\n\n", name, exports.values[name])
+	}
+	declare.WriteString("}\n")
+
+	// Build the blocks replacing the extracted components.
+	var replacement bytes.Buffer
+	if opts.ModulePath != "" {
+		// module_path is the directory of the configuration, which is also
+		// the directory of each of its files.
+		rel, err := filepath.Rel(filepath.Dir(filename), opts.ModulePath)
+		if err != nil {
+			return nil, fmt.Errorf("locating %s from %s: %w", opts.ModulePath, filename, err)
+		}
+		fmt.Fprintf(&replacement, "import.file %q {\n\tfilename = file.path_join(module_path, %s)\n}\n\n", opts.ModuleNamespace, strconv.Quote(filepath.ToSlash(rel)))
+	} else {
+		replacement.Write(declare.Bytes())
+		replacement.WriteString("\n")
+	}
+	fmt.Fprintf(&replacement, "%s %q {\n", instance, opts.Label)
+	for _, name := range arguments.order {
+		fmt.Fprintf(&replacement, "\t%s = %s\n", name, arguments.values[name])
+	}
+	replacement.WriteString("}\n")
+
+	// Replace the first extracted component with the new blocks, and remove
+	// the other ones.
+	var out bytes.Buffer
+	pos := 0
+	for i, b := range blocks {
+		start, end := blockSpan(src, b)
+		out.Write(applyEdits(src, pos, start, edits[filename]))
+		if i == 0 {
+			out.Write(replacement.Bytes())
+		}
+		pos = end
+	}
+	out.Write(applyEdits(src, pos, len(src), edits[filename]))
+
+	res := ExtractResult{Files: make(map[string][]byte, len(edits)+1)}
+	if res.Files[filename], err = format(filename, out.Bytes()); err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", filename, err)
+	}
+	for name, fileEdits := range edits {
+		if name == filename {
+			continue
+		}
+		if res.Files[name], err = format(name, applyEdits(files[name], 0, len(files[name]), fileEdits)); err != nil {
+			return nil, fmt.Errorf("formatting %s: %w", name, err)
+		}
+	}
+	if opts.ModulePath != "" {
+		if res.Module, err = format(opts.ModulePath, declare.Bytes()); err != nil {
+			return nil, fmt.Errorf("formatting %s: %w", opts.ModulePath, err)
+		}
+	}
+	return &res, nil
+}
+
+func (opts ExtractOptions) validate() error {
+	if len(opts.Components) == 0 {
+		return fmt.Errorf("no components to extract")
+	}
+	if !scanner.IsValidIdentifier(opts.Name) {
+		return fmt.Errorf("%q isn't a valid custom component name", opts.Name)
+	}
+	if !scanner.IsValidIdentifier(opts.Label) {
+		return fmt.Errorf("%q isn't a valid label", opts.Label)
+	}
+	if opts.ModulePath != "" && !scanner.IsValidIdentifier(opts.ModuleNamespace) {
+		return fmt.Errorf("%q isn't a valid module namespace", opts.ModuleNamespace)
+	}
+	return nil
+}
+
+// blocksByOffset returns the blocks of m in the order they're declared.
+func blocksByOffset(m map[string]*ast.BlockStmt) []*ast.BlockStmt {
+	blocks := make([]*ast.BlockStmt, 0, len(m))
+	for _, b := range m {
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].NamePos.Offset() < blocks[j].NamePos.Offset()
+	})
+	return blocks
+}
+
+// names generates the names of the arguments and exports of a custom
+// component from the references they replace.
+type names struct {
+	order  []string          // Names in the order they were added.
+	values map[string]string // Name -> reference.
+	byRef  map[string]string // Reference -> name.
+}
+
+func newNames() *names {
+	return &names{values: make(map[string]string), byRef: make(map[string]string)}
+}
+
+// add returns the name used for ref, such as prometheus_scrape_default_targets
+// for prometheus.scrape.default.targets.
+func (n *names) add(ref string) string {
+	if name, ok := n.byRef[ref]; ok {
+		return name
+	}
+
+	name := strings.ReplaceAll(ref, ".", "_")
+	for i := 2; ; i++ {
+		if _, used := n.values[name]; !used {
+			break
+		}
+		name = fmt.Sprintf("%s_%d", strings.ReplaceAll(ref, ".", "_"), i)
+	}
+
+	n.order = append(n.order, name)
+	n.values[name] = ref
+	n.byRef[ref] = name
+	return name
+}
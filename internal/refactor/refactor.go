@@ -0,0 +1,282 @@
+// Package refactor implements structural changes of Alloy configurations,
+// such as renaming components. A configuration is a single file or the files
+// of a directory. Changes are applied to the source text of the files so that
+// comments stay in place, and the files which changed are formatted.
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// declareBlockName is the name of the blocks declaring custom components.
+const declareBlockName = "declare"
+
+// edit replaces the bytes of a source between start and end.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits applies edits to src[start:end] and returns the result. Edits
+// outside of the range are ignored.
+func applyEdits(src []byte, start, end int, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var buf bytes.Buffer
+	pos := start
+	for _, e := range edits {
+		if e.start < start || e.end > end {
+			continue
+		}
+		buf.Write(src[pos:e.start])
+		buf.WriteString(e.text)
+		pos = e.end
+	}
+	buf.Write(src[pos:end])
+	return buf.Bytes()
+}
+
+// format parses and prints src with the standard formatting rules.
+func format(filename string, src []byte) ([]byte, error) {
+	f, err := parser.ParseFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// componentID returns the ID of the component declared by b, such as
+// prometheus.scrape.default.
+func componentID(b *ast.BlockStmt) string {
+	if b.Label == "" {
+		return b.GetBlockName()
+	}
+	return b.GetBlockName() + "." + b.Label
+}
+
+// parseFiles parses the files of a configuration, by name.
+func parseFiles(files map[string][]byte) (map[string]*ast.File, error) {
+	res := make(map[string]*ast.File, len(files))
+	for _, name := range sortedNames(files) {
+		f, err := parser.ParseFile(name, files[name])
+		if err != nil {
+			return nil, err
+		}
+		res[name] = f
+	}
+	return res, nil
+}
+
+// sortedNames returns the names of the files of a configuration in order.
+func sortedNames[V any](files map[string]V) []string {
+	return slices.Sorted(maps.Keys(files))
+}
+
+// components returns the blocks declaring components at the top level of the
+// files of a configuration, by ID, and the name of the file declaring each of
+// them.
+func components(files map[string]*ast.File) (map[string]*ast.BlockStmt, map[*ast.BlockStmt]string, error) {
+	var (
+		res        = make(map[string]*ast.BlockStmt)
+		declaredIn = make(map[*ast.BlockStmt]string)
+	)
+	for _, name := range sortedNames(files) {
+		for _, stmt := range files[name].Body {
+			b, ok := stmt.(*ast.BlockStmt)
+			if !ok || b.Label == "" || b.GetBlockName() == declareBlockName || b.GetBlockName() == function.BlockName {
+				continue
+			}
+			id := componentID(b)
+			if _, exists := res[id]; exists {
+				return nil, nil, fmt.Errorf("component %s is declared more than once", id)
+			}
+			res[id] = b
+			declaredIn[b] = name
+		}
+	}
+	return res, declaredIn, nil
+}
+
+// reference is a reference to the exports of a component.
+type reference struct {
+	traversal []*ast.Ident
+	component *ast.BlockStmt
+	// n is the number of identifiers of traversal naming the component.
+	n int
+}
+
+// span returns the offsets of the identifiers naming the component and its
+// first export, such as prometheus.scrape.default.targets in
+// prometheus.scrape.default.targets.foo.
+func (r reference) span() (int, int) {
+	last := r.traversal[min(r.n, len(r.traversal)-1)]
+	return r.traversal[0].NamePos.Offset(), last.NamePos.Offset() + len(last.Name)
+}
+
+// text returns the identifiers naming the component and its first export.
+func (r reference) text() string {
+	idents := r.traversal[:min(r.n+1, len(r.traversal))]
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ".")
+}
+
+// findReferences returns the references made by the expressions of node to
+// the components of comps. The bodies of declare blocks aren't searched since
+// they can't refer to components declared outside of them. Identifiers
+// shadowed by the variables of comprehensions or the parameters of functions
+// aren't references.
+func findReferences(node ast.Node, comps map[string]*ast.BlockStmt) []reference {
+	var refs []reference
+	ast.Walk(&referenceWalker{components: comps, refs: &refs}, node)
+	return refs
+}
+
+type referenceWalker struct {
+	components map[string]*ast.BlockStmt
+	shadowed   map[string]struct{} // Names of the variables in scope.
+	refs       *[]reference
+}
+
+// shadow returns a walker for a scope where names hide components.
+func (w *referenceWalker) shadow(names ...string) *referenceWalker {
+	shadowed := make(map[string]struct{}, len(w.shadowed)+len(names))
+	maps.Copy(shadowed, w.shadowed)
+	for _, name := range names {
+		shadowed[name] = struct{}{}
+	}
+	return &referenceWalker{components: w.components, shadowed: shadowed, refs: w.refs}
+}
+
+// Visit implements ast.Visitor.
+func (w *referenceWalker) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.BlockStmt:
+		switch n.GetBlockName() {
+		case declareBlockName:
+			return nil
+		case function.BlockName:
+			return w.shadow(functionParams(n)...)
+		}
+	case *ast.ComprehensionExpr:
+		// The collection is evaluated outside of the scope of the variables.
+		ast.Walk(w, n.Collection)
+
+		vars := []string{n.ValueVar.Name}
+		if n.KeyVar != nil {
+			vars = append(vars, n.KeyVar.Name)
+		}
+		inner := w.shadow(vars...)
+		for _, expr := range []ast.Expr{n.Key, n.Value, n.Cond} {
+			if expr != nil {
+				ast.Walk(inner, expr)
+			}
+		}
+		return nil
+	case *ast.IdentifierExpr, *ast.AccessExpr:
+		traversal, ok := ast.Traversal(n.(ast.Expr))
+		if !ok {
+			// The value of the access isn't a traversal, such as a call.
+			return w
+		}
+		if _, ok := w.shadowed[traversal[0].Name]; ok {
+			return nil
+		}
+		for i := 1; i <= len(traversal); i++ {
+			if b, ok := w.components[identsID(traversal[:i])]; ok {
+				*w.refs = append(*w.refs, reference{traversal: traversal, component: b, n: i})
+				break
+			}
+		}
+		return nil
+	}
+	return w
+}
+
+// functionParams returns the names of the parameters of a function block.
+// Parameters which aren't string literals are ignored.
+func functionParams(b *ast.BlockStmt) []string {
+	var params []string
+	for _, stmt := range b.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok || attr.Name.Name != function.ParamsAttr {
+			continue
+		}
+		arr, ok := attr.Value.(*ast.ArrayExpr)
+		if !ok {
+			continue
+		}
+		for _, elem := range arr.Elements {
+			lit, ok := elem.(*ast.LiteralExpr)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			if name, err := strconv.Unquote(lit.Value); err == nil {
+				params = append(params, name)
+			}
+		}
+	}
+	return params
+}
+
+func identsID(idents []*ast.Ident) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ".")
+}
+
+// blockSpan returns the offsets of the text of b in src, including the
+// comments on the lines right above it and the end of its last line.
+func blockSpan(src []byte, b *ast.BlockStmt) (int, int) {
+	start := lineStart(src, ast.StartPos(b).Offset())
+	for start > 0 {
+		prev := lineStart(src, start-1)
+		line := strings.TrimSpace(string(src[prev:start]))
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		start = prev
+	}
+
+	end := ast.EndPos(b).Offset() + 1
+	if idx := bytes.IndexByte(src[end:], '\n'); idx != -1 {
+		end += idx + 1
+	} else {
+		end = len(src)
+	}
+	return start, end
+}
+
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+func lookupComponent(comps map[string]*ast.BlockStmt, id string) (*ast.BlockStmt, error) {
+	b, ok := comps[id]
+	if !ok {
+		return nil, fmt.Errorf("component %s not found", id)
+	}
+	return b, nil
+}
@@ -0,0 +1,313 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `// Discovers the pods.
+discovery.kubernetes "pods" {
+	role = "pod"
+}
+
+// Keeps the pods of the app.
+discovery.relabel "app" {
+	targets = discovery.kubernetes.pods.targets // All pods.
+
+	rule {
+		source_labels = ["app"]
+		regex         = "app"
+		action        = "keep"
+	}
+}
+
+prometheus.scrape "default" {
+	targets    = discovery.relabel.app.output
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://mimir:9009/api/v1/push"
+	}
+}
+`
+
+func TestRename(t *testing.T) {
+	out, err := Rename(testFiles(testConfig), "discovery.kubernetes.pods", "all_pods")
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	require.Equal(t, `// Discovers the pods.
+discovery.kubernetes "all_pods" {
+	role = "pod"
+}
+
+// Keeps the pods of the app.
+discovery.relabel "app" {
+	targets = discovery.kubernetes.all_pods.targets // All pods.
+
+	rule {
+		source_labels = ["app"]
+		regex         = "app"
+		action        = "keep"
+	}
+}
+
+prometheus.scrape "default" {
+	targets    = discovery.relabel.app.output
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://mimir:9009/api/v1/push"
+	}
+}
+`, string(out["config.alloy"]))
+}
+
+func TestRename_Directory(t *testing.T) {
+	files := map[string][]byte{
+		"config/discovery.alloy": []byte(`discovery.kubernetes "pods" {
+	role = "pod"
+}
+`),
+		"config/scrape.alloy": []byte(`prometheus.scrape "default" {
+	targets    = discovery.kubernetes.pods.targets
+	forward_to = []
+}
+`),
+		"config/write.alloy": []byte(`prometheus.remote_write "default" {}
+`),
+	}
+
+	out, err := Rename(files, "discovery.kubernetes.pods", "all_pods")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{
+		"config/discovery.alloy": []byte(`discovery.kubernetes "all_pods" {
+	role = "pod"
+}
+`),
+		"config/scrape.alloy": []byte(`prometheus.scrape "default" {
+	targets    = discovery.kubernetes.all_pods.targets
+	forward_to = []
+}
+`),
+	}, out)
+}
+
+func TestRename_Shadowed(t *testing.T) {
+	out, err := Rename(testFiles(`local.file "config" {
+	filename = "config.yaml"
+}
+
+function "content" {
+	params = ["local"]
+	result = local.file.config.content
+}
+
+local.file "other" {
+	filename = [for local in [local.file.config.content] : local.file.config.content][0]
+}
+`), "local.file.config", "main")
+	require.NoError(t, err)
+	require.Equal(t, `local.file "main" {
+	filename = "config.yaml"
+}
+
+function "content" {
+	params = ["local"]
+	result = local.file.config.content
+}
+
+local.file "other" {
+	filename = [for local in [local.file.main.content] : local.file.config.content][0]
+}
+`, string(out["config.alloy"]))
+}
+
+func TestRename_Errors(t *testing.T) {
+	_, err := Rename(testFiles(testConfig), "discovery.kubernetes.nodes", "all")
+	require.EqualError(t, err, "component discovery.kubernetes.nodes not found")
+
+	_, err = Rename(testFiles(testConfig), "prometheus.scrape.default", "not-valid")
+	require.EqualError(t, err, `"not-valid" isn't a valid label`)
+
+	_, err = Rename(testFiles(testConfig+`prometheus.scrape "other" {}`), "prometheus.scrape.default", "other")
+	require.EqualError(t, err, "component prometheus.scrape.other already exists")
+
+	_, err = Rename(map[string][]byte{
+		"config/a.alloy": []byte(`prometheus.scrape "default" {}`),
+		"config/b.alloy": []byte(`prometheus.scrape "default" {}`),
+	}, "prometheus.scrape.default", "other")
+	require.EqualError(t, err, "component prometheus.scrape.default is declared more than once")
+}
+
+func TestExtract(t *testing.T) {
+	res, err := Extract(testFiles(testConfig), ExtractOptions{
+		Components: []string{"discovery.relabel.app", "prometheus.scrape.default"},
+		Name:       "scrape_app",
+		Label:      "default",
+	})
+	require.NoError(t, err)
+	require.Nil(t, res.Module)
+	require.Equal(t, `// Discovers the pods.
+discovery.kubernetes "pods" {
+	role = "pod"
+}
+
+declare "scrape_app" {
+	argument "discovery_kubernetes_pods_targets" {
+		optional = false
+	}
+
+	argument "prometheus_remote_write_default_receiver" {
+		optional = false
+	}
+
+	// Keeps the pods of the app.
+	discovery.relabel "app" {
+		targets = argument.discovery_kubernetes_pods_targets.value // All pods.
+
+		rule {
+			source_labels = ["app"]
+			regex         = "app"
+			action        = "keep"
+		}
+	}
+
+	prometheus.scrape "default" {
+		targets    = discovery.relabel.app.output
+		forward_to = [argument.prometheus_remote_write_default_receiver.value]
+	}
+}
+
+scrape_app "default" {
+	discovery_kubernetes_pods_targets        = discovery.kubernetes.pods.targets
+	prometheus_remote_write_default_receiver = prometheus.remote_write.default.receiver
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://mimir:9009/api/v1/push"
+	}
+}
+`, string(res.Files["config.alloy"]))
+}
+
+func TestExtract_Module(t *testing.T) {
+	res, err := Extract(testFiles(testConfig), ExtractOptions{
+		Components:      []string{"discovery.relabel.app"},
+		Name:            "app_targets",
+		Label:           "default",
+		ModulePath:      "app.alloy",
+		ModuleNamespace: "app",
+	})
+	require.NoError(t, err)
+	require.Equal(t, `// Discovers the pods.
+discovery.kubernetes "pods" {
+	role = "pod"
+}
+
+import.file "app" {
+	filename = file.path_join(module_path, "app.alloy")
+}
+
+app.app_targets "default" {
+	discovery_kubernetes_pods_targets = discovery.kubernetes.pods.targets
+}
+
+prometheus.scrape "default" {
+	targets    = app.app_targets.default.discovery_relabel_app_output
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://mimir:9009/api/v1/push"
+	}
+}
+`, string(res.Files["config.alloy"]))
+	require.Equal(t, `declare "app_targets" {
+	argument "discovery_kubernetes_pods_targets" {
+		optional = false
+	}
+
+	// Keeps the pods of the app.
+	discovery.relabel "app" {
+		targets = argument.discovery_kubernetes_pods_targets.value // All pods.
+
+		rule {
+			source_labels = ["app"]
+			regex         = "app"
+			action        = "keep"
+		}
+	}
+
+	export "discovery_relabel_app_output" {
+		value = discovery.relabel.app.output
+	}
+}
+`, string(res.Module))
+}
+
+func TestExtract_Errors(t *testing.T) {
+	_, err := Extract(testFiles(testConfig), ExtractOptions{Name: "x", Label: "default"})
+	require.EqualError(t, err, "no components to extract")
+
+	_, err = Extract(testFiles(testConfig+`declare "scrape" {}`), ExtractOptions{
+		Components: []string{"prometheus.scrape.default"},
+		Name:       "scrape",
+		Label:      "default",
+	})
+	require.EqualError(t, err, "a component named scrape is already used")
+
+	_, err = Extract(map[string][]byte{
+		"config/a.alloy": []byte(`prometheus.scrape "a" {}`),
+		"config/b.alloy": []byte(`prometheus.scrape "b" {}`),
+	}, ExtractOptions{
+		Components: []string{"prometheus.scrape.a", "prometheus.scrape.b"},
+		Name:       "scrape",
+		Label:      "default",
+	})
+	require.EqualError(t, err, "components prometheus.scrape.a and prometheus.scrape.b are declared in different files")
+}
+
+func TestExtract_Directory(t *testing.T) {
+	res, err := Extract(map[string][]byte{
+		"config/discovery.alloy": []byte(`discovery.kubernetes "pods" {
+	role = "pod"
+}
+`),
+		"config/scrape.alloy": []byte(`prometheus.scrape "default" {
+	targets    = discovery.kubernetes.pods.targets
+	forward_to = []
+}
+`),
+	}, ExtractOptions{
+		Components:      []string{"discovery.kubernetes.pods"},
+		Name:            "pods",
+		Label:           "default",
+		ModulePath:      "config/modules/pods.alloy",
+		ModuleNamespace: "pods",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{
+		"config/discovery.alloy": []byte(`import.file "pods" {
+	filename = file.path_join(module_path, "modules/pods.alloy")
+}
+
+pods.pods "default" { }
+`),
+		"config/scrape.alloy": []byte(`prometheus.scrape "default" {
+	targets    = pods.pods.default.discovery_kubernetes_pods_targets
+	forward_to = []
+}
+`),
+	}, res.Files)
+}
+
+func testFiles(src string) map[string][]byte {
+	return map[string][]byte{"config.alloy": []byte(src)}
+}
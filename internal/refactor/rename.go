@@ -0,0 +1,67 @@
+package refactor
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/scanner"
+)
+
+// Rename changes the label of the component id, declared at the top level of
+// one of the files of a configuration, to label. References to the component
+// are rewritten in every file. files holds the source of the files by name,
+// and the new source of the files which changed is returned.
+func Rename(files map[string][]byte, id string, label string) (map[string][]byte, error) {
+	if !scanner.IsValidIdentifier(label) {
+		return nil, fmt.Errorf("%q isn't a valid label", label)
+	}
+
+	parsed, err := parseFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	comps, declaredIn, err := components(parsed)
+	if err != nil {
+		return nil, err
+	}
+	b, err := lookupComponent(comps, id)
+	if err != nil {
+		return nil, err
+	}
+	newID := b.GetBlockName() + "." + label
+	if _, exists := comps[newID]; exists {
+		return nil, fmt.Errorf("component %s already exists", newID)
+	}
+
+	filename := declaredIn[b]
+	src := files[filename]
+	labelStart := b.LabelPos.Offset()
+	quoted, err := strconv.QuotedPrefix(string(src[labelStart:]))
+	if err != nil {
+		return nil, fmt.Errorf("reading label of %s: %w", id, err)
+	}
+	edits := map[string][]edit{
+		filename: {{start: labelStart, end: labelStart + len(quoted), text: strconv.Quote(label)}},
+	}
+
+	for name, f := range parsed {
+		for _, ref := range findReferences(f, map[string]*ast.BlockStmt{id: b}) {
+			ident := ref.traversal[ref.n-1]
+			edits[name] = append(edits[name], edit{
+				start: ident.NamePos.Offset(),
+				end:   ident.NamePos.Offset() + len(ident.Name),
+				text:  label,
+			})
+		}
+	}
+
+	out := make(map[string][]byte, len(edits))
+	for name, fileEdits := range edits {
+		if out[name], err = format(name, applyEdits(files[name], 0, len(files[name]), fileEdits)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
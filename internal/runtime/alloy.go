@@ -48,6 +48,7 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sync"
 	"time"
 
@@ -61,6 +62,7 @@ import (
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/resources"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/util"
@@ -114,6 +116,22 @@ type Options struct {
 
 	// EnableCommunityComps enables the use of community components.
 	EnableCommunityComps bool
+
+	// ModuleLockFile is the path of the lock file recording the commits that
+	// modules imported with a version constraint resolved to. Version
+	// constraints are resolved at each pull if it's empty.
+	ModuleLockFile string
+
+	// ResourceTracker tracks the resource usage of components. The resource
+	// usage of components isn't reported if it's nil.
+	ResourceTracker *resources.Tracker
+
+	// DrainTimeout is the maximum duration of the drain of components when the
+	// controller stops. Components are drained in dependency order, starting
+	// from the ones no other component depends on, so that components can
+	// flush the data sent by the components depending on them. All components
+	// are stopped at once if it's zero.
+	DrainTimeout time.Duration
 }
 
 // Runtime is the Alloy system.
@@ -201,6 +219,7 @@ func newController(o controllerOptions) *Runtime {
 			DataPath:             o.DataPath,
 			MinStability:         o.MinStability,
 			EnableCommunityComps: o.EnableCommunityComps,
+			ModuleLockFile:       o.ModuleLockFile,
 			OnBlockNodeUpdate: func(cn controller.BlockNode) {
 				// Changed node should be queued for reevaluation.
 				f.updateQueue.Enqueue(&controller.QueuedNode{Node: cn, LastUpdatedTime: time.Now()})
@@ -225,6 +244,9 @@ func newController(o controllerOptions) *Runtime {
 					DataPath:             o.DataPath,
 					MinStability:         o.MinStability,
 					EnableCommunityComps: o.EnableCommunityComps,
+					ModuleLockFile:       o.ModuleLockFile,
+					ResourceTracker:      o.ResourceTracker,
+					DrainTimeout:         o.DrainTimeout,
 					ID:                   opts.Id,
 					ServiceMap:           serviceMap,
 					WorkerPool:           workerPool,
@@ -258,6 +280,7 @@ func (f *Runtime) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			f.drain(ctx)
 			return
 
 		case <-f.updateQueue.Chan():
@@ -305,6 +328,39 @@ func (f *Runtime) Run(ctx context.Context) {
 	}
 }
 
+// drain prepares the services for the shutdown and stops the running
+// components in dependency order, until the drain deadline of the parent
+// controller or the DrainTimeout.
+func (f *Runtime) drain(ctx context.Context) {
+	deadline, ok := controller.DrainDeadline(ctx)
+	if !ok {
+		if f.opts.DrainTimeout <= 0 {
+			return
+		}
+		deadline = time.Now().Add(f.opts.DrainTimeout)
+	}
+
+	drainCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	if !f.opts.IsModule {
+		level.Info(f.log).Log("msg", "shutting down, draining components", "deadline", deadline)
+		for _, svc := range f.opts.Services {
+			drainer, ok := svc.(service.Drainer)
+			if !ok {
+				continue
+			}
+			if err := drainer.Drain(drainCtx); err != nil {
+				level.Error(f.log).Log("msg", "failed to drain service", "service", svc.Definition().Name, "err", err)
+			}
+		}
+	}
+
+	start := time.Now()
+	f.loader.Drain(drainCtx, f.sched)
+	level.Info(f.log).Log("msg", "finished draining components", "duration", time.Since(start))
+}
+
 // LoadSource synchronizes the state of the controller with the current config
 // source. Components in the graph will be marked as unhealthy if there was an
 // error encountered during Load.
@@ -313,18 +369,31 @@ func (f *Runtime) Run(ctx context.Context) {
 // without any configuration errors.
 // LoadSource uses default loader configuration.
 func (f *Runtime) LoadSource(source *Source, args map[string]any, configPath string) error {
+	return f.LoadSourceWithVariables(source, args, configPath, nil)
+}
+
+// LoadSourceWithVariables is like LoadSource, but also makes variables
+// available to the expressions of the loaded configuration. Nested maps of
+// variables can stand in for components which aren't declared in source: a
+// reference to loki.write.default.receiver is resolved from the "receiver"
+// field of variables["loki"]["write"]["default"].
+func (f *Runtime) LoadSourceWithVariables(source *Source, args map[string]any, configPath string, variables map[string]any) error {
 	modulePath, err := util.ExtractDirPath(configPath)
 	if err != nil {
 		level.Warn(f.log).Log("msg", "failed to extract directory path from configPath", "configPath", configPath, "err", err)
 	}
+
+	scope := make(map[string]any, len(variables)+1)
+	maps.Copy(scope, variables)
+	scope[importsource.ModulePath] = modulePath
+
 	return f.applyLoaderConfig(controller.ApplyOptions{
 		Args:            args,
 		ComponentBlocks: source.Components(),
 		ConfigBlocks:    source.Configs(),
 		DeclareBlocks:   source.Declares(),
-		ArgScope: vm.NewScope(map[string]interface{}{
-			importsource.ModulePath: modulePath,
-		}),
+		FunctionBlocks:  source.Functions(),
+		ArgScope:        vm.NewScope(scope),
 	})
 }
 
@@ -335,6 +404,7 @@ func (f *Runtime) loadSource(source *Source, args map[string]any, customComponen
 		ComponentBlocks:         source.Components(),
 		ConfigBlocks:            source.Configs(),
 		DeclareBlocks:           source.Declares(),
+		FunctionBlocks:          source.Functions(),
 		CustomComponentRegistry: customComponentRegistry,
 		ArgScope:                customComponentRegistry.Scope(),
 	})
@@ -129,6 +129,9 @@ func (f *Runtime) getComponentDetail(cn controller.ComponentNode, graph *dag.Gra
 		if opts.GetDebugInfo {
 			componentInfo.DebugInfo = builtinComponent.DebugInfo()
 		}
+		if opts.GetResources && f.opts.ResourceTracker != nil {
+			componentInfo.Resources = f.opts.ResourceTracker.Usage(componentInfo.ID.String(), builtinComponent.ComponentName())
+		}
 	}
 
 	_, liveDebuggingEnabled := componentInfo.Component.(component.LiveDebugging)
@@ -0,0 +1,322 @@
+package runtime_test
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunction(t *testing.T) {
+	tt := []testCase{
+		{
+			name: "BasicFunction",
+			config: `
+			function "scale" {
+				params = ["value", "factor"]
+				result = value * factor
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.passthrough "pt" {
+				input = scale(testcomponents.count.inc.count, 2)
+				lag = "1ms"
+			}
+
+			testcomponents.summation "sum" {
+				input = testcomponents.passthrough.pt.output
+			}
+			`,
+			expected: 20,
+		},
+		{
+			name: "FunctionCallingFunction",
+			config: `
+			function "double" {
+				params = ["value"]
+				result = value * 2
+			}
+
+			function "quadruple" {
+				params = ["value"]
+				result = double(double(value))
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			testcomponents.passthrough "pt" {
+				input = quadruple(testcomponents.count.inc.count)
+				lag = "1ms"
+			}
+
+			testcomponents.summation "sum" {
+				input = testcomponents.passthrough.pt.output
+			}
+			`,
+			expected: 40,
+		},
+		{
+			name: "FunctionInParentScope",
+			config: `
+			function "negate" {
+				params = ["value"]
+				result = -value
+			}
+
+			declare "test" {
+				argument "input" {
+					optional = false
+				}
+
+				function "double" {
+					params = ["value"]
+					result = negate(negate(value)) * 2
+				}
+
+				export "output" {
+					value = double(argument.input.value)
+				}
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 20,
+		},
+		{
+			name: "ShadowFunction",
+			config: `
+			function "transform" {
+				params = ["value"]
+				result = -value
+			}
+
+			declare "test" {
+				argument "input" {
+					optional = false
+				}
+
+				function "transform" {
+					params = ["value"]
+					result = value
+				}
+
+				export "output" {
+					value = transform(argument.input.value)
+				}
+			}
+
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 10,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := runtime.New(testOptions(t))
+			f, err := runtime.ParseSource(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+			require.NotNil(t, f)
+
+			err = ctrl.LoadSource(f, nil, "")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			require.Eventually(t, func() bool {
+				export := getExport[testcomponents.SummationExports](t, ctrl, "", "testcomponents.summation.sum")
+				return export.LastAdded == tc.expected
+			}, 3*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestFunctionError(t *testing.T) {
+	tt := []struct {
+		name          string
+		config        string
+		expectedError *regexp.Regexp
+	}{
+		{
+			name: "RecursiveFunction",
+			config: `
+			function "a" {
+				params = ["value"]
+				result = b(value)
+			}
+
+			function "b" {
+				params = ["value"]
+				result = a(value)
+			}
+			`,
+			expectedError: regexp.MustCompile(`functions can't call themselves recursively: a -> b -> a`),
+		},
+		{
+			name: "MissingResult",
+			config: `
+			function "a" {
+				params = ["value"]
+			}
+			`,
+			expectedError: regexp.MustCompile(`missing required attribute "result"`),
+		},
+		{
+			name: "DuplicateParameter",
+			config: `
+			function "a" {
+				params = ["value", "value"]
+				result = value
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "a" has a duplicate parameter "value"`),
+		},
+		{
+			name: "DuplicateFunction",
+			config: `
+			function "a" {
+				result = 1
+			}
+
+			function "a" {
+				result = 2
+			}
+			`,
+			expectedError: regexp.MustCompile(`block function.a already declared`),
+		},
+		{
+			name: "ComponentReferenceInFunction",
+			config: `
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			function "a" {
+				result = testcomponents.count.inc.count
+			}
+
+			testcomponents.passthrough "pt" {
+				input = a()
+				lag = "1ms"
+			}
+			`,
+			expectedError: regexp.MustCompile(`identifier "testcomponents" does not exist`),
+		},
+		{
+			name: "ComponentNamespaceConflict",
+			config: `
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+
+			function "testcomponents" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "testcomponents" conflicts with the component namespace "testcomponents"`),
+		},
+		{
+			name: "ImportNamespaceConflict",
+			config: `
+			import.string "mod" {
+				content = ""
+			}
+
+			function "mod" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "mod" conflicts with the import namespace "mod"`),
+		},
+		{
+			name: "ArgumentConflict",
+			config: `
+			function "argument" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "argument" conflicts with the module arguments`),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+			s, err := logging.New(os.Stderr, logging.DefaultOptions)
+			require.NoError(t, err)
+			ctrl := runtime.New(runtime.Options{
+				Logger:       s,
+				DataPath:     t.TempDir(),
+				MinStability: featuregate.StabilityPublicPreview,
+				Reg:          nil,
+				Services:     []service.Service{},
+			})
+			f, err := runtime.ParseSource(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+			require.NotNil(t, f)
+
+			err = ctrl.LoadSource(f, nil, "")
+			if err == nil {
+				t.Errorf("Expected error to match regex %q, but got: nil", tc.expectedError)
+			} else if !tc.expectedError.MatchString(err.Error()) {
+				t.Errorf("Expected error to match regex %q, but got: %v", tc.expectedError, err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			cancel()
+			<-done
+		})
+	}
+}
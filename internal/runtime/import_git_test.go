@@ -6,6 +6,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -381,3 +383,61 @@ testImport.add "cc" {
 		ctrl.Run(ctx)
 	}()
 }
+
+func TestVerifySHA256(t *testing.T) {
+	testRepo := t.TempDir()
+
+	config := func(hash string) string {
+		return `
+import.git "testImport" {
+	repository = "` + testRepo + `"
+	path = "math.alloy"
+	pull_frequency = "1s"
+
+	verify {
+		sha256 = "` + hash + `"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	}
+
+	initializeRepo(t, testRepo)
+	runGit(t, testRepo, "checkout", "-b", "main")
+
+	math := filepath.Join(testRepo, "math.alloy")
+	err := os.WriteFile(math, []byte(contents), 0666)
+	require.NoError(t, err)
+	runGit(t, testRepo, "add", ".")
+	runGit(t, testRepo, "commit", "-m \"test\"")
+
+	// Modules which don't match the pinned hash are refused.
+	testConfigError(t, config(fmt.Sprintf("%x", sha256.Sum256([]byte(contentsMore)))), "sha256 mismatch")
+
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, config(fmt.Sprintf("%x", sha256.Sum256([]byte(contents)))), nil, featuregate.StabilityPublicPreview)
+	err = ctrl.LoadSource(f, nil, "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,97 @@
+package runtime_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	"github.com/stretchr/testify/require"
+)
+
+const addModule = `declare "add" {
+    argument "a" {}
+    argument "b" {}
+
+    export "sum" {
+        value = argument.a.value + argument.b.value
+    }
+}`
+
+const addMoreModule = `declare "add" {
+    argument "a" {}
+    argument "b" {}
+
+    export "sum" {
+        value = argument.a.value + argument.b.value + 1
+    }
+}`
+
+func TestImportOCI(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireAuth("user", "password")
+	registry.Push("modules/math", "v1", map[string]string{
+		"math.alloy": addModule,
+		"README.md":  "not a module",
+	})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules/math:v1"
+	pull_frequency = "100ms"
+	insecure       = true
+
+	basic_auth {
+		username = "user"
+		password = "password"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, main, nil, featuregate.StabilityExperimental)
+	err := ctrl.LoadSource(f, nil, "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Moving the tag updates the module.
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": addMoreModule})
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestImportOCI_RequiresExperimental(t *testing.T) {
+	main := `
+import.oci "testImport" {
+	reference = "registry.example.com/modules/math:v1"
+}
+`
+	testConfigError(t, main, "experimental")
+}
@@ -0,0 +1,69 @@
+package runtime_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportS3(t *testing.T) {
+	// The S3 client keeps its connections open until the server is closed.
+	defer verifyNoGoroutineLeaks(t)
+
+	// Stand-in for an S3 bucket with path-style addressing.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/modules/math.alloy" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(addModule))
+	}))
+	defer srv.Close()
+
+	main := `
+import.s3 "testImport" {
+	path = "s3://modules/math.alloy"
+
+	client {
+		endpoint       = "` + srv.URL + `"
+		use_path_style = true
+		key            = "key"
+		secret         = "secret"
+		region         = "us-east-1"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+
+	ctrl, f := setup(t, main, nil, featuregate.StabilityExperimental)
+	err := ctrl.LoadSource(f, nil, "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -116,6 +116,11 @@ type traversalWalker struct {
 
 	buildTraversal   bool      // Whether
 	currentTraversal Traversal // currentTraversal being built.
+
+	// locals holds the names of comprehension iteration variables in scope.
+	// Traversals starting with a local are dropped since they can never refer
+	// to a component.
+	locals map[string]int
 }
 
 func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
@@ -151,6 +156,26 @@ func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
 			ast.Walk(tw, arg)
 		}
 		return nil
+
+	case *ast.ComprehensionExpr:
+		// The collection is evaluated outside of the comprehension, while the
+		// remaining expressions can reference the iteration variables.
+		ast.Walk(tw, n.Collection)
+		tw.flush()
+
+		vars := []*ast.Ident{n.ValueVar}
+		if n.KeyVar != nil {
+			vars = append(vars, n.KeyVar)
+		}
+		tw.pushLocals(vars)
+		for _, e := range []ast.Expr{n.Key, n.Value, n.Cond} {
+			if e != nil {
+				ast.Walk(tw, e)
+				tw.flush()
+			}
+		}
+		tw.popLocals(vars)
+		return nil
 	}
 
 	return tw
@@ -159,13 +184,28 @@ func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
 // flush will flush the in-progress traversal to the traversals list and unset
 // the buildTraversal state.
 func (tw *traversalWalker) flush() {
-	if tw.buildTraversal && len(tw.currentTraversal) > 0 {
+	if tw.buildTraversal && len(tw.currentTraversal) > 0 && tw.locals[tw.currentTraversal[0].Name] == 0 {
 		tw.traversals = append(tw.traversals, tw.currentTraversal)
 	}
 	tw.buildTraversal = false
 	tw.currentTraversal = nil
 }
 
+func (tw *traversalWalker) pushLocals(vars []*ast.Ident) {
+	if tw.locals == nil {
+		tw.locals = make(map[string]int)
+	}
+	for _, v := range vars {
+		tw.locals[v.Name]++
+	}
+}
+
+func (tw *traversalWalker) popLocals(vars []*ast.Ident) {
+	for _, v := range vars {
+		tw.locals[v.Name]--
+	}
+}
+
 func resolveTraversal(t Traversal, g *dag.Graph) (Reference, diag.Diagnostics) {
 	var (
 		diags diag.Diagnostics
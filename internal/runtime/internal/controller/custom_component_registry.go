@@ -2,32 +2,35 @@ package controller
 
 import (
 	"fmt"
+	"maps"
 	"sync"
 
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
-// CustomComponentRegistry holds custom component definitions that are available in the context.
+// CustomComponentRegistry holds custom component and function definitions that are available in the context.
 // The definitions are either imported, declared locally, or declared in a parent registry.
 // Imported definitions are stored inside of the corresponding import registry.
 type CustomComponentRegistry struct {
 	parent *CustomComponentRegistry // nil if root config
 
-	mut      sync.RWMutex
-	scope    *vm.Scope
-	imports  map[string]*CustomComponentRegistry // importNamespace: importScope
-	declares map[string]ast.Body                 // customComponentName: template
+	mut       sync.RWMutex
+	scope     *vm.Scope
+	imports   map[string]*CustomComponentRegistry // importNamespace: importScope
+	declares  map[string]ast.Body                 // customComponentName: template
+	functions map[string]*functionDefinition      // functionName: definition
 }
 
 // NewCustomComponentRegistry creates a new CustomComponentRegistry with a parent.
 // parent can be nil.
 func NewCustomComponentRegistry(parent *CustomComponentRegistry, scope *vm.Scope) *CustomComponentRegistry {
 	return &CustomComponentRegistry{
-		parent:   parent,
-		scope:    scope,
-		declares: make(map[string]ast.Body),
-		imports:  make(map[string]*CustomComponentRegistry),
+		parent:    parent,
+		scope:     scope,
+		declares:  make(map[string]ast.Body),
+		imports:   make(map[string]*CustomComponentRegistry),
+		functions: make(map[string]*functionDefinition),
 	}
 }
 
@@ -58,6 +61,13 @@ func (s *CustomComponentRegistry) registerDeclare(declare *ast.BlockStmt) {
 	s.declares[declare.Label] = declare.Body
 }
 
+// registerFunction stores a local function definition.
+func (s *CustomComponentRegistry) registerFunction(name string, def *functionDefinition) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.functions[name] = def
+}
+
 // registerImport stores the import namespace.
 // The content will be added later during evaluation.
 // It's important to register it before populating the component nodes
@@ -80,6 +90,7 @@ func (s *CustomComponentRegistry) updateImportContent(importNode *ImportConfigNo
 	}
 	importScope := NewCustomComponentRegistry(nil, importNode.Scope())
 	importScope.declares = importNode.ImportedDeclares()
+	importScope.functions = importNode.ImportedFunctions()
 	importScope.updateImportContentChildren(importNode)
 	s.imports[importNode.label] = importScope
 }
@@ -90,7 +101,54 @@ func (s *CustomComponentRegistry) updateImportContentChildren(importNode *Import
 	for _, child := range importNode.ImportConfigNodesChildren() {
 		childScope := NewCustomComponentRegistry(nil, child.Scope())
 		childScope.declares = child.ImportedDeclares()
+		childScope.functions = child.ImportedFunctions()
 		childScope.updateImportContentChildren(child)
 		s.imports[child.label] = childScope
 	}
 }
+
+// functionVariables returns the functions which can be called from expressions
+// evaluated in the context of the registry.
+// Local functions and functions of parent registries are exposed by name.
+// Imported functions are exposed under their import namespace, only if the import defines functions.
+// Functions are evaluated against the variables returned here, so they can call each other.
+func (s *CustomComponentRegistry) functionVariables() map[string]any {
+	vars := make(map[string]any)
+	if s.parent != nil {
+		maps.Copy(vars, s.parent.functionVariables())
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	for importNamespace, importScope := range s.imports {
+		if imported := importScope.exportedFunctions(); len(imported) > 0 {
+			vars[importNamespace] = imported
+		}
+	}
+
+	scope := vm.NewScope(vars)
+	for name, def := range s.functions {
+		vars[name] = vm.NewFunction(def.params, def.result, scope)
+	}
+	return vars
+}
+
+// exportedFunctions returns the functions defined in an import registry.
+// It returns nil if the content of the import is not loaded yet.
+func (s *CustomComponentRegistry) exportedFunctions() map[string]any {
+	if s == nil {
+		return nil
+	}
+
+	vars := s.functionVariables()
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	exported := make(map[string]any, len(s.functions))
+	for name := range s.functions {
+		exported[name] = vars[name]
+	}
+	return exported
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/scanner"
+	"github.com/grafana/alloy/syntax/typecheck"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// functionDefinition holds a user-defined function declared with a function
+// block. Functions aren't added to the graph: they are exposed to expressions
+// as values and evaluated by the vm on each call.
+type functionDefinition struct {
+	block  *ast.BlockStmt
+	params []string
+	result ast.Expr
+}
+
+// newFunctionDefinition validates a function block and extracts its
+// parameters and result expression.
+func newFunctionDefinition(b *ast.BlockStmt) (*functionDefinition, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	switch {
+	case b.Label == "":
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  "function block must have a label",
+			StartPos: ast.StartPos(b).Position(),
+			EndPos:   ast.EndPos(b).Position(),
+		})
+		return nil, diags
+	case !scanner.IsValidIdentifier(b.Label):
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("function block label %q must be a valid identifier", b.Label),
+			StartPos: ast.StartPos(b).Position(),
+			EndPos:   ast.EndPos(b).Position(),
+		})
+		return nil, diags
+	}
+
+	diags = append(diags, typecheck.Block(b, &function.Arguments{})...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	def := &functionDefinition{block: b}
+	for _, stmt := range b.Body {
+		attr := stmt.(*ast.AttributeStmt)
+		if attr.Name.Name == function.ResultAttr {
+			def.result = attr.Value
+			continue
+		}
+
+		// The parameters must be known before the function is called, so they
+		// are evaluated without any variable in scope.
+		if err := vm.New(attr.Value).Evaluate(vm.NewScope(nil), &def.params); err != nil {
+			var d diag.Diagnostic
+			if !errors.As(err, &d) {
+				d = diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  err.Error(),
+					StartPos: ast.StartPos(attr.Value).Position(),
+					EndPos:   ast.EndPos(attr.Value).Position(),
+				}
+			}
+			diags.Add(d)
+			return nil, diags
+		}
+	}
+
+	seen := make(map[string]struct{}, len(def.params))
+	for _, param := range def.params {
+		if !scanner.IsValidIdentifier(param) {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("function %q has an invalid parameter name %q", b.Label, param),
+				StartPos: ast.StartPos(b).Position(),
+				EndPos:   ast.EndPos(b).Position(),
+			})
+		} else if _, ok := seen[param]; ok {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("function %q has a duplicate parameter %q", b.Label, param),
+				StartPos: ast.StartPos(b).Position(),
+				EndPos:   ast.EndPos(b).Position(),
+			})
+		}
+		seen[param] = struct{}{}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return def, diags
+}
+
+// calls returns the names of the functions in defs which are called by def.
+func (def *functionDefinition) calls(defs map[string]*functionDefinition) []string {
+	var w traversalWalker
+	w.pushLocals(identsFromNames(def.params))
+	ast.Walk(&w, def.result)
+	w.flush()
+
+	var names []string
+	for _, t := range w.traversals {
+		if _, ok := defs[t[0].Name]; ok {
+			names = append(names, t[0].Name)
+		}
+	}
+	return names
+}
+
+// findFunctionCycle returns the names of the functions forming a cycle of
+// calls in defs, or nil if there's none. Functions calling each other
+// recursively would never finish evaluating.
+func findFunctionCycle(defs map[string]*functionDefinition) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var (
+		state = make(map[string]int, len(defs))
+		path  []string
+		visit func(name string) []string
+	)
+
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(path[i:], name)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, callee := range defs[name].calls(defs) {
+			if cycle := visit(callee); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	// Visit functions in a stable order so the reported cycle is deterministic.
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// functionCycleError returns an error describing a cycle found by
+// findFunctionCycle.
+func functionCycleError(cycle []string) error {
+	return fmt.Errorf("functions can't call themselves recursively: %s", strings.Join(cycle, " -> "))
+}
+
+func identsFromNames(names []string) []*ast.Ident {
+	idents := make([]*ast.Ident, 0, len(names))
+	for _, name := range names {
+		idents = append(idents, &ast.Ident{Name: name})
+	}
+	return idents
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/alloy/internal/dag"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/runtime/tracing"
@@ -129,6 +130,7 @@ type ApplyOptions struct {
 	ComponentBlocks []*ast.BlockStmt // pieces of config that can be used to instantiate builtin components and services
 	ConfigBlocks    []*ast.BlockStmt // pieces of config that can be used to instantiate config nodes
 	DeclareBlocks   []*ast.BlockStmt // pieces of config that can be used as templates to instantiate custom components
+	FunctionBlocks  []*ast.BlockStmt // pieces of config that define functions callable from expressions
 
 	// CustomComponentRegistry holds custom component templates.
 	// The definition of a custom component instantiated inside of the loaded config
@@ -170,7 +172,7 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 	// Create a new CustomComponentRegistry based on the provided one.
 	// The provided one should be nil for the root config.
 	l.componentNodeManager.setCustomComponentRegistry(NewCustomComponentRegistry(options.CustomComponentRegistry, options.ArgScope))
-	newGraph, diags := l.loadNewGraph(options.Args, options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks)
+	newGraph, diags := l.loadNewGraph(options.Args, options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks, options.FunctionBlocks)
 	if diags.HasErrors() {
 		return diags
 	}
@@ -301,7 +303,7 @@ func (l *Loader) Cleanup(stopWorkerPool bool) {
 }
 
 // loadNewGraph creates a new graph from the provided blocks and validates it.
-func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt, declareBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
+func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt, declareBlocks []*ast.BlockStmt, functionBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
 	var g dag.Graph
 
 	// Split component blocks into blocks for components and services.
@@ -315,10 +317,19 @@ func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockS
 	declareDiags := l.populateDeclareNodes(&g, declareBlocks)
 	diags = append(diags, declareDiags...)
 
+	// Functions aren't part of the graph, but they must be registered before
+	// wiring the graph so expressions calling them can be resolved.
+	functionDiags := l.registerFunctions(functionBlocks, reservedFunctionNames(componentBlocks, configBlocks))
+	diags = append(diags, functionDiags...)
+
 	// Fill our graph with config blocks.
 	configBlockDiags := l.populateConfigBlockNodes(args, &g, configBlocks)
 	diags = append(diags, configBlockDiags...)
 
+	// Expose the registered functions, including the ones of imports which have
+	// already been loaded.
+	l.cache.UpdateFunctions(l.componentNodeManager.customComponentReg.functionVariables())
+
 	// Fill our graph with components.
 	componentNodeDiags := l.populateComponentNodes(&g, componentBlocks)
 	diags = append(diags, componentNodeDiags...)
@@ -395,6 +406,74 @@ func (l *Loader) populateDeclareNodes(g *dag.Graph, declareBlocks []*ast.BlockSt
 	return diags
 }
 
+// reservedFunctionNames returns the names of the scope which functions can't
+// use, along with what they refer to. A function with one of these names
+// would hide the values of the scope.
+func reservedFunctionNames(componentBlocks, configBlocks []*ast.BlockStmt) map[string]string {
+	reserved := map[string]string{argumentLabel: "the module arguments"}
+	for _, block := range componentBlocks {
+		reserved[block.Name[0]] = fmt.Sprintf("the component namespace %q", block.Name[0])
+	}
+	for _, block := range configBlocks {
+		switch block.GetBlockName() {
+		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+			importsource.BlockNameS3, importsource.BlockNameOCI:
+			reserved[block.Label] = fmt.Sprintf("the import namespace %q", block.Label)
+		}
+	}
+	return reserved
+}
+
+// registerFunctions validates function blocks and registers them in the custom component registry.
+// Function names must not be one of the reserved names.
+func (l *Loader) registerFunctions(functionBlocks []*ast.BlockStmt, reserved map[string]string) diag.Diagnostics {
+	var (
+		diags    diag.Diagnostics
+		blockMap = make(map[string]*ast.BlockStmt, len(functionBlocks))
+		defs     = make(map[string]*functionDefinition, len(functionBlocks))
+	)
+	for _, functionBlock := range functionBlocks {
+		id := BlockComponentID(functionBlock).String()
+
+		if diag, defined := blockAlreadyDefined(blockMap, id, functionBlock); defined {
+			diags = append(diags, diag)
+			continue
+		}
+
+		if what, ok := reserved[functionBlock.Label]; ok {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("function %q conflicts with %s", functionBlock.Label, what),
+				StartPos: functionBlock.NamePos.Position(),
+				EndPos:   functionBlock.NamePos.Add(len(id) - 1).Position(),
+			})
+			continue
+		}
+
+		def, defDiags := newFunctionDefinition(functionBlock)
+		diags = append(diags, defDiags...)
+		if defDiags.HasErrors() {
+			continue
+		}
+		defs[functionBlock.Label] = def
+	}
+
+	if cycle := findFunctionCycle(defs); cycle != nil {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  functionCycleError(cycle).Error(),
+			StartPos: ast.StartPos(defs[cycle[0]].block).Position(),
+			EndPos:   ast.EndPos(defs[cycle[0]].block).Position(),
+		})
+		return diags
+	}
+
+	for name, def := range defs {
+		l.componentNodeManager.customComponentReg.registerFunction(name, def)
+	}
+	return diags
+}
+
 // blockAlreadyDefined returns (diag, true) if the given id is already in the provided blockMap.
 // else it adds the block to the map and returns (empty diag, false).
 func blockAlreadyDefined(blockMap map[string]*ast.BlockStmt, id string, block *ast.BlockStmt) (diag.Diagnostic, bool) {
@@ -642,10 +721,11 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 		case *ForeachConfigNode:
 			l.wireForEachNode(g, n)
 		}
+		l.wireFunctionImports(g, n)
 
 		// Finally, wire component references.
 		l.cache.mut.RLock()
-		refs, nodeDiags := ComponentReferences(n, g, l.log, l.cache.GetContext(), l.globals.MinStability)
+		refs, nodeDiags := ComponentReferences(n, g, l.log, l.wiringScope(), l.globals.MinStability)
 		l.cache.mut.RUnlock()
 		setDataFlowEdges(n, refs)
 		for _, ref := range refs {
@@ -681,6 +761,33 @@ func (l *Loader) wireForEachNode(g *dag.Graph, fn *ForeachConfigNode) {
 	}
 }
 
+// wireFunctionImports adds edges between a node and the import nodes whose functions are called by the node.
+// This ensures that the node is evaluated after the imported functions are loaded, and reevaluated when they change.
+func (l *Loader) wireFunctionImports(g *dag.Graph, n dag.Node) {
+	bn, ok := n.(BlockNode)
+	if !ok || bn.Block() == nil {
+		return
+	}
+	for _, t := range expressionsFromBody(bn.Block().Body) {
+		if importNode, ok := l.importConfigNodes[t[0].Name]; ok && len(t) > 1 {
+			g.AddEdge(dag.Edge{From: n, To: importNode})
+		}
+	}
+}
+
+// wiringScope returns the scope used to resolve the references of the nodes.
+// Import namespaces are added to the scope because the functions they define
+// are only known once the import nodes are evaluated.
+func (l *Loader) wiringScope() *vm.Scope {
+	scope := l.cache.GetContext()
+	for importNamespace := range l.importConfigNodes {
+		if _, found := scope.Lookup(importNamespace); !found {
+			scope.Variables[importNamespace] = map[string]any{}
+		}
+	}
+	return scope
+}
+
 // Variables returns the Variables the Loader exposes for other components to
 // reference.
 func (l *Loader) Variables() map[string]interface{} {
@@ -722,6 +829,14 @@ func (l *Loader) Graph() *dag.Graph {
 	return l.graph.Clone()
 }
 
+// Drain stops the components run by sched in the dependency order of the
+// graph until ctx is done. See [Scheduler.Drain].
+func (l *Loader) Drain(ctx context.Context, sched *Scheduler) {
+	sched.Drain(ctx, l.Graph(), func(remaining int) {
+		l.cm.drainRemainingComponents.Set(float64(remaining))
+	})
+}
+
 // EvaluateDependants sends nodes which depend directly on nodes in updatedNodes for evaluation to the
 // workerPool. It should be called whenever nodes update their exports.
 // It is beneficial to call EvaluateDependants with a batch of nodes, as it will enqueue the entire batch before
@@ -756,6 +871,7 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 		case *ImportConfigNode:
 			// Update the scope with the imported content.
 			l.componentNodeManager.customComponentReg.updateImportContent(parentNode)
+			l.cache.UpdateFunctions(l.componentNodeManager.customComponentReg.functionVariables())
 		}
 		// We collect all nodes directly incoming to parent.
 		_ = dag.WalkIncomingNodes(l.graph, parent.Node, func(n dag.Node) error {
@@ -917,6 +1033,7 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 		}
 	case *ImportConfigNode:
 		l.componentNodeManager.customComponentReg.updateImportContent(c)
+		l.cache.UpdateFunctions(l.componentNodeManager.customComponentReg.functionVariables())
 	}
 
 	if err != nil {
@@ -221,6 +221,32 @@ func TestLoader(t *testing.T) {
 		require.NoError(t, diags.ErrorOrNil())
 	})
 
+	t.Run("Load component with comprehension", func(t *testing.T) {
+		file := `
+			testcomponents.passthrough "static" {
+				input = "1s"
+			}
+
+			testcomponents.tick "default" {
+				frequency = [for f in [testcomponents.passthrough.static.output] : f if f != ""][0]
+			}
+		`
+		l := controller.NewLoader(newLoaderOptions())
+		diags := applyFromContent(t, l, []byte(file), nil, nil)
+		require.NoError(t, diags.ErrorOrNil())
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: []string{
+				"testcomponents.passthrough.static",
+				"testcomponents.tick.default",
+				"logging",
+				"tracing",
+			},
+			OutEdges: []edge{
+				{From: "testcomponents.tick.default", To: "testcomponents.passthrough.static"},
+			},
+		})
+	})
+
 	t.Run("Load with correct stability level", func(t *testing.T) {
 		l := controller.NewLoader(newLoaderOptionsWithStability(featuregate.StabilityPublicPreview))
 		diags := applyFromContent(t, l, []byte(testFile), nil, nil)
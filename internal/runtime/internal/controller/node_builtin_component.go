@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/equality"
 	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/runtime/resources"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/vm"
@@ -84,6 +85,7 @@ type ComponentGlobals struct {
 	NewModuleController  func(opts ModuleControllerOpts) ModuleController // Func to generate a module controller.
 	GetServiceData       func(name string) (interface{}, error)           // Get data for a service.
 	EnableCommunityComps bool                                             // Enables the use of community components.
+	ModuleLockFile       string                                           // Lock file of the modules imported with a version constraint.
 }
 
 // BuiltinComponentNode is a controller node which manages a builtin component.
@@ -286,8 +288,15 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 	argsCopyValue := reflect.ValueOf(argsPointer).Elem().Interface()
 
 	if cn.managed == nil {
-		// We haven't built the managed component successfully yet.
-		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
+		// We haven't built the managed component successfully yet. Goroutines
+		// started by the component when it's built are labeled with its ID.
+		var (
+			managed component.Component
+			err     error
+		)
+		resources.Do(context.Background(), cn.globalID, cn.componentName, func(context.Context) {
+			managed, err = cn.reg.Build(cn.managedOpts, argsCopyValue)
+		})
 		if err != nil {
 			return fmt.Errorf("building component: %w", err)
 		}
@@ -305,7 +314,11 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := cn.managed.Update(argsCopyValue); err != nil {
+	var err error
+	resources.Do(context.Background(), cn.globalID, cn.componentName, func(context.Context) {
+		err = cn.managed.Update(argsCopyValue)
+	})
+	if err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
 
@@ -329,7 +342,13 @@ func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
 	}
 
 	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+
+	// The goroutines running the component are labeled with its ID, so that
+	// its resource usage can be found in profiles.
+	var err error
+	resources.Do(ctx, cn.globalID, cn.componentName, func(ctx context.Context) {
+		err = cn.managed.Run(ctx)
+	})
 
 	// Note: logging of this error is handled by the scheduler.
 	if err != nil {
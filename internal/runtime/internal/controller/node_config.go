@@ -19,7 +19,9 @@ const (
 
 // Add config blocks that are not GA. Config blocks that are not specified here are considered GA.
 var configBlocksUnstable = map[string]featuregate.Stability{
-	foreach.BlockName: foreach.StabilityLevel,
+	foreach.BlockName:         foreach.StabilityLevel,
+	importsource.BlockNameS3:  importsource.Stability(importsource.BlockNameS3),
+	importsource.BlockNameOCI: importsource.Stability(importsource.BlockNameOCI),
 }
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -46,7 +48,8 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, customReg *Cu
 		return NewLoggingConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
-	case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+	case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+		importsource.BlockNameS3, importsource.BlockNameOCI:
 		return NewImportConfigNode(block, globals, importsource.GetSourceType(block.GetBlockName())), nil
 	case foreach.BlockName:
 		return NewForeachConfigNode(block, globals, customReg), nil
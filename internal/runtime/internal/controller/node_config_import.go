@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/internal/runner"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
@@ -26,11 +27,11 @@ import (
 	"github.com/grafana/alloy/syntax/vm"
 )
 
-// ImportConfigNode imports declare and import blocks via a managed import source.
-// The imported declare are stored in importedDeclares.
+// ImportConfigNode imports declare, function and import blocks via a managed import source.
+// The imported declare and function blocks are stored in importedDeclares and importedFunctions.
 // For every imported import block, the ImportConfigNode will create ImportConfigNode children.
 // The children are evaluated and ran by the parent.
-// When an ImportConfigNode receives new content from its source, it updates its importedDeclares and recreates its children.
+// When an ImportConfigNode receives new content from its source, it updates its importedDeclares and importedFunctions and recreates its children.
 // Then an update call is propagated to the root ImportConfigNode to inform the controller for reevaluation.
 type ImportConfigNode struct {
 	nodeID        string
@@ -53,6 +54,7 @@ type ImportConfigNode struct {
 	importConfigNodesChildren map[string]*ImportConfigNode
 	importChildrenRunning     bool
 	importedDeclares          map[string]ast.Body
+	importedFunctions         map[string]*functionDefinition
 
 	// NOTE: To avoid deadlocks, whenever we need both locks we must always first lock the mut, then healthMut.
 	healthMut     sync.RWMutex
@@ -87,7 +89,7 @@ func NewImportConfigNode(block *ast.BlockStmt, globals ComponentGlobals, sourceT
 	}
 	managedOpts := getImportManagedOptions(globals, cn)
 	cn.logger = managedOpts.Logger
-	cn.source = importsource.NewImportSource(sourceType, managedOpts, vm.New(block.Body), cn.onContentUpdate)
+	cn.source = importsource.NewImportSource(sourceType, managedOpts, vm.New(block.Body), globals.ModuleLockFile, cn.onContentUpdate)
 	return cn
 }
 
@@ -212,6 +214,7 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		cn.importedContent[k] = v
 	}
 	cn.importedDeclares = make(map[string]ast.Body)
+	cn.importedFunctions = make(map[string]*functionDefinition)
 	cn.importConfigNodesChildren = make(map[string]*ImportConfigNode)
 
 	for f, ic := range importedContent {
@@ -222,7 +225,7 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 			return
 		}
 
-		// populate importedDeclares and importConfigNodesChildren
+		// populate importedDeclares, importedFunctions and importConfigNodesChildren
 		err = cn.processImportedContent(parsedImportedContent)
 		if err != nil {
 			level.Error(cn.logger).Log("msg", "failed to process imported content", "file", f, "err", err)
@@ -231,6 +234,13 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		}
 	}
 
+	if cycle := findFunctionCycle(cn.importedFunctions); cycle != nil {
+		err := functionCycleError(cycle)
+		level.Error(cn.logger).Log("msg", "failed to process imported content", "err", err)
+		cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("imported content is invalid: %s", err))
+		return
+	}
+
 	// evaluate the importConfigNodesChildren that have been created
 	err := cn.evaluateChildren()
 	if err != nil {
@@ -251,25 +261,31 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 	cn.OnBlockNodeUpdate(cn)
 }
 
-// processImportedContent processes declare and import blocks of the provided ast content.
+// processImportedContent processes declare, function and import blocks of the provided ast content.
 func (cn *ImportConfigNode) processImportedContent(content *ast.File) error {
 	for _, stmt := range content.Body {
 		blockStmt, ok := stmt.(*ast.BlockStmt)
 		if !ok {
-			return fmt.Errorf("only declare and import blocks are allowed in a module")
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module")
 		}
 
 		componentName := strings.Join(blockStmt.Name, ".")
 		switch componentName {
 		case declareType:
 			cn.processDeclareBlock(blockStmt)
-		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+		case function.BlockName:
+			err := cn.processFunctionBlock(blockStmt)
+			if err != nil {
+				return err
+			}
+		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+			importsource.BlockNameS3, importsource.BlockNameOCI:
 			err := cn.processImportBlock(blockStmt, componentName)
 			if err != nil {
 				return err
 			}
 		default:
-			return fmt.Errorf("only declare and import blocks are allowed in a module, got %s", componentName)
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module, got %s", componentName)
 		}
 	}
 	return nil
@@ -284,7 +300,20 @@ func (cn *ImportConfigNode) processDeclareBlock(stmt *ast.BlockStmt) {
 	cn.importedDeclares[stmt.Label] = stmt.Body
 }
 
-// processDeclareBlock creates an ImportConfigNode child from the provided import block.
+// processFunctionBlock stores the function definition in the importedFunctions.
+func (cn *ImportConfigNode) processFunctionBlock(stmt *ast.BlockStmt) error {
+	if _, ok := cn.importedFunctions[stmt.Label]; ok {
+		return fmt.Errorf("function block redefined %s", stmt.Label)
+	}
+	def, diags := newFunctionDefinition(stmt)
+	if diags.HasErrors() {
+		return diags
+	}
+	cn.importedFunctions[stmt.Label] = def
+	return nil
+}
+
+// processImportBlock creates an ImportConfigNode child from the provided import block.
 func (cn *ImportConfigNode) processImportBlock(stmt *ast.BlockStmt, fullName string) error {
 	sourceType := importsource.GetSourceType(fullName)
 	if _, ok := cn.importConfigNodesChildren[stmt.Label]; ok {
@@ -296,8 +325,10 @@ func (cn *ImportConfigNode) processImportBlock(stmt *ast.BlockStmt, fullName str
 	// Children data paths are nested inside their parents to avoid collisions.
 	childGlobals.DataPath = filepath.Join(childGlobals.DataPath, cn.globalID)
 
-	if importsource.GetSourceType(cn.block.GetBlockName()) == importsource.HTTP && sourceType == importsource.File {
-		return fmt.Errorf("importing a module via import.http (nodeID: %s) that contains an import.file block is not supported", cn.nodeID)
+	// Remote sources don't store modules on disk, so they can't be the base of
+	// relative file imports.
+	if parentType := importsource.GetSourceType(cn.block.GetBlockName()); (parentType == importsource.HTTP || parentType == importsource.S3) && sourceType == importsource.File {
+		return fmt.Errorf("importing a module via %s (nodeID: %s) that contains an import.file block is not supported", cn.block.GetBlockName(), cn.nodeID)
 	}
 
 	cn.importConfigNodesChildren[stmt.Label] = NewImportConfigNode(stmt, childGlobals, sourceType)
@@ -434,6 +465,13 @@ func (cn *ImportConfigNode) ImportedDeclares() map[string]ast.Body {
 	return cn.importedDeclares
 }
 
+// ImportedFunctions returns all function blocks that it imported.
+func (cn *ImportConfigNode) ImportedFunctions() map[string]*functionDefinition {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.importedFunctions
+}
+
 // Scope returns the scope associated with the import source.
 func (cn *ImportConfigNode) Scope() *vm.Scope {
 	return vm.NewScope(map[string]interface{}{
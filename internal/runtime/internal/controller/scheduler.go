@@ -3,10 +3,14 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
+	"go.uber.org/atomic"
 
+	"github.com/grafana/alloy/internal/dag"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 )
 
@@ -25,21 +29,45 @@ type Scheduler struct {
 
 	tasksMut sync.Mutex
 	tasks    map[string]*task
+
+	// drainDeadline is the deadline of the ongoing drain, if any.
+	drainDeadline atomic.Pointer[time.Time]
 }
 
+// drainKey is the context key of the Scheduler running a task.
+type drainKey struct{}
+
 // NewScheduler creates a new Scheduler. Call Synchronize to manage the set of
 // components which are running.
 //
 // Call Close to stop the Scheduler and all running components.
 func NewScheduler(logger log.Logger) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Scheduler{
-		ctx:    ctx,
+	s := &Scheduler{
 		cancel: cancel,
 		logger: logger,
 
 		tasks: make(map[string]*task),
 	}
+	s.ctx = context.WithValue(ctx, drainKey{}, s)
+	return s
+}
+
+// DrainDeadline returns the deadline of the drain of the Scheduler running
+// the component given ctx. It returns false if the Scheduler isn't draining.
+//
+// Components running their own Scheduler, such as modules, use it to drain
+// their components within the deadline of their parent.
+func DrainDeadline(ctx context.Context) (time.Time, bool) {
+	s, ok := ctx.Value(drainKey{}).(*Scheduler)
+	if !ok {
+		return time.Time{}, false
+	}
+	deadline := s.drainDeadline.Load()
+	if deadline == nil {
+		return time.Time{}, false
+	}
+	return *deadline, true
 }
 
 // Synchronize synchronizes the running components to those defined by rr.
@@ -123,6 +151,102 @@ func (s *Scheduler) Close() error {
 	return nil
 }
 
+// Drain stops the running components in the dependency order of g: a
+// component is stopped once all the components depending on it have exited,
+// so that it can flush the data they sent to it. Sources, which no component
+// depends on, are stopped first. Services aren't stopped by Drain.
+//
+// Drain returns when all the components in g have exited or when ctx is done,
+// after which Close stops the remaining components at once. onProgress is
+// called with the number of components left to stop each time a component
+// exits.
+func (s *Scheduler) Drain(ctx context.Context, g *dag.Graph, onProgress func(remaining int)) {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.drainDeadline.Store(&deadline)
+	}
+
+	var (
+		stages    = drainStages(g)
+		remaining = 0
+	)
+	for _, ids := range stages {
+		remaining += len(s.lookupTasks(ids))
+	}
+	onProgress(remaining)
+
+	for _, ids := range stages {
+		tasks := s.lookupTasks(ids)
+		if len(tasks) == 0 {
+			continue
+		}
+
+		level.Info(s.logger).Log("msg", "draining components", "components", len(tasks), "remaining", remaining)
+		for _, t := range tasks {
+			t.cancel()
+		}
+		for id, t := range tasks {
+			select {
+			case <-t.exited:
+				remaining--
+				onProgress(remaining)
+				level.Debug(s.logger).Log("msg", "component drained", "node", id, "remaining", remaining)
+			case <-ctx.Done():
+				level.Warn(s.logger).Log("msg", "drain deadline exceeded, stopping the remaining components", "remaining", remaining)
+				return
+			}
+		}
+	}
+}
+
+// lookupTasks returns the running tasks of the nodes with the given IDs.
+func (s *Scheduler) lookupTasks(ids []string) map[string]*task {
+	s.tasksMut.Lock()
+	defer s.tasksMut.Unlock()
+
+	tasks := make(map[string]*task, len(ids))
+	for _, id := range ids {
+		if t, ok := s.tasks[id]; ok {
+			tasks[id] = t
+		}
+	}
+	return tasks
+}
+
+// drainStages groups the IDs of the nodes of g, except services, by the
+// order in which they're stopped: the nodes of a stage only have dependants
+// in the previous stages.
+func drainStages(g *dag.Graph) [][]string {
+	depths := make(map[dag.Node]int)
+	var depth func(n dag.Node) int
+	depth = func(n dag.Node) int {
+		if d, ok := depths[n]; ok {
+			return d
+		}
+		d := 0
+		for _, dependant := range g.Dependants(n) {
+			d = max(d, depth(dependant)+1)
+		}
+		depths[n] = d
+		return d
+	}
+
+	var stages [][]string
+	for _, n := range g.Nodes() {
+		if _, ok := n.(*ServiceNode); ok {
+			continue
+		}
+		d := depth(n)
+		for len(stages) <= d {
+			stages = append(stages, nil)
+		}
+		stages[d] = append(stages[d], n.NodeID())
+	}
+	for _, ids := range stages {
+		slices.Sort(ids)
+	}
+	return stages
+}
+
 // task is a scheduled runnable.
 type task struct {
 	ctx    context.Context
@@ -5,11 +5,15 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/dag"
 	"github.com/grafana/alloy/internal/runtime/internal/controller"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/vm"
@@ -92,6 +96,110 @@ func TestScheduler_Synchronize(t *testing.T) {
 	})
 }
 
+func TestScheduler_Drain(t *testing.T) {
+	logger := log.NewLogfmtLogger(os.Stdout)
+
+	// The sources depend on the processor, which depends on the writer.
+	var (
+		sourceA   = &dagNode{id: "source-a"}
+		sourceB   = &dagNode{id: "source-b"}
+		processor = &dagNode{id: "processor"}
+		writer    = &dagNode{id: "writer"}
+	)
+	var g dag.Graph
+	for _, n := range []dag.Node{sourceA, sourceB, processor, writer} {
+		g.Add(n)
+	}
+	g.AddEdge(dag.Edge{From: sourceA, To: processor})
+	g.AddEdge(dag.Edge{From: sourceB, To: processor})
+	g.AddEdge(dag.Edge{From: processor, To: writer})
+
+	t.Run("Stops components in dependency order", func(t *testing.T) {
+		var (
+			started sync.WaitGroup
+			mut     sync.Mutex
+			stopped []string
+		)
+		runFunc := func(id string) func(ctx context.Context) error {
+			started.Add(1)
+			return func(ctx context.Context) error {
+				started.Done()
+				<-ctx.Done()
+
+				_, draining := controller.DrainDeadline(ctx)
+				assert.True(t, draining)
+
+				mut.Lock()
+				defer mut.Unlock()
+				stopped = append(stopped, id)
+				return nil
+			}
+		}
+
+		sched := controller.NewScheduler(logger)
+		sched.Synchronize([]controller.RunnableNode{
+			fakeRunnable{ID: "writer", Component: mockComponent{RunFunc: runFunc("writer")}},
+			fakeRunnable{ID: "processor", Component: mockComponent{RunFunc: runFunc("processor")}},
+			fakeRunnable{ID: "source-a", Component: mockComponent{RunFunc: runFunc("source-a")}},
+			fakeRunnable{ID: "source-b", Component: mockComponent{RunFunc: runFunc("source-b")}},
+		})
+		started.Wait()
+
+		ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+		defer cancel()
+
+		var progress []int
+		sched.Drain(ctx, &g, func(remaining int) { progress = append(progress, remaining) })
+		require.NoError(t, sched.Close())
+
+		require.Len(t, stopped, 4)
+		require.ElementsMatch(t, []string{"source-a", "source-b"}, stopped[:2])
+		require.Equal(t, []string{"processor", "writer"}, stopped[2:])
+		require.Equal(t, []int{4, 3, 2, 1, 0}, progress)
+	})
+
+	t.Run("Stops remaining components after the deadline", func(t *testing.T) {
+		var (
+			started   sync.WaitGroup
+			flushed   = make(chan struct{})
+			cancelled atomic.Bool
+		)
+		started.Add(2)
+
+		sched := controller.NewScheduler(logger)
+		sched.Synchronize([]controller.RunnableNode{
+			fakeRunnable{ID: "writer", Component: mockComponent{RunFunc: func(ctx context.Context) error {
+				started.Done()
+				<-ctx.Done()
+				cancelled.Store(true)
+				return nil
+			}}},
+			// The processor never finishes flushing.
+			fakeRunnable{ID: "processor", Component: mockComponent{RunFunc: func(ctx context.Context) error {
+				started.Done()
+				<-ctx.Done()
+				<-flushed
+				return nil
+			}}},
+		})
+		started.Wait()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+
+		sched.Drain(ctx, &g, func(int) {})
+		require.False(t, cancelled.Load())
+
+		close(flushed)
+		require.NoError(t, sched.Close())
+		require.True(t, cancelled.Load())
+	})
+}
+
+type dagNode struct{ id string }
+
+func (n *dagNode) NodeID() string { return n.id }
+
 type fakeRunnable struct {
 	ID        string
 	Component component.Component
@@ -21,6 +21,7 @@ type valueCache struct {
 	moduleExports      map[string]any         // Export label -> Export value
 	moduleArguments    map[string]any         // Argument label -> Map with the key "value" that points to the Argument value
 	moduleChangedIndex int                    // Everytime a change occurs this is incremented
+	functions          map[string]any         // Function name or import namespace -> Callable functions
 	scope              *vm.Scope              // scope provides additional context for the nodes in the module
 }
 
@@ -44,6 +45,13 @@ func (vc *valueCache) UpdateScopeVariables(variables map[string]any) {
 	vc.scope.Variables = deepCopyMap(variables)
 }
 
+// UpdateFunctions replaces the functions exposed as variables to the nodes in the module.
+func (vc *valueCache) UpdateFunctions(functions map[string]any) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	vc.functions = functions
+}
+
 // CacheExports will cache the provided exports using the given id. exports may
 // be nil to store an empty object.
 func (vc *valueCache) CacheExports(id ComponentID, exports component.Exports) error {
@@ -210,6 +218,11 @@ func (vc *valueCache) GetContext() *vm.Scope {
 	defer vc.mut.RUnlock()
 	vars := deepCopyMap(vc.scope.Variables)
 
+	// Add functions. They take precedence over the scope variables but not over
+	// the module arguments. The functions of an import namespace are merged with
+	// the exports of the custom components declared by the same import.
+	mergeFunctions(vars, vc.functions)
+
 	// Add module arguments if there are any.
 	if len(vc.moduleArguments) > 0 {
 		vars[argumentLabel] = deepCopyMap(vc.moduleArguments)
@@ -218,6 +231,20 @@ func (vc *valueCache) GetContext() *vm.Scope {
 	return vm.NewScope(vars)
 }
 
+// mergeFunctions adds the functions to vars, merging the nested maps of
+// import namespaces which are present in both.
+func mergeFunctions(vars map[string]any, functions map[string]any) {
+	for name, fn := range functions {
+		existing, existingIsMap := vars[name].(map[string]any)
+		namespace, namespaceIsMap := fn.(map[string]any)
+		if existingIsMap && namespaceIsMap {
+			mergeFunctions(existing, namespace)
+			continue
+		}
+		vars[name] = fn
+	}
+}
+
 func deepCopyMap(original map[string]any) map[string]any {
 	newMap := make(map[string]any, len(original))
 	for key, value := range original {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
@@ -12,6 +13,7 @@ import (
 	"github.com/grafana/alloy/internal/runtime/internal/worker"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/runtime/resources"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/scanner"
@@ -143,6 +145,9 @@ func newModule(o *moduleOptions) *module {
 				DataPath:             o.DataPath,
 				MinStability:         o.MinStability,
 				EnableCommunityComps: o.EnableCommunityComps,
+				ModuleLockFile:       o.ModuleLockFile,
+				ResourceTracker:      o.ResourceTracker,
+				DrainTimeout:         o.DrainTimeout,
 				OnExportsChange: func(exports map[string]any) {
 					if o.export != nil {
 						o.export(exports)
@@ -230,4 +235,15 @@ type moduleControllerOptions struct {
 
 	// EnableCommunityComps enables the use of community components.
 	EnableCommunityComps bool
+
+	// ModuleLockFile is the lock file of the modules imported with a version
+	// constraint.
+	ModuleLockFile string
+
+	// ResourceTracker tracks the resource usage of components.
+	ResourceTracker *resources.Tracker
+
+	// DrainTimeout is the maximum duration of the drain of the components of
+	// the module when it stops outside of the drain of its parent.
+	DrainTimeout time.Duration
 }
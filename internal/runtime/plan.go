@@ -0,0 +1,201 @@
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/internal/controller"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// ChangeAction describes how applying a new configuration affects a
+// component.
+type ChangeAction string
+
+const (
+	ChangeAdded     ChangeAction = "added"     // The component is created.
+	ChangeRemoved   ChangeAction = "removed"   // The component is stopped and removed.
+	ChangeUpdated   ChangeAction = "updated"   // The component is re-evaluated with changed arguments.
+	ChangeUnchanged ChangeAction = "unchanged" // The arguments of the component don't change.
+)
+
+// ComponentChange describes how applying a new configuration affects a single
+// component.
+type ComponentChange struct {
+	ID     string       `json:"id"`
+	Action ChangeAction `json:"action"`
+
+	// Reason is set when the arguments of an updated component couldn't be
+	// compared, and explains why the component is assumed to be updated.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Plan describes the changes applying a new configuration would make to the
+// components of the root module.
+type Plan struct {
+	Components []ComponentChange `json:"components"` // Sorted by ID.
+}
+
+// Count returns the number of components affected by action.
+func (p *Plan) Count(action ChangeAction) int {
+	var n int
+	for _, c := range p.Components {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges returns true if p adds, removes or updates any component.
+func (p *Plan) HasChanges() bool {
+	return p.Count(ChangeUnchanged) != len(p.Components)
+}
+
+// PlanSources computes the changes applying newSource would make to the
+// components of oldSource, without running either of them.
+//
+// Arguments are evaluated with an empty scope, so the arguments of
+// components referencing other components or module arguments can't be
+// compared. Those components are reported as updated when their blocks
+// differ.
+func PlanSources(oldSource, newSource *Source, registry component.Registry) *Plan {
+	scope := vm.NewScope(nil)
+	evaluate := func(b *ast.BlockStmt) (component.Arguments, error) {
+		return evaluateArguments(registry, scope, b)
+	}
+	return planComponents(sourceComponents(oldSource), sourceComponents(newSource), evaluate, evaluate)
+}
+
+// Plan computes the changes applying source would make to the components
+// currently loaded by f. Arguments of components in source are evaluated
+// against the current exports of the running components.
+//
+// Components referencing the exports of added or updated components can only
+// be compared when their blocks are identical, and may be updated again once
+// those exports change.
+func (f *Runtime) Plan(source *Source) *Plan {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	registry := f.opts.ComponentRegistry
+	if registry == nil {
+		registry = component.NewDefaultRegistry(f.opts.MinStability, f.opts.EnableCommunityComps)
+	}
+
+	var (
+		running = make(map[string]*ast.BlockStmt)
+		args    = make(map[string]component.Arguments)
+	)
+	for _, cn := range f.loader.Components() {
+		bn, ok := cn.(controller.BlockNode)
+		if !ok {
+			continue
+		}
+		running[cn.NodeID()] = bn.Block()
+		if _, ok := cn.(*controller.BuiltinComponentNode); ok {
+			args[cn.NodeID()] = cn.Arguments()
+		}
+	}
+
+	scope := vm.NewScope(f.loader.Variables())
+	currentArguments := func(b *ast.BlockStmt) (component.Arguments, error) {
+		id := controller.BlockComponentID(b).String()
+		if a, ok := args[id]; ok && a != nil {
+			return a, nil
+		}
+		return nil, fmt.Errorf("component %s hasn't been evaluated", id)
+	}
+	evaluate := func(b *ast.BlockStmt) (component.Arguments, error) {
+		return evaluateArguments(registry, scope, b)
+	}
+	return planComponents(running, sourceComponents(source), currentArguments, evaluate)
+}
+
+// sourceComponents returns the component blocks of s by ID. Blocks without a
+// label configure services rather than components and are ignored.
+func sourceComponents(s *Source) map[string]*ast.BlockStmt {
+	blocks := make(map[string]*ast.BlockStmt, len(s.components))
+	for _, b := range s.components {
+		if b.Label == "" {
+			continue
+		}
+		blocks[controller.BlockComponentID(b).String()] = b
+	}
+	return blocks
+}
+
+// evaluateArguments evaluates the arguments of the builtin component declared
+// by b.
+func evaluateArguments(registry component.Registry, scope *vm.Scope, b *ast.BlockStmt) (component.Arguments, error) {
+	reg, err := registry.Get(b.GetBlockName())
+	if err != nil {
+		return nil, err
+	}
+	argsPointer := reg.CloneArguments()
+	if err := vm.New(b.Body).Evaluate(scope, argsPointer); err != nil {
+		return nil, fmt.Errorf("decoding configuration: %w", err)
+	}
+	return reflect.ValueOf(argsPointer).Elem().Interface(), nil
+}
+
+// planComponents compares the components declared by the blocks in
+// oldBlocks and newBlocks. The arguments of components found in both are
+// computed with oldArgs and newArgs respectively. When they can't be
+// computed, the blocks are compared instead.
+func planComponents(oldBlocks, newBlocks map[string]*ast.BlockStmt, oldArgs, newArgs func(*ast.BlockStmt) (component.Arguments, error)) *Plan {
+	plan := &Plan{Components: []ComponentChange{}}
+
+	for id := range oldBlocks {
+		if _, ok := newBlocks[id]; !ok {
+			plan.Components = append(plan.Components, ComponentChange{ID: id, Action: ChangeRemoved})
+		}
+	}
+	for id, newBlock := range newBlocks {
+		oldBlock, ok := oldBlocks[id]
+		if !ok {
+			plan.Components = append(plan.Components, ComponentChange{ID: id, Action: ChangeAdded})
+			continue
+		}
+
+		change := ComponentChange{ID: id, Action: ChangeUpdated}
+		oldValue, oldErr := oldArgs(oldBlock)
+		newValue, newErr := newArgs(newBlock)
+		switch {
+		case oldErr == nil && newErr == nil:
+			if equality.DeepEqual(oldValue, newValue) {
+				change.Action = ChangeUnchanged
+			}
+		case sameBlock(oldBlock, newBlock):
+			change.Action = ChangeUnchanged
+		case newErr != nil:
+			change.Reason = fmt.Sprintf("arguments can't be compared: %s", newErr)
+		default:
+			change.Reason = fmt.Sprintf("arguments can't be compared: %s", oldErr)
+		}
+		plan.Components = append(plan.Components, change)
+	}
+
+	sort.Slice(plan.Components, func(i, j int) bool {
+		return plan.Components[i].ID < plan.Components[j].ID
+	})
+	return plan
+}
+
+// sameBlock returns true if a and b are formatted identically.
+func sameBlock(a, b *ast.BlockStmt) bool {
+	var bufA, bufB bytes.Buffer
+	if err := printer.Fprint(&bufA, a); err != nil {
+		return false
+	}
+	if err := printer.Fprint(&bufB, b); err != nil {
+		return false
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
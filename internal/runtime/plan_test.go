@@ -0,0 +1,119 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+var planOldFile = `
+	testcomponents.tick "ticker" {
+		frequency = "1s"
+	}
+
+	testcomponents.passthrough "static" {
+		input = "hello, world!"
+	}
+
+	testcomponents.passthrough "formatted" {
+		input = "hello, " + "world!"
+	}
+
+	testcomponents.passthrough "ticker" {
+		input = testcomponents.tick.ticker.tick_time
+	}
+
+	testcomponents.passthrough "removed" {
+		input = "bye"
+	}
+`
+
+var planNewFile = `
+	testcomponents.tick "ticker" {
+		frequency = "2s"
+	}
+
+	testcomponents.passthrough "static" {
+		input = "hello, world!"
+	}
+
+	testcomponents.passthrough "formatted" {
+		input = "hello, world!"
+	}
+
+	testcomponents.passthrough "ticker" {
+		input = testcomponents.tick.ticker.tick_time
+	}
+
+	testcomponents.passthrough "added" {
+		input = testcomponents.passthrough.static.output
+	}
+`
+
+func TestPlanSources(t *testing.T) {
+	oldSource, err := ParseSource("old", []byte(planOldFile))
+	require.NoError(t, err)
+	newSource, err := ParseSource("new", []byte(planNewFile))
+	require.NoError(t, err)
+
+	registry := component.NewDefaultRegistry(featuregate.StabilityPublicPreview, false)
+	plan := PlanSources(oldSource, newSource, registry)
+
+	require.Equal(t, []ComponentChange{
+		{ID: "testcomponents.passthrough.added", Action: ChangeAdded},
+		{ID: "testcomponents.passthrough.formatted", Action: ChangeUnchanged},
+		{ID: "testcomponents.passthrough.removed", Action: ChangeRemoved},
+		{ID: "testcomponents.passthrough.static", Action: ChangeUnchanged},
+		{ID: "testcomponents.passthrough.ticker", Action: ChangeUnchanged},
+		{ID: "testcomponents.tick.ticker", Action: ChangeUpdated},
+	}, plan.Components)
+	require.True(t, plan.HasChanges())
+	require.Equal(t, 3, plan.Count(ChangeUnchanged))
+
+	// Changes to blocks whose arguments can't be evaluated without running the
+	// configuration are reported with a reason.
+	changedSource, err := ParseSource("changed", []byte(`
+		testcomponents.passthrough "ticker" {
+			input = testcomponents.tick.ticker.tick_time
+			lag   = "1s"
+		}
+	`))
+	require.NoError(t, err)
+	plan = PlanSources(oldSource, changedSource, registry)
+	require.Equal(t, ChangeUpdated, plan.Components[3].Action)
+	require.Equal(t, "testcomponents.passthrough.ticker", plan.Components[3].ID)
+	require.Contains(t, plan.Components[3].Reason, "arguments can't be compared")
+
+	require.False(t, PlanSources(oldSource, oldSource, registry).HasChanges())
+}
+
+func TestController_Plan(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+	defer cleanUpController(t.Context(), ctrl)
+
+	oldSource, err := ParseSource("old", []byte(planOldFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(oldSource, nil, ""))
+
+	newSource, err := ParseSource("new", []byte(planNewFile))
+	require.NoError(t, err)
+	plan := ctrl.Plan(newSource)
+
+	// The arguments of testcomponents.passthrough.ticker are compared using the
+	// exports of the running testcomponents.tick.ticker component.
+	require.Equal(t, []ComponentChange{
+		{ID: "testcomponents.passthrough.added", Action: ChangeAdded},
+		{ID: "testcomponents.passthrough.formatted", Action: ChangeUnchanged},
+		{ID: "testcomponents.passthrough.removed", Action: ChangeRemoved},
+		{ID: "testcomponents.passthrough.static", Action: ChangeUnchanged},
+		{ID: "testcomponents.passthrough.ticker", Action: ChangeUnchanged},
+		{ID: "testcomponents.tick.ticker", Action: ChangeUpdated},
+	}, plan.Components)
+
+	// Planning doesn't change the running components.
+	require.Len(t, ctrl.loader.Components(), 5)
+}
@@ -0,0 +1,29 @@
+// Package resources attributes the resource usage of Alloy to the
+// components it runs.
+//
+// Components are identified by pprof labels set on the goroutines running
+// them, which are inherited by the goroutines they start. CPU and goroutine
+// profiles of Alloy can be filtered by these labels, for example with
+// `go tool pprof -tagfocus alloy_component_id=loki.process.default`.
+package resources
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+const (
+	// LabelComponentID is the pprof label holding the global ID of the
+	// component.
+	LabelComponentID = "alloy_component_id"
+
+	// LabelComponentName is the pprof label holding the name of the component,
+	// such as loki.process.
+	LabelComponentName = "alloy_component_name"
+)
+
+// Do calls f with the pprof labels of the component added to ctx and to the
+// calling goroutine. Goroutines started by f inherit the labels.
+func Do(ctx context.Context, componentID, componentName string, f func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(LabelComponentID, componentID, LabelComponentName, componentName), f)
+}
@@ -0,0 +1,371 @@
+package resources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Options configures a Tracker.
+type Options struct {
+	Logger log.Logger
+
+	// Interval is the interval between two samples of the resource usage.
+	Interval time.Duration
+
+	// CPUProfileDuration is the duration of the CPU profile taken at each
+	// sample. The CPU usage of components isn't sampled if it's zero.
+	//
+	// Only one CPU profile can be taken at a time: CPU profiles requested
+	// through /debug/pprof/profile fail while the Tracker takes one.
+	CPUProfileDuration time.Duration
+}
+
+// DefaultOptions holds the default settings for a Tracker.
+var DefaultOptions = Options{
+	Interval:           time.Minute,
+	CPUProfileDuration: 10 * time.Second,
+}
+
+// Tracker periodically samples the resource usage of the running components
+// from profiles of the process:
+//
+//   - Goroutines and CPU usage are attributed to components by the pprof
+//     labels set with Do.
+//   - Heap profiles don't record pprof labels, so the heap in use is
+//     attributed to the name of the component whose package is the closest
+//     to the allocation in the stack trace of the allocation.
+type Tracker struct {
+	opts       Options
+	packages   []componentPackage
+	nameGroups map[string]string // Names of the packages implementing each component.
+
+	mut        sync.RWMutex
+	goroutines map[string]int     // Goroutines by component ID.
+	cpuCores   map[string]float64 // CPU cores by component ID.
+	heapInuse  map[string]int64   // Heap in use by component name.
+	updateTime time.Time
+
+	goroutinesDesc *prometheus.Desc
+	cpuCoresDesc   *prometheus.Desc
+	heapInuseDesc  *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Tracker)(nil)
+
+// NewTracker creates a new Tracker. Call Run to start sampling.
+func NewTracker(opts Options) *Tracker {
+	if opts.Logger == nil {
+		opts.Logger = log.NewNopLogger()
+	}
+
+	packages := componentPackages()
+	nameGroups := make(map[string]string)
+	for _, p := range packages {
+		for _, name := range strings.Split(p.name, ",") {
+			nameGroups[name] = p.name
+		}
+	}
+
+	return &Tracker{
+		opts:       opts,
+		packages:   packages,
+		nameGroups: nameGroups,
+
+		goroutinesDesc: prometheus.NewDesc(
+			"alloy_component_goroutines",
+			"Number of goroutines running the component.",
+			[]string{"component_path", "component_id"}, nil,
+		),
+		cpuCoresDesc: prometheus.NewDesc(
+			"alloy_component_cpu_cores",
+			"Approximate number of CPU cores used by the component, sampled from a CPU profile.",
+			[]string{"component_path", "component_id"}, nil,
+		),
+		heapInuseDesc: prometheus.NewDesc(
+			"alloy_component_heap_inuse_bytes",
+			"Approximate size of the heap in use allocated by the code of all the components with the same name, sampled from a heap profile.",
+			[]string{"component_name"}, nil,
+		),
+	}
+}
+
+// Run samples the resource usage until ctx is canceled.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.sample(ctx); err != nil {
+			level.Warn(t.opts.Logger).Log("msg", "failed to sample the resource usage of components", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Usage returns the last sampled resource usage of the component with the
+// given global ID and name.
+func (t *Tracker) Usage(componentID, componentName string) *component.ResourceUsage {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+
+	if t.updateTime.IsZero() {
+		return nil
+	}
+	return &component.ResourceUsage{
+		Goroutines:     t.goroutines[componentID],
+		CPUCores:       t.cpuCores[componentID],
+		HeapInuseBytes: t.heapInuse[t.nameGroups[componentName]],
+		UpdateTime:     t.updateTime,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.goroutinesDesc
+	ch <- t.cpuCoresDesc
+	ch <- t.heapInuseDesc
+}
+
+// Collect implements prometheus.Collector.
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+
+	for id, n := range t.goroutines {
+		parent, localID := splitComponentID(id)
+		ch <- prometheus.MustNewConstMetric(t.goroutinesDesc, prometheus.GaugeValue, float64(n), parent, localID)
+	}
+	for id, cores := range t.cpuCores {
+		parent, localID := splitComponentID(id)
+		ch <- prometheus.MustNewConstMetric(t.cpuCoresDesc, prometheus.GaugeValue, cores, parent, localID)
+	}
+	for name, bytes := range t.heapInuse {
+		ch <- prometheus.MustNewConstMetric(t.heapInuseDesc, prometheus.GaugeValue, float64(bytes), name)
+	}
+}
+
+// splitComponentID splits a global component ID into the path of its
+// controller and its local ID, like the labels of component metrics.
+func splitComponentID(id string) (string, string) {
+	parent, localID := path.Split(id)
+	return "/" + strings.TrimSuffix(parent, "/"), localID
+}
+
+func (t *Tracker) sample(ctx context.Context) error {
+	goroutines, err := sampleGoroutines()
+	if err != nil {
+		return fmt.Errorf("sampling goroutines: %w", err)
+	}
+	heapInuse, err := sampleHeap(t.packages)
+	if err != nil {
+		return fmt.Errorf("sampling heap: %w", err)
+	}
+
+	t.mut.Lock()
+	t.goroutines, t.heapInuse = goroutines, heapInuse
+	t.updateTime = time.Now()
+	t.mut.Unlock()
+
+	if t.opts.CPUProfileDuration <= 0 {
+		return nil
+	}
+	cpuCores, err := sampleCPU(ctx, t.opts.CPUProfileDuration)
+	if err != nil {
+		return fmt.Errorf("sampling CPU: %w", err)
+	}
+
+	t.mut.Lock()
+	t.cpuCores = cpuCores
+	t.mut.Unlock()
+	return nil
+}
+
+func sampleGoroutines() (map[string]int, error) {
+	p, err := lookupProfile("goroutine")
+	if err != nil {
+		return nil, err
+	}
+
+	goroutines := make(map[string]int)
+	for _, s := range p.Sample {
+		if id := sampleLabel(s, LabelComponentID); id != "" {
+			goroutines[id] += int(s.Value[0])
+		}
+	}
+	return goroutines, nil
+}
+
+func sampleHeap(packages []componentPackage) (map[string]int64, error) {
+	p, err := lookupProfile("heap")
+	if err != nil {
+		return nil, err
+	}
+	inuseIndex := sampleTypeIndex(p, "inuse_space")
+	if inuseIndex < 0 {
+		return nil, fmt.Errorf("the heap profile has no inuse_space samples")
+	}
+
+	var (
+		heapInuse = make(map[string]int64)
+		// Functions appear in many stack traces, so their component is cached.
+		functionComponents = make(map[*profile.Function]string)
+	)
+	for _, s := range p.Sample {
+		if name := allocatingComponent(s, packages, functionComponents); name != "" {
+			heapInuse[name] += s.Value[inuseIndex]
+		}
+	}
+	return heapInuse, nil
+}
+
+func sampleCPU(ctx context.Context, duration time.Duration) (map[string]float64, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+	pprof.StopCPUProfile()
+	elapsed := time.Since(start)
+
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return nil, err
+	}
+	cpuIndex := sampleTypeIndex(p, "cpu")
+	if cpuIndex < 0 {
+		return nil, fmt.Errorf("the CPU profile has no cpu samples")
+	}
+
+	cpuCores := make(map[string]float64)
+	for _, s := range p.Sample {
+		if id := sampleLabel(s, LabelComponentID); id != "" {
+			cpuCores[id] += float64(s.Value[cpuIndex]) / float64(elapsed.Nanoseconds())
+		}
+	}
+	return cpuCores, nil
+}
+
+func lookupProfile(name string) (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return profile.Parse(&buf)
+}
+
+func sampleTypeIndex(p *profile.Profile, typ string) int {
+	return slices.IndexFunc(p.SampleType, func(st *profile.ValueType) bool { return st.Type == typ })
+}
+
+func sampleLabel(s *profile.Sample, key string) string {
+	if values := s.Label[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// componentPackage is the Go package implementing components.
+type componentPackage struct {
+	path string
+	// name of the component implemented by the package. Packages implementing
+	// several components have all the names, separated by commas.
+	name string
+}
+
+// componentPackages returns the packages of the registered components, from
+// the longest to the shortest path. The package of a component is the
+// package of its Build function.
+func componentPackages() []componentPackage {
+	names := make(map[string][]string)
+	for _, name := range component.AllNames() {
+		reg, ok := component.Get(name)
+		if !ok || reg.Build == nil {
+			continue
+		}
+		fn := runtime.FuncForPC(reflect.ValueOf(reg.Build).Pointer())
+		if fn == nil {
+			continue
+		}
+		pkg := funcPackage(fn.Name())
+		names[pkg] = append(names[pkg], name)
+	}
+
+	packages := make([]componentPackage, 0, len(names))
+	for pkg, pkgNames := range names {
+		slices.Sort(pkgNames)
+		packages = append(packages, componentPackage{path: pkg, name: strings.Join(pkgNames, ",")})
+	}
+	slices.SortFunc(packages, func(a, b componentPackage) int {
+		return len(b.path) - len(a.path)
+	})
+	return packages
+}
+
+// allocatingComponent returns the name of the component whose package is
+// the closest to the allocation in the stack trace of s. Subpackages of a
+// component package are part of the component.
+func allocatingComponent(s *profile.Sample, packages []componentPackage, cache map[*profile.Function]string) string {
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			name, ok := cache[line.Function]
+			if !ok {
+				name = functionComponent(line.Function.Name, packages)
+				cache[line.Function] = name
+			}
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// functionComponent returns the name of the component implemented by the
+// package of the function, if any.
+func functionComponent(function string, packages []componentPackage) string {
+	pkg := funcPackage(function)
+	for _, p := range packages {
+		if pkg == p.path || strings.HasPrefix(pkg, p.path+"/") {
+			return p.name
+		}
+	}
+	return ""
+}
+
+// funcPackage returns the package of a function from its fully qualified
+// name, such as github.com/grafana/alloy/internal/component/loki/process.New.
+func funcPackage(name string) string {
+	lastSlash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[lastSlash+1:], '.'); dot >= 0 {
+		return name[:lastSlash+1+dot]
+	}
+	return name
+}
@@ -0,0 +1,87 @@
+package resources
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	Do(t.Context(), "module.file.default/loki.process.default", "loki.process", func(ctx context.Context) {
+		id, _ := pprof.Label(ctx, LabelComponentID)
+		require.Equal(t, "module.file.default/loki.process.default", id)
+		name, _ := pprof.Label(ctx, LabelComponentName)
+		require.Equal(t, "loki.process", name)
+	})
+}
+
+func TestTracker_Goroutines(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	Do(t.Context(), "module.file.default/test.busy.a", "test.busy", func(context.Context) {
+		for range 3 {
+			go func() { <-stop }()
+		}
+	})
+
+	tracker := NewTracker(Options{Interval: time.Minute})
+	require.Nil(t, tracker.Usage("module.file.default/test.busy.a", "test.busy"))
+	require.NoError(t, tracker.sample(t.Context()))
+
+	usage := tracker.Usage("module.file.default/test.busy.a", "test.busy")
+	require.NotNil(t, usage)
+	require.Equal(t, 3, usage.Goroutines)
+	require.Zero(t, tracker.Usage("test.busy.other", "test.busy").Goroutines)
+}
+
+func TestTracker_CPU(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	Do(ctx, "test.busy.a", "test.busy", func(ctx context.Context) {
+		go func() {
+			for ctx.Err() == nil {
+			}
+		}()
+	})
+
+	cpuCores, err := sampleCPU(t.Context(), 500*time.Millisecond)
+	require.NoError(t, err)
+	require.Greater(t, cpuCores["test.busy.a"], 0.0)
+}
+
+func TestSplitComponentID(t *testing.T) {
+	parent, id := splitComponentID("loki.process.default")
+	require.Equal(t, "/", parent)
+	require.Equal(t, "loki.process.default", id)
+
+	parent, id = splitComponentID("import.git.logs/logs.pipeline.default/loki.process.default")
+	require.Equal(t, "/import.git.logs/logs.pipeline.default", parent)
+	require.Equal(t, "loki.process.default", id)
+}
+
+func TestFunctionComponent(t *testing.T) {
+	packages := []componentPackage{
+		{path: "github.com/grafana/alloy/internal/component/prometheus/exporter/unix", name: "prometheus.exporter.unix"},
+		{path: "github.com/grafana/alloy/internal/component/loki/process", name: "loki.process"},
+		{path: "github.com/grafana/alloy/internal/component/prometheus/exporter", name: "prometheus.exporter.self"},
+	}
+
+	tt := map[string]string{
+		"github.com/grafana/alloy/internal/component/loki/process.(*Component).Run":             "loki.process",
+		"github.com/grafana/alloy/internal/component/loki/process/stages.(*regexStage).Process": "loki.process",
+		"github.com/grafana/alloy/internal/component/prometheus/exporter/unix.init.0.func1":     "prometheus.exporter.unix",
+		"github.com/grafana/alloy/internal/component/prometheus/exporter.New":                   "prometheus.exporter.self",
+		"github.com/grafana/alloy/internal/component/loki/processor.New":                        "",
+		"github.com/prometheus/prometheus/model/labels.(*Builder).Labels":                       "",
+		"runtime.malg": "",
+		"github.com/grafana/alloy/internal/component/loki/process.New[go.shape.int].func1.gowrap1": "loki.process",
+	}
+	for function, expected := range tt {
+		require.Equal(t, expected, functionComponent(function, packages), function)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/nodeconf/export"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/internal/static/config/encoder"
 	"github.com/grafana/alloy/syntax/ast"
@@ -23,9 +24,10 @@ type Source struct {
 
 	// Components holds the list of raw Alloy AST blocks describing components.
 	// The Alloy controller can interpret them.
-	components    []*ast.BlockStmt
-	configBlocks  []*ast.BlockStmt
-	declareBlocks []*ast.BlockStmt
+	components     []*ast.BlockStmt
+	configBlocks   []*ast.BlockStmt
+	declareBlocks  []*ast.BlockStmt
+	functionBlocks []*ast.BlockStmt
 }
 
 // ParseSource parses the Alloy file specified by bb into a File. name should be
@@ -62,6 +64,7 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 		components []*ast.BlockStmt
 		configs    []*ast.BlockStmt
 		declares   []*ast.BlockStmt
+		functions  []*ast.BlockStmt
 	)
 
 	for _, stmt := range body {
@@ -79,8 +82,11 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 			switch fullName {
 			case "declare":
 				declares = append(declares, stmt)
+			case function.BlockName:
+				functions = append(functions, stmt)
 			case "logging", "tracing", argument.BlockName, export.BlockName, foreach.BlockName,
-				importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+				importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+				importsource.BlockNameS3, importsource.BlockNameOCI:
 				configs = append(configs, stmt)
 			default:
 				components = append(components, stmt)
@@ -97,9 +103,10 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 	}
 
 	return &Source{
-		components:    components,
-		configBlocks:  configs,
-		declareBlocks: declares,
+		components:     components,
+		configBlocks:   configs,
+		declareBlocks:  declares,
+		functionBlocks: functions,
 	}, nil
 }
 
@@ -152,6 +159,7 @@ func ParseSources(sources map[string][]byte) (*Source, error) {
 		mergedSource.components = append(mergedSource.components, sourceFragment.components...)
 		mergedSource.configBlocks = append(mergedSource.configBlocks, sourceFragment.configBlocks...)
 		mergedSource.declareBlocks = append(mergedSource.declareBlocks, sourceFragment.declareBlocks...)
+		mergedSource.functionBlocks = append(mergedSource.functionBlocks, sourceFragment.functionBlocks...)
 	}
 
 	if len(mergedDiags) > 0 {
@@ -190,3 +198,7 @@ func (s *Source) Configs() []*ast.BlockStmt {
 func (s *Source) Declares() []*ast.BlockStmt {
 	return s.declareBlocks
 }
+
+func (s *Source) Functions() []*ast.BlockStmt {
+	return s.functionBlocks
+}
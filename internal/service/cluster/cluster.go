@@ -3,11 +3,14 @@
 package cluster
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/http"
@@ -61,6 +64,40 @@ const (
 	// stateUpdateMinInterval is the minimum time interval between propagating peer changes to Alloy components.
 	// This allows to rate limit the number of updates when the cluster is frequently changing (e.g. during rollout).
 	stateUpdateMinInterval = time.Second
+
+	// nodeInfoPath is the HTTP path serving the NodeInfo of the local node to
+	// its peers.
+	nodeInfoPath = "/api/v1/ckit/node"
+
+	// nodeInfoRetryInterval is how often to retry fetching the NodeInfo of
+	// peers which couldn't be fetched.
+	nodeInfoRetryInterval = 10 * time.Second
+
+	// kvPath is the HTTP path used by peers to exchange the content of their
+	// KV store.
+	kvPath = "/api/v1/ckit/kv"
+
+	// kvContentType is the content type of the requests exchanging the content
+	// of the KV store. Like the other ckit routes, the KV route only accepts
+	// HTTP/2 requests with its own content type.
+	kvContentType = "application/x.alloy.kv+json"
+
+	// maxKVSyncSize is the maximum size of the body of the requests and
+	// responses exchanging the content of the KV store, which leaves room for
+	// the JSON encoding of a full store.
+	maxKVSyncSize = 4 * maxKVSize
+
+	// kvSyncInterval is how often to exchange the content of the KV store with
+	// random peers.
+	kvSyncInterval = 5 * time.Second
+
+	// kvSyncPeers is the number of random peers the content of the KV store is
+	// exchanged with at every sync.
+	kvSyncPeers = 3
+
+	// kvTombstoneTTL is how long deleted keys of the KV store are remembered,
+	// so that the deletion propagates to every peer.
+	kvTombstoneTTL = time.Hour
 )
 
 // Options are used to configure the cluster service. Options are constant for
@@ -88,6 +125,15 @@ type Options struct {
 	MinimumClusterSize     int           // Minimum cluster size before admitting traffic to components that use clustering.
 	MinimumSizeWaitTimeout time.Duration // Maximum duration to wait for minimum cluster size before proceeding; 0 means no timeout.
 
+	NodeWeight   int               // Share of work assigned to this node relative to other nodes. Defaults to 1.
+	NodeTopology map[string]string // Labels describing where this node runs, such as its zone.
+
+	// TopologyAwareLabel, when set, is the name of a topology label of the
+	// nodes. Targets with a label of the same name are assigned to the nodes
+	// whose topology label has the same value, if any. All nodes must use the
+	// same value.
+	TopologyAwareLabel string
+
 	// Function to discover peers to join. If this function is nil or returns an
 	// empty slice, no peers will be joined.
 	DiscoverPeers discovery.DiscoverFn
@@ -99,9 +145,20 @@ type Service struct {
 	tracer trace.TracerProvider
 	opts   Options
 
-	sharder shard.Sharder
-	node    *ckit.Node
-	randGen *rand.Rand
+	sharder    shard.Sharder
+	topology   *topologySharder
+	node       *ckit.Node
+	randGen    *rand.Rand
+	httpClient *http.Client
+
+	// notifyPeersChange is used to signal that the NodeInfo of new peers
+	// needs to be fetched.
+	notifyPeersChange chan struct{}
+
+	// kv is the KV store replicated across the cluster, and notifyKVChange is
+	// used to signal that it was written to locally.
+	kv             *KV
+	notifyKVChange chan struct{}
 
 	// alloyCluster is given to components via calls to Data() and implements Cluster.
 	alloyCluster *alloyCluster
@@ -130,6 +187,7 @@ type ComponentBlock struct {
 
 var (
 	_ service.Service            = (*Service)(nil)
+	_ service.Drainer            = (*Service)(nil)
 	_ httpservice.ServiceHandler = (*Service)(nil)
 )
 
@@ -150,7 +208,7 @@ func New(opts Options) (*Service, error) {
 		Name:          opts.NodeName,
 		AdvertiseAddr: opts.AdvertiseAddress,
 		Log:           l,
-		Sharder:       shard.Ring(tokensPerNode),
+		Sharder:       newTopologySharder(tokensPerNode, opts.TopologyAwareLabel),
 		Label:         opts.ClusterName,
 		EnableTLS:     opts.EnableTLS,
 	}
@@ -199,11 +257,18 @@ func New(opts Options) (*Service, error) {
 		opts:   opts,
 
 		sharder:             ckitConfig.Sharder,
+		topology:            ckitConfig.Sharder.(*topologySharder),
 		node:                node,
 		randGen:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		httpClient:          httpClient,
 		notifyClusterChange: make(chan struct{}, 1),
+		notifyPeersChange:   make(chan struct{}, 1),
+		notifyKVChange:      make(chan struct{}, 1),
 	}
+	s.topology.SetNodeInfo(opts.NodeName, s.localNodeInfo())
+	s.kv = newKV(opts.NodeName, s.triggerKVChangeNotification)
 	s.alloyCluster = newAlloyCluster(ckitConfig.Sharder, s.triggerClusterChangeNotification, opts, l)
+	s.alloyCluster.kv = s.kv
 
 	return s, nil
 }
@@ -266,7 +331,16 @@ func (s *Service) Definition() service.Definition {
 // ServiceHandler returns the service handler for the clustering service. The
 // resulting handler always returns 404 when clustering is disabled.
 func (s *Service) ServiceHandler(_ service.Host) (base string, handler http.Handler) {
-	base, handler = s.node.Handler()
+	transportBase, transportHandler := s.node.Handler()
+
+	mux := http.NewServeMux()
+	mux.Handle(transportBase, transportHandler)
+	mux.HandleFunc(nodeInfoPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.localNodeInfo())
+	})
+	mux.HandleFunc(kvPath, s.handleKVSync)
+	handler = mux
 
 	if !s.opts.EnableClustering {
 		handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -274,7 +348,7 @@ func (s *Service) ServiceHandler(_ service.Host) (base string, handler http.Hand
 		})
 	}
 
-	return base, handler
+	return "/api/v1/ckit/", handler
 }
 
 // ChangeState changes the state of the service. If clustering is enabled,
@@ -287,6 +361,16 @@ func (s *Service) ChangeState(ctx context.Context, targetState peer.State) error
 	return s.node.ChangeState(ctx, targetState)
 }
 
+// Drain implements [service.Drainer]. The node moves to the Terminating state
+// so that the other nodes of the cluster take over its share of the work
+// while its components are drained.
+func (s *Service) Drain(ctx context.Context) error {
+	if s.node.CurrentState() != peer.StateParticipant {
+		return nil
+	}
+	return s.node.ChangeState(ctx, peer.StateTerminating)
+}
+
 // Run starts the cluster service. It will run until the provided context is
 // canceled or there is a fatal error.
 func (s *Service) Run(ctx context.Context, host service.Host) error {
@@ -305,6 +389,7 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 			return false
 		}
 		s.triggerClusterChangeNotification()
+		s.triggerPeersChangeNotification()
 		return true
 	}))
 
@@ -352,6 +437,49 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}
 	}()
 
+	if s.opts.EnableClustering {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			t := time.NewTicker(nodeInfoRetryInterval)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.notifyPeersChange:
+				case <-t.C:
+				}
+				if s.fetchNodeInfos(ctx) {
+					s.triggerClusterChangeNotification()
+				}
+			}
+		}()
+	}
+
+	if s.opts.EnableClustering {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			t := time.NewTicker(kvSyncInterval)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.notifyKVChange:
+				case <-t.C:
+					s.kv.removeTombstones(time.Now().Add(-kvTombstoneTTL))
+				}
+				s.syncKV(ctx)
+			}
+		}()
+	}
+
 	if s.opts.EnableClustering && s.opts.RejoinInterval > 0 {
 		wg.Add(1)
 
@@ -445,6 +573,173 @@ func (s *Service) triggerClusterChangeNotification() {
 	}
 }
 
+func (s *Service) triggerPeersChangeNotification() {
+	select {
+	case s.notifyPeersChange <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) triggerKVChangeNotification() {
+	select {
+	case s.notifyKVChange <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) localNodeInfo() NodeInfo {
+	return NodeInfo{
+		Weight:   max(s.opts.NodeWeight, 1),
+		Topology: s.opts.NodeTopology,
+	}
+}
+
+// fetchNodeInfos fetches the NodeInfo of the peers for which it isn't known
+// yet, and returns true if any was fetched.
+func (s *Service) fetchNodeInfos(ctx context.Context) bool {
+	var fetched bool
+	for _, p := range s.topology.Peers() {
+		if s.topology.HasNodeInfo(p.Name) {
+			continue
+		}
+		if p.Self {
+			s.topology.SetNodeInfo(p.Name, s.localNodeInfo())
+			fetched = true
+			continue
+		}
+
+		info, err := s.fetchNodeInfo(ctx, p)
+		if err != nil {
+			level.Warn(s.log).Log("msg", "failed to fetch node info of peer; will retry", "peer", p.Name, "err", err)
+			continue
+		}
+		level.Debug(s.log).Log("msg", "fetched node info of peer", "peer", p.Name, "weight", info.Weight)
+		s.topology.SetNodeInfo(p.Name, info)
+		fetched = true
+	}
+	return fetched
+}
+
+func (s *Service) fetchNodeInfo(ctx context.Context, p peer.Peer) (NodeInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	scheme := "http"
+	if s.opts.EnableTLS {
+		scheme = "https"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+p.Addr+nodeInfoPath, nil)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// The peer runs a version of Alloy which doesn't serve its NodeInfo.
+		return NodeInfo{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return NodeInfo{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var info NodeInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return NodeInfo{}, fmt.Errorf("decoding node info: %w", err)
+	}
+	return info, nil
+}
+
+// handleKVSync merges the content of the KV store of a peer, and responds
+// with the content of the local KV store.
+func (s *Service) handleKVSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.ProtoMajor != 2 {
+		w.WriteHeader(http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Header.Get("Content-Type") != kvContentType {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var entries map[string]kvEntry
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxKVSyncSize)).Decode(&entries); err != nil {
+		http.Error(w, fmt.Sprintf("decoding KV entries: %s", err), http.StatusBadRequest)
+		return
+	}
+	s.kv.merge(entries)
+
+	w.Header().Set("Content-Type", kvContentType)
+	_ = json.NewEncoder(w).Encode(s.kv.snapshot())
+}
+
+// syncKV exchanges the content of the KV store with random peers.
+func (s *Service) syncKV(ctx context.Context) {
+	var peers []peer.Peer
+	for _, p := range s.node.Peers() {
+		if !p.Self {
+			peers = append(peers, p)
+		}
+	}
+	// s.randGen isn't safe for concurrent use, so the global source is used.
+	rand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+
+	for _, p := range peers[:min(len(peers), kvSyncPeers)] {
+		entries, err := s.exchangeKV(ctx, p)
+		if err != nil {
+			level.Debug(s.log).Log("msg", "failed to sync KV store with peer", "peer", p.Name, "err", err)
+			continue
+		}
+		s.kv.merge(entries)
+	}
+}
+
+func (s *Service) exchangeKV(ctx context.Context, p peer.Peer) (map[string]kvEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(s.kv.snapshot())
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if s.opts.EnableTLS {
+		scheme = "https"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, scheme+"://"+p.Addr+kvPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", kvContentType)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// The peer runs a version of Alloy without a KV store.
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var entries map[string]kvEntry
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxKVSyncSize)).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding KV entries: %w", err)
+	}
+	return entries, nil
+}
+
 func (s *Service) getRandomPeers() ([]string, error) {
 	if !s.opts.EnableClustering || s.opts.DiscoverPeers == nil {
 		return nil, nil
@@ -481,12 +776,12 @@ func (s *Service) stop() {
 	defer cancel()
 
 	// The node is going away. We move to the Terminating state to signal
-	// that we should not be owners for write hashing operations anymore.
-	//
-	// TODO(rfratto): should we enter terminating state earlier to allow for
-	// some kind of hand-off between components?
-	if err := s.node.ChangeState(ctx, peer.StateTerminating); err != nil {
-		level.Error(s.log).Log("msg", "failed to change state to Terminating", "err", err)
+	// that we should not be owners for write hashing operations anymore,
+	// unless Drain already did it.
+	if s.node.CurrentState() != peer.StateTerminating {
+		if err := s.node.ChangeState(ctx, peer.StateTerminating); err != nil {
+			level.Error(s.log).Log("msg", "failed to change state to Terminating", "err", err)
+		}
 	}
 
 	if err := s.node.Stop(); err != nil {
@@ -66,9 +66,18 @@ type alloyCluster struct {
 	rwMutex       sync.RWMutex
 	deadlineTimer *time.Timer
 	clusterState  clusterState
+
+	// singletonSet tracks the components electing an owner with Singleton.
+	singletonSet
+
+	// kv is the KV store replicated across the cluster.
+	kv *KV
 }
 
-var _ Cluster = (*alloyCluster)(nil)
+var (
+	_ Cluster           = (*alloyCluster)(nil)
+	_ singletonRegistry = (*alloyCluster)(nil)
+)
 
 func newAlloyCluster(sharder shard.Sharder, clusterChangeCallback func(), opts Options, log log.Logger) *alloyCluster {
 	c := &alloyCluster{
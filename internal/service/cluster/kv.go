@@ -0,0 +1,284 @@
+package cluster
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxKVKeySize is the maximum size of a key of the KV store.
+	maxKVKeySize = 256
+
+	// maxKVValueSize is the maximum size of a value of the KV store. The KV
+	// store is intended for small pieces of state, such as cursors, and its
+	// whole content is exchanged between peers.
+	maxKVValueSize = 64 << 10
+
+	// maxKVEntries is the maximum number of entries of the KV store, including
+	// tombstones.
+	maxKVEntries = 4096
+
+	// maxKVSize is the maximum total size of the keys and values of the KV
+	// store.
+	maxKVSize = 4 << 20
+
+	// maxKVClockSkew is how far in the future the entries of peers can be
+	// written. Entries from further in the future are ignored until the local
+	// clock catches up, so that a peer with a wrong clock can't make its
+	// writes win for a long time.
+	maxKVClockSkew = time.Minute
+)
+
+// KV is a key-value store replicated to every node of the cluster.
+//
+// Nodes periodically exchange the content of their store with random peers,
+// so writes eventually propagate to the whole cluster. When the same key is
+// written by several nodes, the most recent write wins.
+//
+// The content of the store is only held in memory: it survives nodes leaving
+// the cluster as long as another node holds it, but not a restart of the
+// whole cluster.
+//
+// Components writing to the KV store should prefix their keys with their ID
+// to avoid collisions with other components.
+type KV struct {
+	node     string
+	now      func() time.Time
+	onChange func() // Called after local writes.
+
+	mut     sync.RWMutex
+	entries map[string]kvEntry
+	size    int           // Total size of the keys and values of entries.
+	changed chan struct{} // Closed and replaced when entries change.
+}
+
+// kvEntry is a versioned value of the KV store. Deleted keys are kept as
+// tombstones so that deletions propagate to peers.
+type kvEntry struct {
+	Value     string `json:"value,omitempty"`
+	Timestamp int64  `json:"timestamp"` // Unix time of the write in nanoseconds.
+	Node      string `json:"node"`      // Name of the node which wrote the entry.
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+// size returns the size accounted for the entry of key.
+func (e kvEntry) size(key string) int {
+	return len(key) + len(e.Value)
+}
+
+// newerThan returns true if e was written after other. Writes with the same
+// timestamp are ordered by node name so that every node agrees on the winner.
+func (e kvEntry) newerThan(other kvEntry) bool {
+	if e.Timestamp != other.Timestamp {
+		return e.Timestamp > other.Timestamp
+	}
+	return e.Node > other.Node
+}
+
+func newKV(node string, onChange func()) *KV {
+	return &KV{
+		node:     node,
+		now:      time.Now,
+		onChange: onChange,
+		entries:  make(map[string]kvEntry),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Get returns the value of key. ok is false if key isn't set.
+func (kv *KV) Get(key string) (value string, ok bool) {
+	kv.mut.RLock()
+	defer kv.mut.RUnlock()
+
+	e, ok := kv.entries[key]
+	if !ok || e.Deleted {
+		return "", false
+	}
+	return e.Value, true
+}
+
+// List returns the keys starting with prefix and their values.
+func (kv *KV) List(prefix string) map[string]string {
+	kv.mut.RLock()
+	defer kv.mut.RUnlock()
+
+	res := make(map[string]string)
+	for key, e := range kv.entries {
+		if e.Deleted || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		res[key] = e.Value
+	}
+	return res
+}
+
+// Set sets the value of key.
+func (kv *KV) Set(key, value string) error {
+	if err := validateKVKey(key); err != nil {
+		return err
+	}
+	if len(value) > maxKVValueSize {
+		return fmt.Errorf("value of key %q is %d bytes, which exceeds the maximum of %d bytes", key, len(value), maxKVValueSize)
+	}
+	return kv.write(key, kvEntry{Value: value})
+}
+
+// Delete deletes key. Deleting a key which isn't set is a no-op.
+func (kv *KV) Delete(key string) {
+	if _, ok := kv.Get(key); !ok {
+		return
+	}
+	// A tombstone replacing an existing entry always fits in the store.
+	_ = kv.write(key, kvEntry{Deleted: true})
+}
+
+// Changed returns a channel which is closed the next time the content of the
+// store changes, either from a local write or from a peer.
+func (kv *KV) Changed() <-chan struct{} {
+	kv.mut.RLock()
+	defer kv.mut.RUnlock()
+	return kv.changed
+}
+
+func validateKVKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	if len(key) > maxKVKeySize {
+		return fmt.Errorf("key %q exceeds the maximum size of %d bytes", key, maxKVKeySize)
+	}
+	return nil
+}
+
+// write stores e as the new entry of key, written by the local node.
+func (kv *KV) write(key string, e kvEntry) error {
+	kv.mut.Lock()
+	if err := kv.checkCapacity(key, e); err != nil {
+		kv.mut.Unlock()
+		return err
+	}
+	e.Node = kv.node
+	e.Timestamp = kv.now().UnixNano()
+	if prev, ok := kv.entries[key]; ok && prev.Timestamp >= e.Timestamp {
+		// Make sure the local write wins over the previous one, even if the
+		// clock of the node which wrote it is ahead.
+		e.Timestamp = prev.Timestamp + 1
+	}
+	kv.put(key, e)
+	kv.notifyChanged()
+	kv.mut.Unlock()
+
+	if kv.onChange != nil {
+		kv.onChange()
+	}
+	return nil
+}
+
+// checkCapacity returns an error if storing e as the entry of key would
+// exceed the maximum number of entries or the maximum size of the store.
+// kv.mut must be held.
+func (kv *KV) checkCapacity(key string, e kvEntry) error {
+	prev, exists := kv.entries[key]
+	if !exists && len(kv.entries) >= maxKVEntries {
+		return fmt.Errorf("the KV store is full: it holds the maximum of %d entries", maxKVEntries)
+	}
+	size := kv.size + e.size(key)
+	if exists {
+		size -= prev.size(key)
+	}
+	if size > maxKVSize {
+		return fmt.Errorf("writing key %q would exceed the maximum size of the KV store of %d bytes", key, maxKVSize)
+	}
+	return nil
+}
+
+// put stores e as the entry of key. kv.mut must be held for writing.
+func (kv *KV) put(key string, e kvEntry) {
+	if prev, ok := kv.entries[key]; ok {
+		kv.size -= prev.size(key)
+	}
+	kv.entries[key] = e
+	kv.size += e.size(key)
+}
+
+// snapshot returns a copy of all the entries, including tombstones.
+func (kv *KV) snapshot() map[string]kvEntry {
+	kv.mut.RLock()
+	defer kv.mut.RUnlock()
+	return maps.Clone(kv.entries)
+}
+
+// merge merges the entries of a peer, keeping the most recent entry of each
+// key. Invalid entries, entries written too far in the future and entries
+// which don't fit in the store are ignored. merge returns true if any entry
+// changed.
+func (kv *KV) merge(entries map[string]kvEntry) bool {
+	kv.mut.Lock()
+	defer kv.mut.Unlock()
+
+	maxTimestamp := kv.now().Add(maxKVClockSkew).UnixNano()
+
+	var changed bool
+	for key, e := range entries {
+		if validateKVKey(key) != nil || len(e.Value) > maxKVValueSize || e.Timestamp > maxTimestamp {
+			continue
+		}
+		if prev, ok := kv.entries[key]; ok && !e.newerThan(prev) {
+			continue
+		}
+		if kv.checkCapacity(key, e) != nil {
+			continue
+		}
+		kv.put(key, e)
+		changed = true
+	}
+	if changed {
+		kv.notifyChanged()
+	}
+	return changed
+}
+
+// removeTombstones forgets the keys deleted before the given time.
+func (kv *KV) removeTombstones(before time.Time) {
+	kv.mut.Lock()
+	defer kv.mut.Unlock()
+
+	maps.DeleteFunc(kv.entries, func(key string, e kvEntry) bool {
+		if e.Deleted && e.Timestamp < before.UnixNano() {
+			kv.size -= e.size(key)
+			return true
+		}
+		return false
+	})
+}
+
+// notifyChanged wakes up the callers waiting on Changed. kv.mut must be held
+// for writing.
+func (kv *KV) notifyChanged() {
+	close(kv.changed)
+	kv.changed = make(chan struct{})
+}
+
+// kvCluster is implemented by clusters providing a KV store.
+type kvCluster interface {
+	kvStore() *KV
+}
+
+var _ kvCluster = (*alloyCluster)(nil)
+
+func (c *alloyCluster) kvStore() *KV {
+	return c.kv
+}
+
+// KVStore returns the KV store replicated across the nodes of c. ok is false
+// if c doesn't provide a KV store.
+func KVStore(c Cluster) (kv *KV, ok bool) {
+	kc, ok := c.(kvCluster)
+	if !ok || kc.kvStore() == nil {
+		return nil, false
+	}
+	return kc.kvStore(), true
+}
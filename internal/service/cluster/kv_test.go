@@ -0,0 +1,167 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKV(t *testing.T) {
+	var writes int
+	kv := newKV("a", func() { writes++ })
+
+	changed := kv.Changed()
+	require.NoError(t, kv.Set("loki.source.cloudflare.default/cursor", "10"))
+	require.NoError(t, kv.Set("loki.source.cloudflare.other/cursor", "20"))
+	require.Equal(t, 2, writes)
+
+	select {
+	case <-changed:
+	default:
+		require.FailNow(t, "expected Changed to be closed after a write")
+	}
+
+	value, ok := kv.Get("loki.source.cloudflare.default/cursor")
+	require.True(t, ok)
+	require.Equal(t, "10", value)
+
+	require.Equal(t, map[string]string{
+		"loki.source.cloudflare.default/cursor": "10",
+		"loki.source.cloudflare.other/cursor":   "20",
+	}, kv.List("loki.source.cloudflare."))
+	require.Equal(t, map[string]string{
+		"loki.source.cloudflare.other/cursor": "20",
+	}, kv.List("loki.source.cloudflare.other/"))
+
+	kv.Delete("loki.source.cloudflare.default/cursor")
+	_, ok = kv.Get("loki.source.cloudflare.default/cursor")
+	require.False(t, ok)
+	require.Len(t, kv.List(""), 1)
+
+	// Deleting a key which isn't set isn't a write.
+	kv.Delete("missing")
+	require.Equal(t, 3, writes)
+
+	require.Error(t, kv.Set("", "value"))
+	require.Error(t, kv.Set(strings.Repeat("k", maxKVKeySize+1), "value"))
+	require.Error(t, kv.Set("key", strings.Repeat("v", maxKVValueSize+1)))
+}
+
+func TestKV_Limits(t *testing.T) {
+	kv := newKV("a", nil)
+	for i := range maxKVEntries {
+		require.NoError(t, kv.Set(fmt.Sprintf("key-%d", i), ""))
+	}
+	require.EqualError(t, kv.Set("other", ""), "the KV store is full: it holds the maximum of 4096 entries")
+	// Existing keys can still be written to.
+	require.NoError(t, kv.Set("key-0", "value"))
+
+	kv = newKV("a", nil)
+	value := strings.Repeat("v", maxKVValueSize)
+	for i := range maxKVSize / maxKVValueSize {
+		require.NoError(t, kv.Set(strconv.Itoa(i), value[:maxKVValueSize-len(strconv.Itoa(i))]))
+	}
+	require.EqualError(t, kv.Set("other", "v"), `writing key "other" would exceed the maximum size of the KV store of 4194304 bytes`)
+
+	// Entries of peers which don't fit are ignored.
+	require.False(t, kv.merge(map[string]kvEntry{
+		"other": {Value: "v", Timestamp: time.Now().UnixNano(), Node: "b"},
+	}))
+
+	// Deleting entries frees space.
+	kv.Delete("0")
+	require.NoError(t, kv.Set("other", "v"))
+}
+
+func TestKV_Merge(t *testing.T) {
+	var (
+		now = time.Now()
+		a   = newKV("a", nil)
+		b   = newKV("b", nil)
+	)
+	a.now = func() time.Time { return now }
+	b.now = func() time.Time { return now }
+
+	// Concurrent writes with the same timestamp are resolved the same way on
+	// every node.
+	require.NoError(t, a.Set("key", "from-a"))
+	require.NoError(t, b.Set("key", "from-b"))
+	require.True(t, a.merge(b.snapshot()))
+	require.False(t, b.merge(a.snapshot()))
+	for _, kv := range []*KV{a, b} {
+		value, _ := kv.Get("key")
+		require.Equal(t, "from-b", value)
+	}
+
+	// A local write wins over the entry of a node whose clock is ahead.
+	b.now = func() time.Time { return now.Add(maxKVClockSkew / 2) }
+	require.NoError(t, b.Set("key", "from-b-later"))
+	require.True(t, a.merge(b.snapshot()))
+	require.NoError(t, a.Set("key", "from-a-later"))
+	require.True(t, b.merge(a.snapshot()))
+	value, _ := b.Get("key")
+	require.Equal(t, "from-a-later", value)
+
+	// Entries written too far in the future are ignored until the local clock
+	// catches up.
+	b.now = func() time.Time { return now.Add(time.Hour) }
+	require.NoError(t, b.Set("key", "from-b-future"))
+	require.False(t, a.merge(b.snapshot()))
+	value, _ = a.Get("key")
+	require.Equal(t, "from-a-later", value)
+	a.now = func() time.Time { return now.Add(time.Hour) }
+	require.True(t, a.merge(b.snapshot()))
+	value, _ = a.Get("key")
+	require.Equal(t, "from-b-future", value)
+
+	// Deletions propagate as tombstones, which are eventually removed.
+	b.Delete("key")
+	require.True(t, a.merge(b.snapshot()))
+	_, ok := a.Get("key")
+	require.False(t, ok)
+
+	a.removeTombstones(now)
+	require.Contains(t, a.snapshot(), "key")
+	a.removeTombstones(now.Add(2 * time.Hour))
+	require.NotContains(t, a.snapshot(), "key")
+}
+
+func TestHandleKVSync(t *testing.T) {
+	s := &Service{kv: newKV("a", nil)}
+	require.NoError(t, s.kv.Set("key", "value"))
+
+	body := `{"other": {"value": "from-b", "timestamp": 1, "node": "b"}}`
+	newRequest := func(protoMajor int, contentType string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, kvPath, strings.NewReader(body))
+		r.ProtoMajor = protoMajor
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleKVSync(rec, newRequest(1, kvContentType))
+	require.Equal(t, http.StatusHTTPVersionNotSupported, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handleKVSync(rec, newRequest(2, "application/json"))
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	_, ok := s.kv.Get("other")
+	require.False(t, ok)
+
+	rec = httptest.NewRecorder()
+	s.handleKVSync(rec, newRequest(2, kvContentType))
+	require.Equal(t, http.StatusOK, rec.Code)
+	value, _ := s.kv.Get("other")
+	require.Equal(t, "from-b", value)
+
+	var entries map[string]kvEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	require.Equal(t, "value", entries["key"].Value)
+}
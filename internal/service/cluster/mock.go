@@ -8,10 +8,13 @@ import (
 	"github.com/grafana/ckit/shard"
 )
 
-// Mock returns a mock implementation of the Cluster interface.
-func Mock() Cluster { return mockCluster{} }
+// Mock returns a mock implementation of the Cluster interface. The KV store
+// of the mock isn't replicated.
+func Mock() Cluster { return mockCluster{kv: newKV("self", nil)} }
 
-type mockCluster struct{}
+type mockCluster struct {
+	kv *KV
+}
 
 func (mockCluster) Lookup(key shard.Key, replicationFactor int, op shard.Op) ([]peer.Peer, error) {
 	return []peer.Peer{{
@@ -42,3 +45,7 @@ func (mockCluster) Observe(ckit.Observer) {
 func (mockCluster) Handler() (string, http.Handler) {
 	return "/not/a/valid/path", http.NotFoundHandler()
 }
+
+func (c mockCluster) kvStore() *KV {
+	return c.kv
+}
@@ -0,0 +1,322 @@
+package cluster
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+)
+
+// NodeInfo describes the share of work a node of the cluster takes and where
+// it runs. Nodes learn the NodeInfo of their peers over HTTP.
+type NodeInfo struct {
+	// Weight is the share of work assigned to the node relative to other
+	// nodes. A node with a weight of 2 owns twice as many keys as a node with
+	// a weight of 1. Weights lower than 1 are treated as 1.
+	Weight int `json:"weight"`
+	// Topology holds labels describing where the node runs, such as its
+	// availability zone.
+	Topology map[string]string `json:"topology,omitempty"`
+}
+
+func (ni NodeInfo) weight() int {
+	return max(ni.Weight, 1)
+}
+
+// topologySharder implements shard.Sharder with a hash ring where nodes own a
+// number of tokens proportional to their weight.
+//
+// Tokens are generated the same way as shard.Ring, so nodes with a weight of
+// 1 own the same keys as with shard.Ring.
+//
+// When topologyLabel is set, topologySharder also keeps a hash ring of the
+// nodes sharing each value of the topology label, so that keys can be
+// assigned to nodes of a given topology with LookupTopology.
+type topologySharder struct {
+	numTokens     int
+	topologyLabel string
+
+	mut        sync.RWMutex
+	peers      []peer.Peer         // Peers from the last call to SetPeers.
+	infos      map[string]NodeInfo // NodeInfo by peer name.
+	all        ringPair
+	byTopology map[string]ringPair // Rings by value of the topology label.
+}
+
+var _ shard.Sharder = (*topologySharder)(nil)
+
+func newTopologySharder(numTokens int, topologyLabel string) *topologySharder {
+	return &topologySharder{
+		numTokens:     numTokens,
+		topologyLabel: topologyLabel,
+		infos:         make(map[string]NodeInfo),
+	}
+}
+
+// Lookup implements shard.Sharder.
+func (ts *topologySharder) Lookup(key shard.Key, numOwners int, op shard.Op) ([]peer.Peer, error) {
+	ts.mut.RLock()
+	defer ts.mut.RUnlock()
+	return ts.all.lookup(key, numOwners, op)
+}
+
+// LookupTopology is like Lookup, but only considers the peers whose topology
+// label has the given value. ok is false if no peer has that value, or if no
+// topology label is configured.
+func (ts *topologySharder) LookupTopology(key shard.Key, value string, numOwners int, op shard.Op) (peers []peer.Peer, ok bool, err error) {
+	ts.mut.RLock()
+	defer ts.mut.RUnlock()
+
+	rings, ok := ts.byTopology[value]
+	if !ok {
+		return nil, false, nil
+	}
+	peers, err = rings.lookup(key, numOwners, op)
+	return peers, true, err
+}
+
+// Peers implements shard.Sharder.
+func (ts *topologySharder) Peers() []peer.Peer {
+	ts.mut.RLock()
+	defer ts.mut.RUnlock()
+
+	ps := make([]peer.Peer, 0, len(ts.all.peers))
+	for _, p := range ts.all.peers {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	return ps
+}
+
+// SetPeers implements shard.Sharder. The NodeInfo of peers which left is
+// forgotten.
+func (ts *topologySharder) SetPeers(ps []peer.Peer) {
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+
+	ts.peers = ps
+
+	names := make(map[string]struct{}, len(ps))
+	for _, p := range ps {
+		names[p.Name] = struct{}{}
+	}
+	maps.DeleteFunc(ts.infos, func(name string, _ NodeInfo) bool {
+		_, found := names[name]
+		return !found
+	})
+
+	ts.rebuild()
+}
+
+// SetNodeInfo sets the NodeInfo of the peer name, and rebuilds the rings if
+// it changed.
+func (ts *topologySharder) SetNodeInfo(name string, info NodeInfo) {
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+
+	if prev, ok := ts.infos[name]; ok && prev.weight() == info.weight() && maps.Equal(prev.Topology, info.Topology) {
+		return
+	}
+	ts.infos[name] = info
+	ts.rebuild()
+}
+
+// HasNodeInfo returns true if the NodeInfo of the peer name is known.
+func (ts *topologySharder) HasNodeInfo(name string) bool {
+	ts.mut.RLock()
+	defer ts.mut.RUnlock()
+	_, ok := ts.infos[name]
+	return ok
+}
+
+// rebuild recomputes the rings from the current peers. ts.mut must be held
+// for writing.
+func (ts *topologySharder) rebuild() {
+	ts.all = newRingPair(ts.peers, ts.tokens)
+
+	ts.byTopology = nil
+	if ts.topologyLabel == "" {
+		return
+	}
+
+	grouped := make(map[string][]peer.Peer)
+	for _, p := range ts.peers {
+		value, ok := ts.infos[p.Name].Topology[ts.topologyLabel]
+		if !ok {
+			continue
+		}
+		grouped[value] = append(grouped[value], p)
+	}
+
+	ts.byTopology = make(map[string]ringPair, len(grouped))
+	for value, ps := range grouped {
+		ts.byTopology[value] = newRingPair(ps, ts.tokens)
+	}
+}
+
+// tokens returns the number of tokens owned by the peer name.
+func (ts *topologySharder) tokens(name string) int {
+	return ts.numTokens * ts.infos[name].weight()
+}
+
+// ringPair holds the rings used for each shard.Op.
+type ringPair struct {
+	peers           map[string]peer.Peer // Non-viewer peers by name.
+	read, readWrite ring
+}
+
+func newRingPair(ps []peer.Peer, tokens func(name string) int) ringPair {
+	var (
+		rp        = ringPair{peers: make(map[string]peer.Peer, len(ps))}
+		read      []string
+		readWrite []string
+	)
+	for _, p := range ps {
+		switch p.State {
+		case peer.StateParticipant:
+			read = append(read, p.Name)
+			readWrite = append(readWrite, p.Name)
+			rp.peers[p.Name] = p
+		case peer.StateTerminating:
+			read = append(read, p.Name)
+			rp.peers[p.Name] = p
+		}
+	}
+	rp.read = newRing(read, tokens)
+	rp.readWrite = newRing(readWrite, tokens)
+	return rp
+}
+
+func (rp ringPair) lookup(key shard.Key, numOwners int, op shard.Op) ([]peer.Peer, error) {
+	var r ring
+	switch op {
+	case shard.OpRead:
+		r = rp.read
+	case shard.OpReadWrite:
+		r = rp.readWrite
+	default:
+		return nil, fmt.Errorf("unknown op %s", op)
+	}
+
+	names, err := r.get(uint64(key), numOwners)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]peer.Peer, 0, len(names))
+	for _, name := range names {
+		res = append(res, rp.peers[name])
+	}
+	return res, nil
+}
+
+// ring is a consistent hash ring.
+type ring struct {
+	numNodes int
+	tokens   []ringToken // Sorted by token, then node.
+}
+
+type ringToken struct {
+	node  string
+	token uint64
+}
+
+func newRing(nodes []string, numTokens func(node string) int) ring {
+	var toks []ringToken
+	for _, node := range nodes {
+		dig := xxhash.New()
+		_, _ = dig.WriteString(node)
+
+		// Each token hashes the node name followed by the numbers of all the
+		// previous tokens truncated to a byte, like shard.Ring.
+		tokData := []byte{0}
+		for t := 0; t < numTokens(node); t++ {
+			tokData[0] = byte(t)
+			_, _ = dig.Write(tokData)
+			toks = append(toks, ringToken{node: node, token: dig.Sum64()})
+		}
+	}
+	sort.Slice(toks, func(i, j int) bool {
+		if toks[i].token == toks[j].token {
+			return toks[i].node < toks[j].node
+		}
+		return toks[i].token < toks[j].token
+	})
+	return ring{numNodes: len(nodes), tokens: toks}
+}
+
+// get returns the n nodes owning key.
+func (r ring) get(key uint64, n int) ([]string, error) {
+	if n > r.numNodes {
+		return nil, fmt.Errorf("not enough nodes: need at least %d, have %d", n, r.numNodes)
+	} else if n == 0 {
+		return []string{}, nil
+	}
+
+	idx := sort.Search(len(r.tokens), func(i int) bool {
+		return r.tokens[i].token >= key
+	})
+	if idx == len(r.tokens) {
+		// Wrap around if we hit the end of the ring.
+		idx = 0
+	}
+
+	res := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for len(res) < n {
+		owner := r.tokens[idx].node
+		if _, found := seen[owner]; !found {
+			res = append(res, owner)
+			seen[owner] = struct{}{}
+		}
+		idx = (idx + 1) % len(r.tokens)
+	}
+	return res, nil
+}
+
+// topologyCluster is implemented by clusters supporting topology-aware
+// lookups.
+type topologyCluster interface {
+	topologyAwareLabel() string
+	lookupTopology(key shard.Key, value string, replicationFactor int, op shard.Op) ([]peer.Peer, bool, error)
+}
+
+var _ topologyCluster = (*alloyCluster)(nil)
+
+func (c *alloyCluster) topologyAwareLabel() string {
+	return c.opts.TopologyAwareLabel
+}
+
+func (c *alloyCluster) lookupTopology(key shard.Key, value string, replicationFactor int, op shard.Op) ([]peer.Peer, bool, error) {
+	ts, ok := c.sharder.(*topologySharder)
+	if !ok {
+		return nil, false, nil
+	}
+	return ts.LookupTopology(key, value, replicationFactor, op)
+}
+
+// TopologyAwareLabel returns the name of the target label used to assign
+// targets to the nodes of the same topology, or an empty string if
+// topology-aware sharding isn't enabled for c.
+func TopologyAwareLabel(c Cluster) string {
+	if tc, ok := c.(topologyCluster); ok {
+		return tc.topologyAwareLabel()
+	}
+	return ""
+}
+
+// LookupTopology is like c.Lookup, but only considers the nodes whose
+// topology-aware label has the given value. It falls back to c.Lookup if no
+// node has that value or if topology-aware sharding isn't enabled for c.
+func LookupTopology(c Cluster, key shard.Key, value string, replicationFactor int, op shard.Op) ([]peer.Peer, error) {
+	if tc, ok := c.(topologyCluster); ok {
+		peers, ok, err := tc.lookupTopology(key, value, replicationFactor, op)
+		if ok {
+			return peers, err
+		}
+	}
+	return c.Lookup(key, replicationFactor, op)
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/stretchr/testify/require"
+)
+
+func testPeers(names ...string) []peer.Peer {
+	peers := make([]peer.Peer, 0, len(names))
+	for _, name := range names {
+		peers = append(peers, peer.Peer{Name: name, Addr: name, State: peer.StateParticipant})
+	}
+	return peers
+}
+
+func TestTopologySharder_MatchesRing(t *testing.T) {
+	peers := testPeers("a", "b", "c")
+	peers[2].State = peer.StateTerminating
+
+	ring := shard.Ring(tokensPerNode)
+	ring.SetPeers(peers)
+	ts := newTopologySharder(tokensPerNode, "")
+	ts.SetPeers(peers)
+
+	require.Equal(t, ring.Peers(), ts.Peers())
+	for i := 0; i < 1000; i++ {
+		key := shard.StringKey(fmt.Sprintf("key-%d", i))
+		for _, op := range []shard.Op{shard.OpRead, shard.OpReadWrite} {
+			expect, err := ring.Lookup(key, 1, op)
+			require.NoError(t, err)
+			actual, err := ts.Lookup(key, 1, op)
+			require.NoError(t, err)
+			require.Equal(t, expect, actual)
+		}
+	}
+}
+
+func TestTopologySharder_Weight(t *testing.T) {
+	ts := newTopologySharder(tokensPerNode, "")
+	ts.SetPeers(testPeers("a", "b", "c"))
+	ts.SetNodeInfo("c", NodeInfo{Weight: 2})
+
+	owned := make(map[string]int)
+	const keys = 100_000
+	for i := 0; i < keys; i++ {
+		peers, err := ts.Lookup(shard.StringKey(fmt.Sprintf("key-%d", i)), 1, shard.OpReadWrite)
+		require.NoError(t, err)
+		owned[peers[0].Name]++
+	}
+
+	// c owns about half of the keys, a and b about a quarter each.
+	require.InDelta(t, 0.5, float64(owned["c"])/keys, 0.05)
+	require.InDelta(t, 0.25, float64(owned["a"])/keys, 0.05)
+	require.InDelta(t, 0.25, float64(owned["b"])/keys, 0.05)
+}
+
+func TestTopologySharder_LookupTopology(t *testing.T) {
+	ts := newTopologySharder(tokensPerNode, "zone")
+	ts.SetPeers(testPeers("a", "b", "c", "d"))
+	ts.SetNodeInfo("a", NodeInfo{Topology: map[string]string{"zone": "east"}})
+	ts.SetNodeInfo("b", NodeInfo{Topology: map[string]string{"zone": "east"}})
+	ts.SetNodeInfo("c", NodeInfo{Topology: map[string]string{"zone": "west"}})
+
+	for i := 0; i < 100; i++ {
+		key := shard.StringKey(fmt.Sprintf("key-%d", i))
+
+		peers, ok, err := ts.LookupTopology(key, "east", 1, shard.OpReadWrite)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Contains(t, []string{"a", "b"}, peers[0].Name)
+
+		peers, ok, err = ts.LookupTopology(key, "west", 1, shard.OpReadWrite)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "c", peers[0].Name)
+
+		_, ok, err = ts.LookupTopology(key, "north", 1, shard.OpReadWrite)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	// The NodeInfo of peers leaving the cluster is forgotten.
+	ts.SetPeers(testPeers("a", "b", "d"))
+	require.False(t, ts.HasNodeInfo("c"))
+	_, ok, err := ts.LookupTopology(shard.StringKey("key"), "west", 1, shard.OpReadWrite)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
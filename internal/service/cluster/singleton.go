@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"go.uber.org/atomic"
+)
+
+// SingletonBlock holds the clustering settings of a component which can be
+// configured to run on a single node of the cluster. SingletonBlock is
+// intended to be exposed as a block called "clustering".
+type SingletonBlock struct {
+	// Singleton runs the component only on the node of the cluster owning it.
+	Singleton bool `alloy:"singleton,attr,optional"`
+}
+
+// Singleton elects the node of a cluster which runs a component which must
+// only run once across the cluster.
+//
+// The owner of a component is the node which owns its ID in the hash ring, so
+// every node elects the same owner without coordination. When the owner
+// leaves the cluster, ownership moves to another node of the ring. Components
+// should call Update when notified of a cluster change.
+type Singleton struct {
+	id      string
+	cluster Cluster
+	owner   atomic.Bool
+}
+
+// NewSingleton returns a Singleton electing the owner of the component id.
+// The component is reported as a singleton of the cluster until Close is
+// called.
+func NewSingleton(c Cluster, id string) *Singleton {
+	if r, ok := c.(singletonRegistry); ok {
+		r.registerSingleton(id)
+	}
+	return &Singleton{id: id, cluster: c}
+}
+
+// Update checks whether the local node owns the component, and returns true
+// if ownership changed since the last call to Update. Ownership is unchanged
+// if the owner can't be determined.
+func (s *Singleton) Update() (changed bool, err error) {
+	owner, err := singletonOwner(s.cluster, s.id)
+	if err != nil {
+		return false, err
+	}
+	return s.owner.Swap(owner.Self) != owner.Self, nil
+}
+
+// IsOwner returns true if the local node owned the component on the last call
+// to Update.
+func (s *Singleton) IsOwner() bool {
+	return s.owner.Load()
+}
+
+// Close stops reporting the component as a singleton of the cluster.
+func (s *Singleton) Close() {
+	if r, ok := s.cluster.(singletonRegistry); ok {
+		r.unregisterSingleton(s.id)
+	}
+}
+
+// SingletonOwners returns the IDs of the singleton components of c, by the
+// name of the peer owning them.
+func SingletonOwners(c Cluster) map[string][]string {
+	r, ok := c.(singletonRegistry)
+	if !ok {
+		return nil
+	}
+
+	owners := make(map[string][]string)
+	for _, id := range r.singletons() {
+		owner, err := singletonOwner(c, id)
+		if err != nil {
+			continue
+		}
+		owners[owner.Name] = append(owners[owner.Name], id)
+	}
+	return owners
+}
+
+func singletonOwner(c Cluster, id string) (peer.Peer, error) {
+	// NOTE: since this is leader election, it is okay to NOT check if cluster is ready.
+	peers, err := c.Lookup(shard.StringKey(id), 1, shard.OpReadWrite)
+	if err != nil {
+		return peer.Peer{}, fmt.Errorf("unable to determine owner of %s: %w", id, err)
+	}
+	if len(peers) != 1 {
+		return peer.Peer{}, fmt.Errorf("unexpected peers from ownership check of %s: %+v", id, peers)
+	}
+	return peers[0], nil
+}
+
+// singletonRegistry tracks the singleton components of a cluster.
+type singletonRegistry interface {
+	registerSingleton(id string)
+	unregisterSingleton(id string)
+	singletons() []string
+}
+
+// singletonSet implements singletonRegistry. The same component ID may be
+// registered several times, for example while a component is being rebuilt.
+type singletonSet struct {
+	mut sync.Mutex
+	ids map[string]int
+}
+
+var _ singletonRegistry = (*singletonSet)(nil)
+
+func (s *singletonSet) registerSingleton(id string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.ids == nil {
+		s.ids = make(map[string]int)
+	}
+	s.ids[id]++
+}
+
+func (s *singletonSet) unregisterSingleton(id string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.ids[id] <= 1 {
+		delete(s.ids, id)
+		return
+	}
+	s.ids[id]--
+}
+
+func (s *singletonSet) singletons() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	ids := make([]string, 0, len(s.ids))
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
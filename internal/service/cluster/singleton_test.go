@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleton(t *testing.T) {
+	const id = "loki.source.kubernetes_events.default"
+
+	sharder := shard.Ring(tokensPerNode)
+	c := newAlloyCluster(sharder, func() {}, Options{}, log.NewNopLogger())
+	defer c.shutdown()
+
+	// setPeers sets the peers of the cluster, with self as the local node.
+	setPeers := func(self string, names ...string) {
+		peers := make([]peer.Peer, 0, len(names))
+		for _, name := range names {
+			peers = append(peers, peer.Peer{Name: name, Addr: name, Self: name == self, State: peer.StateParticipant})
+		}
+		sharder.SetPeers(peers)
+	}
+
+	setPeers("a", "a", "b", "c")
+	owner, err := singletonOwner(c, id)
+	require.NoError(t, err)
+
+	// Every node elects the same owner.
+	s := NewSingleton(c, id)
+	for _, name := range []string{"a", "b", "c"} {
+		setPeers(name, "a", "b", "c")
+		_, err := s.Update()
+		require.NoError(t, err)
+		require.Equal(t, name == owner.Name, s.IsOwner(), name)
+	}
+	require.Equal(t, map[string][]string{owner.Name: {id}}, SingletonOwners(c))
+
+	// Ownership moves to another node when the owner leaves the cluster.
+	var remaining []string
+	for _, name := range []string{"a", "b", "c"} {
+		if name != owner.Name {
+			remaining = append(remaining, name)
+		}
+	}
+	setPeers("", remaining...)
+	newOwner, err := singletonOwner(c, id)
+	require.NoError(t, err)
+	require.NotEqual(t, owner.Name, newOwner.Name)
+
+	for _, name := range remaining {
+		setPeers(name, remaining...)
+		_, err := s.Update()
+		require.NoError(t, err)
+		require.Equal(t, name == newOwner.Name, s.IsOwner(), name)
+	}
+
+	s.Close()
+	require.Empty(t, SingletonOwners(c))
+}
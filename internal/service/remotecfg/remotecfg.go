@@ -11,6 +11,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,11 +23,13 @@ import (
 	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
 	"github.com/grafana/alloy/internal/alloyseed"
 	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/common/config"
 	"github.com/grafana/alloy/internal/featuregate"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/alloy/internal/util/jitter"
 	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/ast"
@@ -46,6 +50,20 @@ const baseJitter = 100 * time.Millisecond
 // slightly less than MaxInt to avoid overflowing
 const disablePollingFrequency = math.MaxInt64 - baseJitter
 
+// minLongPollDuration is the minimum duration the API must hold a long
+// polling request which didn't return a new configuration to poll again
+// immediately. APIs answering faster don't support long polling, and are
+// polled every poll_frequency instead.
+const minLongPollDuration = time.Second
+
+// longPollGracePeriod is added to the timeout of long polling requests so the
+// API has time to respond after holding the request.
+const longPollGracePeriod = 10 * time.Second
+
+// maxReportedUnhealthyComponents is the maximum number of unhealthy component
+// IDs reported to the API.
+const maxReportedUnhealthyComponents = 10
+
 var errNotModified = errors.New("config not modified since last fetch")
 
 // Service implements a service for remote configuration.
@@ -65,6 +83,9 @@ type Service struct {
 	clientFactory        func(args Arguments) (collectorv1connect.CollectorServiceClient, error)
 	ticker               *jitter.Ticker
 	updateTickerChan     chan struct{}
+	pollNowChan          chan struct{}
+	cancelPoll           context.CancelFunc
+	probationEndChan     chan struct{}
 	pollFrequency        time.Duration
 	dataPath             string
 	lastLoadedConfigHash string
@@ -79,6 +100,27 @@ type Service struct {
 	// This is the AST file parsed from the configuration. This is used
 	// for the support bundle
 	astFile *ast.File
+
+	// These are the hash of the last configuration successfully loaded, and
+	// the error of the last load if it failed. They are reported to the API
+	// when report_status is set.
+	appliedConfigHash string
+	lastLoadErr       error
+
+	// These track staged rollouts when probation_period is set. A new
+	// configuration is on probation until probationDeadline, and replaces
+	// knownGoodConfig if no component became unhealthy by then. Components
+	// which were already unhealthy before the configuration was loaded are in
+	// probationBaseline. Otherwise, knownGoodConfig is loaded again, and the
+	// hash of the new configuration is remembered in rejectedConfigHash so it
+	// isn't loaded again.
+	knownGoodConfig    []byte
+	probationConfig    []byte
+	probationDeadline  time.Time
+	probationTimer     *time.Timer
+	probationBaseline  map[string]struct{}
+	rejectedConfigHash string
+	rollbackErr        error
 }
 
 type metrics struct {
@@ -89,6 +131,7 @@ type metrics struct {
 	lastFetchSuccessTime prometheus.Gauge
 	totalAttempts        prometheus.Counter
 	getConfigTime        prometheus.Histogram
+	probationOutcomes    *prometheus.CounterVec
 }
 
 // ServiceName defines the name used for the remotecfg service.
@@ -113,6 +156,10 @@ type Arguments struct {
 	Name             string                   `alloy:"name,attr,optional"`
 	Attributes       map[string]string        `alloy:"attributes,attr,optional"`
 	PollFrequency    time.Duration            `alloy:"poll_frequency,attr,optional"`
+	LongPolling      bool                     `alloy:"long_polling,attr,optional"`
+	LongPollTimeout  time.Duration            `alloy:"long_polling_timeout,attr,optional"`
+	ReportStatus     bool                     `alloy:"report_status,attr,optional"`
+	ProbationPeriod  time.Duration            `alloy:"probation_period,attr,optional"`
 	HTTPClientConfig *config.HTTPClientConfig `alloy:",squash"`
 }
 
@@ -122,6 +169,7 @@ func GetDefaultArguments() Arguments {
 		ID:               alloyseed.Get().UID,
 		Attributes:       make(map[string]string),
 		PollFrequency:    1 * time.Minute,
+		LongPollTimeout:  5 * time.Minute,
 		HTTPClientConfig: config.CloneDefaultHTTPClientConfig(),
 	}
 }
@@ -136,6 +184,12 @@ func (a *Arguments) Validate() error {
 	if a.PollFrequency < 10*time.Second {
 		return fmt.Errorf("poll_frequency must be at least \"10s\", got %q", a.PollFrequency)
 	}
+	if a.LongPolling && a.LongPollTimeout < 10*time.Second {
+		return fmt.Errorf("long_polling_timeout must be at least \"10s\", got %q", a.LongPollTimeout)
+	}
+	if a.ProbationPeriod < 0 {
+		return fmt.Errorf("probation_period must not be negative, got %q", a.ProbationPeriod)
+	}
 
 	for k := range a.Attributes {
 		if strings.HasPrefix(k, reservedAttributeNamespace+namespaceDelimiter) {
@@ -173,6 +227,8 @@ func New(opts Options) (*Service, error) {
 		opts:             opts,
 		systemAttrs:      getSystemAttributes(),
 		updateTickerChan: make(chan struct{}, 1),
+		pollNowChan:      make(chan struct{}, 1),
+		probationEndChan: make(chan struct{}, 1),
 		pollFrequency:    disablePollingFrequency,
 		clientFactory: func(args Arguments) (collectorv1connect.CollectorServiceClient, error) {
 			httpClient, err := commonconfig.NewClientFromConfig(*args.HTTPClientConfig.Convert(), "remoteconfig")
@@ -241,6 +297,13 @@ func (s *Service) registerMetrics() {
 				Help: "Duration of remote configuration requests.",
 			},
 		),
+		probationOutcomes: prom.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "remotecfg_probation_outcomes_total",
+				Help: "Outcomes of the probation period of remote configurations, either passed or rolled_back.",
+			},
+			[]string{"outcome"},
+		),
 	}
 	s.metrics = mets
 }
@@ -298,13 +361,19 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		s.ctrl.Run(ctx)
 	}()
 
+	// With long polling, the API is polled continuously rather than on ticks.
+	if s.longPolling() {
+		s.triggerPoll()
+	}
+
 	for {
 		select {
 		case <-s.ticker.C:
-			err := s.fetchRemote()
-			if err != nil && err != errNoopClient {
-				level.Error(s.opts.Logger).Log("msg", "failed to fetch remote configuration from the API", "err", err)
-			}
+			s.poll(ctx)
+		case <-s.pollNowChan:
+			s.poll(ctx)
+		case <-s.probationEndChan:
+			s.endProbation()
 		case <-s.updateTickerChan:
 			s.ticker.Reset(s.pollFrequency)
 		case <-ctx.Done():
@@ -313,11 +382,73 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 	}
 }
 
+// poll fetches the configuration from the API. With long polling, the API
+// holds the request until the configuration changes, and the API is polled
+// again as soon as it responds.
+func (s *Service) poll(ctx context.Context) {
+	// The end of a probation period takes precedence over polling, which may
+	// block for a long time with long polling.
+	select {
+	case <-s.probationEndChan:
+		s.endProbation()
+	default:
+	}
+
+	s.mut.Lock()
+	var wait time.Duration
+	if s.args.LongPolling {
+		wait = s.args.LongPollTimeout
+	}
+	pollCtx, cancel := context.WithCancel(ctx)
+	s.cancelPoll = cancel
+	s.mut.Unlock()
+	defer cancel()
+
+	start := time.Now()
+	changed, err := s.fetchRemote(pollCtx, wait)
+	switch {
+	case ctx.Err() != nil:
+		return
+	case pollCtx.Err() != nil:
+		// The request was canceled by Update, or by the end of a probation
+		// period; poll again, with the new Arguments if they changed.
+		s.triggerPoll()
+		return
+	case err != nil && err != errNoopClient:
+		level.Error(s.opts.Logger).Log("msg", "failed to fetch remote configuration from the API", "err", err)
+	}
+
+	if wait > 0 && (changed || (err == nil && time.Since(start) >= minLongPollDuration)) {
+		s.triggerPoll()
+	}
+}
+
+// longPolling returns true if the API is polled with long polling.
+func (s *Service) longPolling() bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args.LongPolling
+}
+
+// triggerPoll makes Run poll the API immediately.
+func (s *Service) triggerPoll() {
+	select {
+	case s.pollNowChan <- struct{}{}:
+	default:
+	}
+}
+
 // Update implements [service.Service] and applies settings.
 func (s *Service) Update(newConfig any) error {
 	newArgs := newConfig.(Arguments)
 	s.mut.Lock()
 
+	// Stop waiting on an in-flight long polling request, which uses the
+	// previous Arguments.
+	if s.cancelPoll != nil {
+		s.cancelPoll()
+	}
+
 	// We either never set the block on the first place, or recently removed
 	// it. Make sure we stop everything gracefully before returning.
 	if newArgs.URL == "" {
@@ -368,6 +499,9 @@ func (s *Service) Update(newConfig any) error {
 	// the updated Arguments, and/or fall back to the updated cache location.
 	if s.ctrl != nil && s.ctrl.Ready() {
 		s.fetch()
+		if newArgs.LongPolling {
+			s.triggerPoll()
+		}
 	}
 
 	return nil
@@ -383,7 +517,7 @@ func (s *Service) GetCachedAstFile() *ast.File {
 // fetch attempts to read configuration from the API and the local cache
 // and then parse/load their contents in order of preference.
 func (s *Service) fetch() {
-	if err := s.fetchRemote(); err != nil {
+	if _, err := s.fetchRemote(context.Background(), 0); err != nil {
 		level.Error(s.opts.Logger).Log("msg", "failed to fetch remote config", "err", err)
 		s.fetchLocal()
 	}
@@ -404,14 +538,18 @@ func (s *Service) registerCollector() error {
 	return nil
 }
 
-func (s *Service) fetchRemote() error {
+// fetchRemote fetches the configuration from the API and loads it. If wait is
+// non-zero, the API may hold the request for up to wait until the
+// configuration changes. fetchRemote returns true if a new configuration was
+// received.
+func (s *Service) fetchRemote(ctx context.Context, wait time.Duration) (changed bool, err error) {
 	if !s.isEnabled() {
-		return nil
+		return false, nil
 	}
 
 	level.Debug(s.opts.Logger).Log("msg", "fetching remote configuration")
 
-	b, err := s.getAPIConfig()
+	b, err := s.getAPIConfig(ctx, wait)
 	s.metrics.totalAttempts.Add(1)
 
 	if err == nil {
@@ -420,13 +558,13 @@ func (s *Service) fetchRemote() error {
 	} else if err != errNotModified {
 		s.metrics.totalFailures.Add(1)
 		s.metrics.lastLoadSuccess.Set(0)
-		return err
+		return false, err
 	}
 
 	if err == errNotModified {
 		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it has not been modified since last fetch")
 		s.metrics.lastFetchNotModified.Set(1)
-		return nil
+		return false, nil
 	} else {
 		s.metrics.lastFetchNotModified.Set(0)
 	}
@@ -435,17 +573,153 @@ func (s *Service) fetchRemote() error {
 	newConfigHash := getHash(b)
 	if s.getLastLoadedCfgHash() == newConfigHash {
 		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it matched the last loaded one")
-		return nil
+		return false, nil
+	}
+	if s.getRejectedCfgHash() == newConfigHash {
+		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it matched the last rolled back one")
+		return false, nil
 	}
 
+	// Components which are unhealthy before the new configuration is loaded
+	// don't count against its probation.
+	unhealthyBefore := s.unhealthyComponents()
+
 	err = s.parseAndLoad(b)
 	if err != nil {
-		return err
+		return true, err
 	}
 
-	// If successful, flush to disk and keep a copy.
-	s.setCachedConfig(b)
-	return nil
+	s.commitConfig(b, unhealthyBefore)
+	return true, nil
+}
+
+// commitConfig is called after the configuration b loaded successfully. It
+// flushes b to disk, or puts it on probation if probation_period is set.
+// unhealthyBefore holds the components which were unhealthy before b was
+// loaded.
+func (s *Service) commitConfig(b []byte, unhealthyBefore []string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.rollbackErr = nil
+	if s.probationTimer != nil {
+		// The configuration on probation was replaced before the end of its
+		// probation.
+		s.probationTimer.Stop()
+		s.probationTimer = nil
+		s.probationConfig = nil
+	}
+
+	if s.args.ProbationPeriod == 0 {
+		s.knownGoodConfig = b
+		s.writeCachedConfig(b)
+		return
+	}
+
+	if s.knownGoodConfig == nil {
+		// Fall back to the on-disk cache, which only holds configurations
+		// which passed their probation.
+		s.knownGoodConfig, _ = os.ReadFile(s.dataPath)
+	}
+	s.probationConfig = b
+	s.probationDeadline = time.Now().Add(s.args.ProbationPeriod)
+	s.probationBaseline = make(map[string]struct{}, len(unhealthyBefore))
+	for _, id := range unhealthyBefore {
+		s.probationBaseline[id] = struct{}{}
+	}
+	s.probationTimer = time.AfterFunc(s.args.ProbationPeriod, s.triggerProbationEnd)
+}
+
+// triggerProbationEnd makes Run end the probation of the current
+// configuration. Run handles the end of the probation between polls, so an
+// in-flight poll, which can be held by the API with long polling, is
+// canceled; it's retried once the probation ended.
+func (s *Service) triggerProbationEnd() {
+	select {
+	case s.probationEndChan <- struct{}{}:
+	default:
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.cancelPoll != nil {
+		s.cancelPoll()
+	}
+}
+
+// endProbation ends the probation of the current configuration once its
+// deadline passed. The configuration becomes the known good configuration
+// if none of its components became unhealthy; otherwise, the known good
+// configuration is loaded again.
+func (s *Service) endProbation() {
+	s.mut.Lock()
+	b := s.probationConfig
+	if b == nil || time.Now().Before(s.probationDeadline) {
+		s.mut.Unlock()
+		return
+	}
+	s.probationConfig = nil
+	s.probationTimer = nil
+	knownGood := s.knownGoodConfig
+	baseline := s.probationBaseline
+	s.probationBaseline = nil
+	s.mut.Unlock()
+
+	unhealthy := slices.DeleteFunc(s.unhealthyComponents(), func(id string) bool {
+		_, ok := baseline[id]
+		return ok
+	})
+	if len(unhealthy) == 0 {
+		level.Info(s.opts.Logger).Log("msg", "remote configuration passed its probation", "hash", getHash(b))
+		s.metrics.probationOutcomes.WithLabelValues("passed").Inc()
+
+		s.mut.Lock()
+		s.knownGoodConfig = b
+		s.writeCachedConfig(b)
+		s.mut.Unlock()
+		return
+	}
+
+	rollbackErr := fmt.Errorf("components became unhealthy during the probation period: %s", strings.Join(unhealthy, ", "))
+	if len(knownGood) == 0 {
+		level.Warn(s.opts.Logger).Log("msg", "remote configuration failed its probation, but there is no known good configuration to roll back to", "hash", getHash(b), "err", rollbackErr)
+		s.mut.Lock()
+		s.rollbackErr = rollbackErr
+		s.mut.Unlock()
+		return
+	}
+
+	level.Warn(s.opts.Logger).Log("msg", "remote configuration failed its probation; rolling back to the last known good configuration", "hash", getHash(b), "err", rollbackErr)
+	s.metrics.probationOutcomes.WithLabelValues("rolled_back").Inc()
+
+	if err := s.parseAndLoad(knownGood); err != nil {
+		level.Error(s.opts.Logger).Log("msg", "failed to roll back to the last known good configuration", "err", err)
+	}
+
+	s.mut.Lock()
+	s.rejectedConfigHash = getHash(b)
+	s.rollbackErr = rollbackErr
+	s.mut.Unlock()
+}
+
+// unhealthyComponents returns the sorted IDs of the unhealthy components of
+// the remote configuration.
+func (s *Service) unhealthyComponents() []string {
+	host := s.controllerHost()
+	if host == nil {
+		return nil
+	}
+
+	var unhealthy []string
+	components, _ := host.ListComponents("", component.InfoOptions{GetHealth: true})
+	for _, c := range components {
+		switch c.Health.Health {
+		case component.HealthTypeUnhealthy, component.HealthTypeExited:
+			unhealthy = append(unhealthy, c.ID.String())
+		}
+	}
+	slices.Sort(unhealthy)
+	return unhealthy
 }
 
 func (s *Service) fetchLocal() {
@@ -458,21 +732,41 @@ func (s *Service) fetchLocal() {
 	err = s.parseAndLoad(b)
 	if err != nil {
 		level.Error(s.opts.Logger).Log("msg", "failed to load from cache", "err", err)
+		return
 	}
+
+	s.mut.Lock()
+	s.knownGoodConfig = b
+	s.mut.Unlock()
 }
 
-func (s *Service) getAPIConfig() ([]byte, error) {
+func (s *Service) getAPIConfig(ctx context.Context, wait time.Duration) ([]byte, error) {
 	s.mut.RLock()
+	attrs := s.attrs
+	if s.args.ReportStatus {
+		attrs = maps.Clone(s.attrs)
+		maps.Copy(attrs, s.statusAttributes())
+	}
 	req := connect.NewRequest(&collectorv1.GetConfigRequest{
 		Id:              s.args.ID,
-		LocalAttributes: s.attrs,
+		LocalAttributes: attrs,
 		Hash:            s.remoteHash,
 	})
 	client := s.asClient
 	s.mut.RUnlock()
 
+	if wait > 0 {
+		// Ask the API to hold the request until the configuration changes,
+		// as defined by RFC 7240.
+		req.Header().Set("Prefer", fmt.Sprintf("wait=%d", int(wait.Seconds())))
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait+longPollGracePeriod)
+		defer cancel()
+	}
+
 	start := time.Now()
-	gcr, err := client.GetConfig(context.Background(), req)
+	gcr, err := client.GetConfig(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -496,12 +790,9 @@ func (s *Service) getCachedConfig() ([]byte, error) {
 	return os.ReadFile(p)
 }
 
-func (s *Service) setCachedConfig(b []byte) {
-	s.mut.RLock()
-	p := s.dataPath
-	s.mut.RUnlock()
-
-	err := os.WriteFile(p, b, 0750)
+// writeCachedConfig flushes b to the on-disk cache. s.mut must be held.
+func (s *Service) writeCachedConfig(b []byte) {
+	err := os.WriteFile(s.dataPath, b, 0750)
 	if err != nil {
 		level.Error(s.opts.Logger).Log("msg", "failed to flush remote configuration contents the on-disk cache", "err", err)
 	}
@@ -515,8 +806,10 @@ func (s *Service) parseAndLoad(b []byte) error {
 	if len(b) == 0 {
 		return nil
 	}
-	s.setLastLoadedCfgHash(getHash(b))
+	hash := getHash(b)
+	s.setLastLoadedCfgHash(hash)
 	file, err := ctrl.LoadSource(b, nil, s.opts.ConfigPath)
+	s.setLoadResult(hash, err)
 	if err != nil {
 		return err
 	}
@@ -525,6 +818,79 @@ func (s *Service) parseAndLoad(b []byte) error {
 	return nil
 }
 
+// setLoadResult records the outcome of loading the configuration with the
+// given hash.
+func (s *Service) setLoadResult(hash string, err error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.lastLoadErr = err
+	if err == nil {
+		s.appliedConfigHash = hash
+	}
+}
+
+// statusAttributes returns the attributes reporting the status of the
+// configuration and of its components to the API. s.mut must be held for
+// reading.
+func (s *Service) statusAttributes() map[string]string {
+	attr := func(name string) string {
+		return reservedAttributeNamespace + namespaceDelimiter + name
+	}
+
+	attrs := map[string]string{
+		attr("config.hash"): s.appliedConfigHash,
+	}
+	switch {
+	case s.lastLoadErr != nil:
+		attrs[attr("config.status")] = "failed"
+		attrs[attr("config.error")] = s.lastLoadErr.Error()
+	case s.probationConfig != nil:
+		attrs[attr("config.status")] = "probation"
+	case s.rollbackErr != nil && s.rejectedConfigHash != "":
+		attrs[attr("config.status")] = "rolled_back"
+		attrs[attr("config.error")] = s.rollbackErr.Error()
+		attrs[attr("config.rejected_hash")] = s.rejectedConfigHash
+	case s.rollbackErr != nil:
+		// The configuration failed its probation, but there was nothing to
+		// roll back to.
+		attrs[attr("config.status")] = "failed"
+		attrs[attr("config.error")] = s.rollbackErr.Error()
+	case s.appliedConfigHash == "":
+		attrs[attr("config.status")] = "none"
+	default:
+		attrs[attr("config.status")] = "applied"
+	}
+
+	var total int
+	if host := s.controllerHost(); host != nil {
+		components, _ := host.ListComponents("", component.InfoOptions{})
+		total = len(components)
+	}
+	unhealthy := s.unhealthyComponents()
+	attrs[attr("components.total")] = strconv.Itoa(total)
+	attrs[attr("components.unhealthy")] = strconv.Itoa(len(unhealthy))
+	attrs[attr("components.unhealthy_ids")] = util.JoinWithTruncation(unhealthy, ",", maxReportedUnhealthyComponents, "...")
+	return attrs
+}
+
+// controllerHost returns the Host of the controller running the remote
+// configuration, or nil if it isn't available.
+func (s *Service) controllerHost() service.Host {
+	hp, ok := s.ctrl.(interface{ GetHost() service.Host })
+	if !ok {
+		return nil
+	}
+	return hp.GetHost()
+}
+
+func (s *Service) getRejectedCfgHash() string {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	return s.rejectedConfigHash
+}
+
 func (s *Service) getLastLoadedCfgHash() string {
 	s.mut.RLock()
 	defer s.mut.RUnlock()
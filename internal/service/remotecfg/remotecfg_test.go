@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/remotecfg/remotecfgtest"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/ast"
@@ -252,6 +254,278 @@ func TestAPIResponseNotModified(t *testing.T) {
 	wg.Wait()
 }
 
+func TestLongPolling(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	server := remotecfgtest.NewServer(t)
+	server.SetConfig(cfg1)
+
+	// Poll rarely, so that the updated configuration can only be received
+	// through long polling.
+	env := newTestEnvironmentWithServer(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url                  = "%s"
+		id                   = "test"
+		poll_frequency       = "1h"
+		long_polling         = true
+		long_polling_timeout = "30s"
+	`, server.URL())))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.Eventually(t, func() bool { return server.Registered("test") }, time.Second, 10*time.Millisecond)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	server.SetConfig(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getLastLoadedCfgHash())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestReportStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfgGood := `loki.process "default" { forward_to = [] }`
+	cfgBad := `loki.process "default" { forward_to = [missing.component.receiver] }`
+
+	server := remotecfgtest.NewServer(t)
+	server.SetConfig(cfgGood)
+
+	env := newTestEnvironmentWithServer(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		id             = "test"
+		poll_frequency = "10s"
+		report_status  = true
+	`, server.URL())))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		attrs := server.Attributes("test")
+		assert.Equal(c, "applied", attrs["collector.config.status"])
+		assert.Equal(c, getHash([]byte(cfgGood)), attrs["collector.config.hash"])
+		assert.Equal(c, "1", attrs["collector.components.total"])
+		assert.Equal(c, "0", attrs["collector.components.unhealthy"])
+		assert.Equal(c, runtime.GOOS, attrs["collector.os"])
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The hash of the last configuration successfully loaded is still
+	// reported after a configuration fails to load.
+	server.SetConfig(cfgBad)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		attrs := server.Attributes("test")
+		assert.Equal(c, "failed", attrs["collector.config.status"])
+		assert.Equal(c, getHash([]byte(cfgGood)), attrs["collector.config.hash"])
+		assert.Contains(c, attrs["collector.config.error"], "missing.component.receiver")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestProbationRollback(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfgGood := `remotecfg_test.health "default" { healthy = true }`
+	cfgBad := `remotecfg_test.health "default" { healthy = false }`
+
+	server := remotecfgtest.NewServer(t)
+	server.SetConfig(cfgGood)
+
+	env := newTestEnvironmentWithServer(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url              = "%s"
+		id               = "test"
+		poll_frequency   = "10s"
+		report_status    = true
+		probation_period = "100ms"
+	`, server.URL())))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	// The configuration is only flushed to disk after its probation.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		b, err := env.svc.getCachedConfig()
+		assert.NoError(c, err)
+		assert.Equal(c, cfgGood, string(b))
+		assert.Equal(c, "applied", server.Attributes("test")["collector.config.status"])
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A configuration making components unhealthy is rolled back, and the
+	// cache still holds the known good configuration.
+	server.SetConfig(cfgBad)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		attrs := server.Attributes("test")
+		assert.Equal(c, "rolled_back", attrs["collector.config.status"])
+		assert.Equal(c, getHash([]byte(cfgGood)), attrs["collector.config.hash"])
+		assert.Equal(c, getHash([]byte(cfgBad)), attrs["collector.config.rejected_hash"])
+		assert.Contains(c, attrs["collector.config.error"], "remotecfg_test.health.default")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, getHash([]byte(cfgGood)), env.svc.getLastLoadedCfgHash())
+	b, err := env.svc.getCachedConfig()
+	require.NoError(t, err)
+	require.Equal(t, cfgGood, string(b))
+
+	cancel()
+	wg.Wait()
+}
+
+func TestProbationRollback_LongPolling(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfgGood := `remotecfg_test.health "default" { healthy = true }`
+	cfgBad := `remotecfg_test.health "default" { healthy = false }`
+
+	server := remotecfgtest.NewServer(t)
+	server.SetConfig(cfgGood)
+
+	// The API holds requests for longer than the test runs, so the probation
+	// must end while a request is in flight.
+	env := newTestEnvironmentWithServer(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url                  = "%s"
+		id                   = "test"
+		poll_frequency       = "1h"
+		long_polling         = true
+		long_polling_timeout = "1m"
+		probation_period     = "100ms"
+	`, server.URL())))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		b, err := env.svc.getCachedConfig()
+		assert.NoError(c, err)
+		assert.Equal(c, cfgGood, string(b))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	server.SetConfig(cfgBad)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfgBad)), env.svc.getRejectedCfgHash())
+		assert.Equal(c, getHash([]byte(cfgGood)), env.svc.getLastLoadedCfgHash())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestProbation_AlreadyUnhealthy(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfgGood := `remotecfg_test.health "broken" { healthy = false }`
+	cfgNew := `
+		remotecfg_test.health "broken" { healthy = false }
+		remotecfg_test.health "default" { healthy = true }
+	`
+
+	server := remotecfgtest.NewServer(t)
+	server.SetConfig(cfgGood)
+
+	env := newTestEnvironmentWithServer(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url              = "%s"
+		id               = "test"
+		poll_frequency   = "10s"
+		report_status    = true
+		probation_period = "100ms"
+	`, server.URL())))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfgGood)), server.Attributes("test")["collector.config.hash"])
+		assert.Equal(c, "1", server.Attributes("test")["collector.components.unhealthy"])
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The component which was unhealthy before the new configuration was
+	// loaded doesn't make the new configuration fail its probation.
+	server.SetConfig(cfgNew)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		b, err := env.svc.getCachedConfig()
+		assert.NoError(c, err)
+		assert.Equal(c, cfgNew, string(b))
+		assert.Equal(c, "applied", server.Attributes("test")["collector.config.status"])
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, env.svc.getRejectedCfgHash())
+
+	cancel()
+	wg.Wait()
+}
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "remotecfg_test.health",
+		Stability: featuregate.StabilityGenerallyAvailable,
+		Args:      healthArguments{},
+		Build: func(_ component.Options, args component.Arguments) (component.Component, error) {
+			return &healthComponent{healthy: args.(healthArguments).Healthy}, nil
+		},
+	})
+}
+
+type healthArguments struct {
+	Healthy bool `alloy:"healthy,attr"`
+}
+
+// healthComponent is a component whose health is set by its arguments.
+type healthComponent struct {
+	mut     sync.Mutex
+	healthy bool
+}
+
+var _ component.HealthComponent = (*healthComponent)(nil)
+
+func (c *healthComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (c *healthComponent) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.healthy = args.(healthArguments).Healthy
+	return nil
+}
+
+func (c *healthComponent) CurrentHealth() component.Health {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.healthy {
+		return component.Health{Health: component.HealthTypeHealthy}
+	}
+	return component.Health{Health: component.HealthTypeUnhealthy, Message: "unhealthy"}
+}
+
 func buildGetConfigHandler(in string, hash string, notModified bool) func(context.Context, *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
 	return func(context.Context, *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
 		rsp := &connect.Response[collectorv1.GetConfigResponse]{
@@ -295,6 +569,21 @@ func newTestEnvironment(t *testing.T, client *collectorClient) *testEnvironment
 	}
 }
 
+// newTestEnvironmentWithServer returns a test environment using the real
+// client, to connect to a remotecfgtest.Server.
+func newTestEnvironmentWithServer(t *testing.T) *testEnvironment {
+	svc, err := New(Options{
+		Logger:      util.TestLogger(t),
+		StoragePath: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	return &testEnvironment{
+		t:   t,
+		svc: svc,
+	}
+}
+
 func (env *testEnvironment) ApplyConfig(config string) error {
 	var args Arguments
 	if err := syntax.Unmarshal([]byte(config), &args); err != nil {
@@ -391,4 +680,5 @@ func (sc serviceController) LoadSource(b []byte, args map[string]any, configPath
 	}
 	return source.SourceFiles()[""], sc.f.LoadSource(source, args, configPath)
 }
-func (sc serviceController) Ready() bool { return sc.f.Ready() }
+func (sc serviceController) Ready() bool           { return sc.f.Ready() }
+func (sc serviceController) GetHost() service.Host { return sc.f }
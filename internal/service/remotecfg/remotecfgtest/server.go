@@ -0,0 +1,153 @@
+// Package remotecfgtest provides a fake remote configuration API for tests.
+package remotecfgtest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+)
+
+// Server is a fake remote configuration API serving the same configuration
+// to every collector.
+//
+// Server supports long polling: requests for the current configuration with
+// a "Prefer: wait=<seconds>" header are held until the configuration changes
+// or the wait expires.
+type Server struct {
+	srv *httptest.Server
+
+	mut        sync.Mutex
+	content    string
+	version    int
+	changed    chan struct{} // Closed and replaced when the configuration changes.
+	attributes map[string]map[string]string
+	registered map[string]bool
+}
+
+var _ collectorv1connect.CollectorServiceHandler = (*Server)(nil)
+
+// NewServer starts a new Server. The Server is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		changed:    make(chan struct{}),
+		attributes: make(map[string]map[string]string),
+		registered: make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(collectorv1connect.NewCollectorServiceHandler(s))
+	s.srv = httptest.NewServer(mux)
+	t.Cleanup(s.srv.Close)
+
+	return s
+}
+
+// URL returns the URL of the Server, to use as the url of remotecfg.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// SetConfig sets the configuration served to collectors.
+func (s *Server) SetConfig(content string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.content = content
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Attributes returns the attributes sent by the collector id in its last
+// request for configuration.
+func (s *Server) Attributes(id string) map[string]string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return maps.Clone(s.attributes[id])
+}
+
+// Registered returns true if the collector id registered itself.
+func (s *Server) Registered(id string) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.registered[id]
+}
+
+// GetConfig implements collectorv1connect.CollectorServiceHandler.
+func (s *Server) GetConfig(ctx context.Context, req *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
+	s.mut.Lock()
+	s.attributes[req.Msg.Id] = maps.Clone(req.Msg.LocalAttributes)
+	hash, changed := s.hash(), s.changed
+	s.mut.Unlock()
+
+	if req.Msg.Hash == hash {
+		if wait := preferredWait(req.Header()); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			case <-changed:
+			}
+		}
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if req.Msg.Hash == s.hash() {
+		return connect.NewResponse(&collectorv1.GetConfigResponse{NotModified: true}), nil
+	}
+	return connect.NewResponse(&collectorv1.GetConfigResponse{
+		Content: s.content,
+		Hash:    s.hash(),
+	}), nil
+}
+
+// hash returns the hash of the current configuration. s.mut must be held.
+func (s *Server) hash() string {
+	return fmt.Sprintf("v%d", s.version)
+}
+
+// preferredWait returns the wait preference of a request, as defined by RFC
+// 7240.
+func preferredWait(h http.Header) time.Duration {
+	for _, pref := range h.Values("Prefer") {
+		for _, p := range strings.Split(pref, ",") {
+			value, ok := strings.CutPrefix(strings.TrimSpace(p), "wait=")
+			if !ok {
+				continue
+			}
+			if seconds, err := strconv.Atoi(value); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return 0
+}
+
+// RegisterCollector implements collectorv1connect.CollectorServiceHandler.
+func (s *Server) RegisterCollector(_ context.Context, req *connect.Request[collectorv1.RegisterCollectorRequest]) (*connect.Response[collectorv1.RegisterCollectorResponse], error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.registered[req.Msg.Id] = true
+	return connect.NewResponse(&collectorv1.RegisterCollectorResponse{}), nil
+}
+
+// UnregisterCollector implements collectorv1connect.CollectorServiceHandler.
+func (s *Server) UnregisterCollector(_ context.Context, req *connect.Request[collectorv1.UnregisterCollectorRequest]) (*connect.Response[collectorv1.UnregisterCollectorResponse], error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.registered, req.Msg.Id)
+	return connect.NewResponse(&collectorv1.UnregisterCollectorResponse{}), nil
+}
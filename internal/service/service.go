@@ -150,3 +150,11 @@ type Service interface {
 	// Data may be invoked before Run.
 	Data() any
 }
+
+// Drainer is implemented by services which prepare for the shutdown of the
+// Alloy controller before its components are drained.
+type Drainer interface {
+	// Drain is called once the Alloy controller starts shutting down, before
+	// its components are stopped. Drain should return once ctx is done.
+	Drain(ctx context.Context) error
+}
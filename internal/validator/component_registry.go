@@ -39,3 +39,16 @@ func (cr *componentRegistry) registerCustomComponent(c *ast.BlockStmt, args any)
 	// support doing proper checks of modules.
 	cr.custom[c.Label] = component.Registration{Name: c.Label, Args: args}
 }
+
+// isCustom reports whether name refers to a custom component, such as a
+// declare block or a component of an imported module.
+func (cr *componentRegistry) isCustom(name string) bool {
+	parts := strings.Split(name, ".")
+	if _, ok := cr.custom[parts[0]]; ok {
+		return true
+	}
+	if parent, ok := cr.parent.(*componentRegistry); ok {
+		return parent.isCustom(name)
+	}
+	return false
+}
@@ -36,7 +36,9 @@ func (g *orderedGraph) Nodes() iter.Seq[dag.Node] {
 	}
 }
 
-func validateGraph(s *state) diag.Diagnostics {
+// validateGraph type checks the nodes of the graph of s. When strict is set,
+// the expressions referencing other components are type checked as well.
+func validateGraph(s *state, strict bool) diag.Diagnostics {
 	var diags diag.Diagnostics
 	for n := range s.graph.Nodes() {
 		switch node := n.(type) {
@@ -45,6 +47,9 @@ func validateGraph(s *state) diag.Diagnostics {
 			diags.Merge(node.diags)
 			if node.args != nil {
 				diags.Merge(typecheck.Block(node.block, node.args))
+				if strict {
+					diags.Merge(typecheck.Expressions(node.block, node.args, s.resolveReference))
+				}
 			}
 		case *componentNode:
 			name := node.block.GetBlockName()
@@ -66,8 +71,11 @@ func validateGraph(s *state) diag.Diagnostics {
 				continue
 			}
 			diags.Merge(typecheck.Block(node.block, reg.CloneArguments()))
+			if strict {
+				diags.Merge(typecheck.Expressions(node.block, reg.CloneArguments(), s.resolveReference))
+			}
 		case *subNode:
-			diags.Merge(validateGraph(node.state))
+			diags.Merge(validateGraph(node.state, strict))
 		}
 	}
 
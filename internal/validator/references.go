@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/typecheck"
+)
+
+// resolveReference resolves references to the exports of the components in
+// s. It implements typecheck.ResolveFunc.
+//
+// The type of a reference is only known for components registered with their
+// exports. References to custom components, or to identifiers that aren't
+// components such as stdlib functions, are left unchecked.
+func (s *state) resolveReference(traversal []*ast.Ident) (reflect.Type, int, diag.Diagnostics) {
+	node, owner, n := s.findComponent(traversal)
+	if node == nil {
+		if !s.hasNamespace(traversal[0].Name) {
+			return nil, 0, nil
+		}
+		return nil, 0, diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(traversal[0]).Position(),
+			EndPos:   ast.EndPos(traversal[len(traversal)-1]).Position(),
+			Message:  fmt.Sprintf("component %q does not exist or is out of scope", identsID(traversal)),
+		}}
+	}
+
+	// References to the component itself can't be checked further.
+	if n == len(traversal) {
+		return nil, 0, nil
+	}
+
+	name := node.block.GetBlockName()
+	if owner.cr.isCustom(name) {
+		return nil, 0, nil
+	}
+	reg, err := owner.cr.Get(name)
+	if err != nil {
+		// Unknown components are reported when validating the component.
+		return nil, 0, nil
+	}
+
+	field := traversal[n]
+	if reg.Exports != nil {
+		if ty, ok := typecheck.FieldType(reflect.TypeOf(reg.Exports), field.Name); ok {
+			return ty, n + 1, nil
+		}
+	}
+	return nil, 0, diag.Diagnostics{{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(field).Position(),
+		EndPos:   ast.EndPos(field).Position(),
+		Message:  fmt.Sprintf("component %q does not export %q", identsID(traversal[:n]), field.Name),
+	}}
+}
+
+// findComponent returns the component node referenced by the shortest prefix
+// of traversal, along with the state it belongs to and the length of the
+// prefix.
+func (s *state) findComponent(traversal []*ast.Ident) (*componentNode, *state, int) {
+	for cur := s; cur != nil; cur = cur.parent {
+		for n := 1; n <= len(traversal); n++ {
+			if node, ok := cur.graph.GetByID(identsID(traversal[:n])).(*componentNode); ok {
+				return node, cur, n
+			}
+		}
+	}
+	return nil, nil, 0
+}
+
+// hasNamespace reports whether a component in scope has a name starting with
+// namespace. References within such a namespace must point to a component.
+func (s *state) hasNamespace(namespace string) bool {
+	for cur := s; cur != nil; cur = cur.parent {
+		for _, c := range cur.components {
+			if c.Name[0] == namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func identsID(idents []*ast.Ident) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ".")
+}
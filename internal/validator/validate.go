@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/nodeconf/export"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
@@ -36,6 +37,8 @@ type Options struct {
 	// MinStability is the minimum stability level of features that can be used by the collector. It is defined by
 	// the user, for example, via command-line flags.
 	MinStability featuregate.Stability
+	// Strict enables type checking of the expressions referencing other components.
+	Strict bool
 }
 
 func Validate(opts Options) error {
@@ -45,6 +48,7 @@ func Validate(opts Options) error {
 
 type validator struct {
 	minStability featuregate.Stability
+	strict       bool
 	sources      map[string][]byte
 	sm           map[string]service.Definition
 }
@@ -57,6 +61,7 @@ func newValidator(opts Options) *validator {
 
 	return &validator{
 		minStability: opts.MinStability,
+		strict:       opts.Strict,
 		sources:      opts.Sources,
 		sm:           sm,
 	}
@@ -74,13 +79,13 @@ func (v *validator) run(cr *componentRegistry) error {
 		root:       true,
 		graph:      newGraph(),
 		declares:   s.Declares(),
-		configs:    s.Configs(),
+		configs:    append(s.Configs(), s.Functions()...),
 		components: components,
 		services:   services,
 		cr:         cr,
 	}
 
-	diags := validateGraph(v.validate(rootState))
+	diags := validateGraph(v.validate(rootState), v.strict)
 	if diags.HasErrors() {
 		return diags
 	}
@@ -99,6 +104,9 @@ type state struct {
 	cr         *componentRegistry
 	// arguments registered by module
 	arguments []*ast.BlockStmt
+	// parent is the state of the enclosing block for foreach blocks, which can
+	// reference the components of their parent.
+	parent *state
 }
 
 func (v *validator) validate(s *state) *state {
@@ -181,8 +189,8 @@ func (v *validator) validateConfigs(s *state) {
 			register = true
 		}
 
-		// In configs we store blocks for logging, tracing, argument, export, import.file,
-		// import.string, import.http, import.git and foreach.
+		// In configs we store blocks for logging, tracing, argument, export, function, import.file,
+		// import.string, import.http, import.git, import.s3, import.oci and foreach.
 		switch node.block.GetBlockName() {
 		case "logging":
 			node.args = &logging.Options{}
@@ -231,6 +239,15 @@ func (v *validator) validateConfigs(s *state) {
 				node.diags.Add(diag)
 			}
 
+			s.graph.Add(node)
+		case function.BlockName:
+			node.args = &function.Arguments{}
+			if diag, ok := blockMissingLabel(node.block); ok {
+				node.diags.Add(diag)
+			} else if diag, ok := functionConflict(s, node.block); ok {
+				node.diags.Add(diag)
+			}
+
 			s.graph.Add(node)
 		default:
 			v.validateImport(node, register, s)
@@ -239,13 +256,25 @@ func (v *validator) validateConfigs(s *state) {
 }
 
 func (v *validator) validateImport(node *blockNode, register bool, s *state) {
+	name := node.block.GetBlockName()
+
+	// Check required stability level.
+	if err := featuregate.CheckAllowed(importsource.Stability(name), v.minStability, fmt.Sprintf("config block %q", name)); err != nil {
+		node.diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: node.block.NamePos.Position(),
+			EndPos:   node.block.NamePos.Add(len(name) - 1).Position(),
+			Message:  err.Error(),
+		})
+	}
+
 	// Require label for import block.
 	if diag, ok := blockMissingLabel(node.block); ok {
 		register = false
 		node.diags.Add(diag)
 	}
 
-	switch node.block.GetBlockName() {
+	switch name {
 	case importsource.BlockNameFile:
 		node.args = &importsource.FileArguments{}
 		s.graph.Add(node)
@@ -258,6 +287,12 @@ func (v *validator) validateImport(node *blockNode, register bool, s *state) {
 	case importsource.BlockNameGit:
 		node.args = &importsource.GitArguments{}
 		s.graph.Add(node)
+	case importsource.BlockNameS3:
+		node.args = &importsource.S3Arguments{}
+		s.graph.Add(node)
+	case importsource.BlockNameOCI:
+		node.args = &importsource.OCIArguments{}
+		s.graph.Add(node)
 	}
 
 	if register {
@@ -326,6 +361,7 @@ func (v *validator) validateForeach(node *blockNode, s *state) {
 		services:   services,
 		components: components,
 		cr:         newComponentRegistry(s.cr),
+		parent:     s,
 	})))
 }
 
@@ -394,8 +430,9 @@ func (v *validator) validateServices(s *state) {
 }
 
 var configBlockNames = [...]string{
-	foreach.BlockName, argument.BlockName, export.BlockName, "logging", "tracing",
+	foreach.BlockName, argument.BlockName, export.BlockName, function.BlockName, "logging", "tracing",
 	importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+	importsource.BlockNameS3, importsource.BlockNameOCI,
 }
 
 // extractBlocks extracts configs, declares and components blocks from body
@@ -514,6 +551,37 @@ func blockMissingLabel(b *ast.BlockStmt) (diag.Diagnostic, bool) {
 	return diag.Diagnostic{}, false
 }
 
+// functionConflict returns a diagnostic if the name of the function block b
+// is used by the module arguments, a component namespace or an import
+// namespace of s.
+func functionConflict(s *state, b *ast.BlockStmt) (diag.Diagnostic, bool) {
+	var what string
+	if b.Label == "argument" {
+		what = "the module arguments"
+	}
+	for _, c := range s.components {
+		if c.Name[0] == b.Label {
+			what = fmt.Sprintf("the component namespace %q", b.Label)
+		}
+	}
+	for _, c := range s.configs {
+		if strings.HasPrefix(c.GetBlockName(), "import.") && c.Label == b.Label {
+			what = fmt.Sprintf("the import namespace %q", b.Label)
+		}
+	}
+	if what == "" {
+		return diag.Diagnostic{}, false
+	}
+
+	id := blockID(b)
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		Message:  fmt.Sprintf("function %q conflicts with %s", b.Label, what),
+		StartPos: b.NamePos.Position(),
+		EndPos:   b.NamePos.Add(len(id) - 1).Position(),
+	}, true
+}
+
 func generateArgumentsStruct(args []*ast.BlockStmt) any {
 	fields := make([]reflect.StructField, 0, len(args))
 	for _, a := range args {
@@ -37,11 +37,15 @@ const (
 
 func TestValidate(t *testing.T) {
 	// Test with default config.
-	testDirectory(t, "./testdata/ga", featuregate.StabilityGenerallyAvailable, false)
-	testDirectory(t, "./testdata/default", featuregate.StabilityExperimental, false)
+	testDirectory(t, "./testdata/ga", featuregate.StabilityGenerallyAvailable, false, false)
+	testDirectory(t, "./testdata/default", featuregate.StabilityExperimental, false, false)
 }
 
-func testDirectory(t *testing.T, dir string, minStability featuregate.Stability, enableCommunityComps bool) {
+func TestValidateStrict(t *testing.T) {
+	testDirectory(t, "./testdata/strict", featuregate.StabilityExperimental, false, true)
+}
+
+func testDirectory(t *testing.T, dir string, minStability featuregate.Stability, enableCommunityComps bool, strict bool) {
 	require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, _ error) error {
 		if d.IsDir() && path != dir {
 			return filepath.SkipDir
@@ -69,6 +73,7 @@ func testDirectory(t *testing.T, dir string, minStability featuregate.Stability,
 						&ui.Service{},
 					),
 					MinStability: minStability,
+					Strict:       strict,
 				})
 
 				diagsFile := strings.TrimSuffix(path, txtarSuffix) + diagsSuffix
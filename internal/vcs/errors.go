@@ -45,3 +45,29 @@ type InvalidRevisionError struct {
 func (err InvalidRevisionError) Error() string {
 	return fmt.Sprintf("invalid revision \"%s\"", err.Revision)
 }
+
+// SignatureError represents a revision which isn't signed by a trusted key.
+type SignatureError struct {
+	Revision string
+	Inner    error
+}
+
+// Error returns the error string, denoting the unverified revision.
+func (err SignatureError) Error() string {
+	return fmt.Sprintf("failed to verify the signature of revision %q: %s", err.Revision, err.Inner)
+}
+
+// Unwrap returns the inner error.
+func (err SignatureError) Unwrap() error { return err.Inner }
+
+// NoMatchingVersionError represents a version constraint which isn't matched
+// by any tag of a repository.
+type NoMatchingVersionError struct {
+	Repository string
+	Constraint string
+}
+
+// Error returns the error string, denoting the unmatched constraint.
+func (err NoMatchingVersionError) Error() string {
+	return fmt.Sprintf("no tag of repository %q matches version %q", err.Repository, err.Constraint)
+}
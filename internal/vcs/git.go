@@ -16,7 +16,15 @@ import (
 type GitRepoOptions struct {
 	Repository string
 	Revision   string
-	Auth       GitAuthConfig
+	// Version is a semantic version constraint, such as "~> 1.4". When set,
+	// the highest tag matching the constraint is checked out instead of
+	// Revision.
+	Version string
+	// Tag is the tag Revision was resolved from, such as the tag of a locked
+	// version when Revision is its commit. VerifySignature accepts its
+	// signature as long as it points to the checked out commit.
+	Tag  string
+	Auth GitAuthConfig
 }
 
 // GitRepo manages a Git repository for the purposes of retrieving a file from
@@ -26,6 +34,7 @@ type GitRepo struct {
 	repo     *git.Repository
 	workTree *git.Worktree
 	auth     transport.AuthMethod
+	revision string // Revision checked out by the last update.
 }
 
 // NewGitRepo creates a new instance of a GitRepo, where the Git repository is
@@ -33,7 +42,8 @@ type GitRepo struct {
 //
 // 1. If storagePath is empty on disk, NewGitRepo initializes GitRepo by cloning the repository.
 // 2. After GitRepo is initialized/opened, a git fetch is don.
-// 3. Then, a git checkout is done to the Revision specified in GitRepoOptions.
+// 3. Then, a git checkout is done to the Revision specified in GitRepoOptions,
+// or to the highest tag matching its Version.
 func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*GitRepo, error) {
 	var (
 		repo *git.Repository
@@ -98,11 +108,16 @@ func isRepoCloned(dir string) bool {
 // Update updates the repository by pulling new content and re-checking out to
 // latest version of Revision.
 func (repo *GitRepo) Update(ctx context.Context) error {
-	fetchErr := repo.repo.FetchContext(ctx, &git.FetchOptions{
+	fetchOpts := &git.FetchOptions{
 		RemoteName: "origin",
 		Force:      true,
 		Auth:       repo.auth,
-	})
+	}
+	if repo.opts.Version != "" || repo.opts.Tag != "" {
+		// New versions are only visible through new tags.
+		fetchOpts.Tags = git.AllTags
+	}
+	fetchErr := repo.repo.FetchContext(ctx, fetchOpts)
 	if fetchErr != nil && !errors.Is(fetchErr, git.NoErrAlreadyUpToDate) {
 		return UpdateFailedError{
 			Repository: repo.opts.Repository,
@@ -110,10 +125,19 @@ func (repo *GitRepo) Update(ctx context.Context) error {
 		}
 	}
 
-	checkoutErr := checkout(repo.opts.Revision, repo.repo)
+	rev := repo.opts.Revision
+	if repo.opts.Version != "" {
+		tag, err := resolveVersion(repo.repo, repo.opts.Repository, repo.opts.Version)
+		if err != nil {
+			return err
+		}
+		rev = tag
+	}
+
+	checkoutErr := checkout(rev, repo.repo)
 	if checkoutErr != nil {
 		if errors.Is(checkoutErr, plumbing.ErrReferenceNotFound) {
-			return InvalidRevisionError{repo.opts.Revision}
+			return InvalidRevisionError{rev}
 		}
 
 		return UpdateFailedError{
@@ -122,9 +146,16 @@ func (repo *GitRepo) Update(ctx context.Context) error {
 		}
 	}
 
+	repo.revision = rev
 	return nil
 }
 
+// Revision returns the revision checked out by the last update. It's the tag
+// matching the version constraint when GitRepoOptions.Version is set.
+func (repo *GitRepo) Revision() string {
+	return repo.revision
+}
+
 // ReadFile returns a file from the repository specified by path.
 func (repo *GitRepo) ReadFile(path string) ([]byte, error) {
 	f, err := repo.workTree.Filesystem.Open(path)
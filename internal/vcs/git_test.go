@@ -80,6 +80,7 @@ type testRepository struct {
 	worktree    *git.Worktree
 	commitCount uint
 	filename    string
+	signer      git.Signer // Optional signer of new commits.
 }
 
 func (repo *testRepository) CurrentRef() (string, error) {
@@ -176,7 +177,7 @@ func (r *testRepository) commit() string {
 	_, err = r.worktree.Add(".")
 	require.NoError(r.t, err)
 
-	_, err = r.worktree.Commit(msg, &git.CommitOptions{})
+	_, err = r.worktree.Commit(msg, &git.CommitOptions{Signer: r.signer})
 	require.NoError(r.t, err)
 
 	return msg
@@ -0,0 +1,215 @@
+package vcs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// SigningKeys are the public keys trusted to sign the revision of a
+// repository.
+type SigningKeys struct {
+	GPGKeyRing    string   // Armored GPG public keys.
+	SSHPublicKeys []string // SSH public keys in the authorized_keys format.
+}
+
+// Empty returns true if no key is set.
+func (keys SigningKeys) Empty() bool {
+	return keys.GPGKeyRing == "" && len(keys.SSHPublicKeys) == 0
+}
+
+// VerifySignature verifies that the checked out revision is signed by one of
+// keys. The revision is verified if it's an annotated tag with a valid
+// signature, or if the commit it points to has a valid signature. When
+// GitRepoOptions.Tag is set, that tag is verified instead of the revision, if
+// it still points to the checked out commit.
+//
+// Commits and tags can be signed with GPG or SSH keys.
+func (repo *GitRepo) VerifySignature(keys SigningKeys) error {
+	head, err := repo.repo.Head()
+	if err != nil {
+		return err
+	}
+
+	tagName := repo.revision
+	if repo.opts.Tag != "" {
+		tagName = repo.opts.Tag
+	}
+
+	var tagErr error
+	if ref, err := repo.repo.Tag(tagName); err == nil {
+		if tag, err := repo.repo.TagObject(ref.Hash()); err == nil {
+			if tag.Target != head.Hash() {
+				tagErr = fmt.Errorf("tag %s points to %s instead of commit %s", tagName, tag.Target, head.Hash())
+			} else if tagErr = verifyObject(tag, tag.PGPSignature, keys); tagErr == nil {
+				return nil
+			}
+		}
+	}
+
+	commit, err := repo.repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	if err := verifyObject(commit, commit.PGPSignature, keys); err != nil {
+		if tagErr != nil {
+			return SignatureError{Revision: repo.revision, Inner: errors.Join(tagErr, err)}
+		}
+		return SignatureError{Revision: repo.revision, Inner: err}
+	}
+	return nil
+}
+
+// signedObject is a git object which can be signed.
+type signedObject interface {
+	ID() plumbing.Hash
+	Type() plumbing.ObjectType
+	EncodeWithoutSignature(o plumbing.EncodedObject) error
+}
+
+var (
+	_ signedObject = (*object.Commit)(nil)
+	_ signedObject = (*object.Tag)(nil)
+)
+
+func verifyObject(obj signedObject, signature string, keys SigningKeys) error {
+	if signature == "" {
+		return fmt.Errorf("%s %s is not signed", obj.Type(), obj.ID())
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := obj.EncodeWithoutSignature(encoded); err != nil {
+		return err
+	}
+	r, err := encoded.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var payload bytes.Buffer
+	if _, err := payload.ReadFrom(r); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(signature, sshSignatureHeader) {
+		err = verifySSHSignature(payload.Bytes(), signature, keys.SSHPublicKeys)
+	} else {
+		err = verifyGPGSignature(obj, keys.GPGKeyRing)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", obj.Type(), obj.ID(), err)
+	}
+	return nil
+}
+
+func verifyGPGSignature(obj signedObject, keyRing string) error {
+	if keyRing == "" {
+		return fmt.Errorf("signed with a GPG key, but no GPG key is trusted")
+	}
+
+	var err error
+	switch obj := obj.(type) {
+	case *object.Commit:
+		_, err = obj.Verify(keyRing)
+	case *object.Tag:
+		_, err = obj.Verify(keyRing)
+	}
+	return err
+}
+
+const (
+	sshSignatureHeader    = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureFooter    = "-----END SSH SIGNATURE-----"
+	sshSignatureMagic     = "SSHSIG"
+	sshSignatureNamespace = "git"
+)
+
+// verifySSHSignature verifies an armored SSH signature of payload, as created
+// by git with gpg.format=ssh.
+func verifySSHSignature(payload []byte, signature string, publicKeys []string) error {
+	if len(publicKeys) == 0 {
+		return fmt.Errorf("signed with an SSH key, but no SSH key is trusted")
+	}
+
+	armored := strings.TrimSpace(signature)
+	armored = strings.TrimPrefix(armored, sshSignatureHeader)
+	armored = strings.TrimSuffix(armored, sshSignatureFooter)
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(armored), ""))
+	if err != nil {
+		return fmt.Errorf("decoding SSH signature: %w", err)
+	}
+
+	rest, ok := bytes.CutPrefix(blob, []byte(sshSignatureMagic))
+	if !ok {
+		return fmt.Errorf("invalid SSH signature")
+	}
+	var sig struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+	if err := ssh.Unmarshal(rest, &sig); err != nil {
+		return fmt.Errorf("decoding SSH signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return fmt.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	if sig.Namespace != sshSignatureNamespace {
+		return fmt.Errorf("unexpected SSH signature namespace %q", sig.Namespace)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("parsing SSH signature public key: %w", err)
+	}
+	if !trustedSSHKey(publicKey, publicKeys) {
+		return fmt.Errorf("signed with an untrusted SSH key %s", ssh.FingerprintSHA256(publicKey))
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sig.Namespace, sig.Reserved, sig.HashAlgorithm, h.Sum(nil)})...)
+
+	var sshSig ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &sshSig); err != nil {
+		return fmt.Errorf("decoding SSH signature: %w", err)
+	}
+	return publicKey.Verify(signed, &sshSig)
+}
+
+func trustedSSHKey(key ssh.PublicKey, publicKeys []string) bool {
+	for _, pk := range publicKeys {
+		trusted, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pk))
+		if err == nil && bytes.Equal(trusted.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package vcs_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/grafana/alloy/internal/vcs"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestVerifySignature_SSH(t *testing.T) {
+	repo, repoDirectory := initRepository(t, "main")
+
+	trusted, trustedKey := newSSHSigner(t)
+	other, _ := newSSHSigner(t)
+	keys := vcs.SigningKeys{SSHPublicKeys: []string{trustedKey}}
+
+	tracker, err := vcs.NewGitRepo(t.Context(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: repoDirectory,
+		Revision:   "main",
+	})
+	require.NoError(t, err)
+
+	// The initial commit isn't signed.
+	var sigErr vcs.SignatureError
+	require.ErrorAs(t, tracker.VerifySignature(keys), &sigErr)
+
+	repo.signer = trusted
+	repo.commit()
+	require.NoError(t, tracker.Update(t.Context()))
+	require.NoError(t, tracker.VerifySignature(keys))
+
+	// Signatures are only valid for trusted keys.
+	require.ErrorAs(t, tracker.VerifySignature(vcs.SigningKeys{}), &sigErr)
+
+	repo.signer = other
+	repo.commit()
+	require.NoError(t, tracker.Update(t.Context()))
+	require.ErrorContains(t, tracker.VerifySignature(keys), "untrusted SSH key")
+}
+
+func TestVerifySignature_GPGTag(t *testing.T) {
+	repo, repoDirectory := initRepository(t, "main")
+
+	entity, err := openpgp.NewEntity("Go test", "", "go-test@example.com", nil)
+	require.NoError(t, err)
+	keys := vcs.SigningKeys{GPGKeyRing: armoredPublicKey(t, entity)}
+
+	head, err := repo.repo.Head()
+	require.NoError(t, err)
+	_, err = repo.repo.CreateTag("v1.0.0", head.Hash(), &git.CreateTagOptions{
+		Message: "v1.0.0",
+		SignKey: entity,
+	})
+	require.NoError(t, err)
+
+	// The tag is signed even if the commit it points to isn't.
+	tracker, err := vcs.NewGitRepo(t.Context(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: repoDirectory,
+		Revision:   "v1.0.0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, tracker.VerifySignature(keys))
+
+	other, err := openpgp.NewEntity("Other", "", "other@example.com", nil)
+	require.NoError(t, err)
+	require.Error(t, tracker.VerifySignature(vcs.SigningKeys{GPGKeyRing: armoredPublicKey(t, other)}))
+}
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) string {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+// sshSigner signs git objects with an SSH key, like git with gpg.format=ssh.
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+func newSSHSigner(t *testing.T) (*sshSigner, string) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	return &sshSigner{signer: signer}, string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+
+	signed := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{"git", "", "sha512", h.Sum(nil)})...)
+	sig, err := s.signer.Sign(rand.Reader, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, s.signer.PublicKey().Marshal(), "git", "", "sha512", ssh.Marshal(sig)})...)
+
+	var buf bytes.Buffer
+	buf.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	for len(encoded) > 70 {
+		buf.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	buf.WriteString(encoded + "\n-----END SSH SIGNATURE-----\n")
+	return buf.Bytes(), nil
+}
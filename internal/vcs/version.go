@@ -0,0 +1,55 @@
+package vcs
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/hashicorp/go-version"
+)
+
+// ValidateVersion returns an error if constraint isn't a valid semantic
+// version constraint, such as "~> 1.4" or ">= 1.2, < 2.0".
+func ValidateVersion(constraint string) error {
+	_, err := version.NewConstraint(constraint)
+	return err
+}
+
+// resolveVersion returns the name of the tag of repo with the highest
+// semantic version matching constraint. Tags which aren't semantic versions
+// are ignored, and so are pre-releases unless the constraint refers to one.
+func resolveVersion(repo *git.Repository, repository, constraint string) (string, error) {
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		bestTag     string
+		bestVersion *version.Version
+	)
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		v, err := version.NewSemver(name)
+		if err != nil || !constraints.Check(v) {
+			return nil
+		}
+		if bestVersion == nil || v.GreaterThan(bestVersion) {
+			bestTag, bestVersion = name, v
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if bestVersion == nil {
+		return "", NoMatchingVersionError{Repository: repository, Constraint: constraint}
+	}
+	return bestTag, nil
+}
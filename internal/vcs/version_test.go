@@ -0,0 +1,59 @@
+package vcs_test
+
+import (
+	"testing"
+
+	"github.com/grafana/alloy/internal/vcs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	repo, repoDirectory := initRepository(t, "main")
+
+	tag := func(name string) string {
+		msg := repo.commit()
+		head, err := repo.repo.Head()
+		require.NoError(t, err)
+		_, err = repo.repo.CreateTag(name, head.Hash(), nil)
+		require.NoError(t, err)
+		return msg
+	}
+	tag("v1.3.0")
+	msg140 := tag("v1.4.0")
+	tag("v2.0.0")
+	repo.commit()
+
+	tracker, err := vcs.NewGitRepo(t.Context(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: repoDirectory,
+		Version:    "~> 1.4",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v1.4.0", tracker.Revision())
+	repo.validate(tracker, msg140)
+
+	// New matching tags are picked up on update, but pre-releases and
+	// non-matching versions are ignored.
+	msg141 := tag("v1.4.1")
+	tag("v1.5.0-rc.1")
+	tag("not-a-version")
+	require.NoError(t, tracker.Update(t.Context()))
+	require.Equal(t, "v1.4.1", tracker.Revision())
+	repo.validate(tracker, msg141)
+}
+
+func TestVersion_NoMatch(t *testing.T) {
+	repo, repoDirectory := initRepository(t, "main")
+	repo.commit()
+
+	_, err := vcs.NewGitRepo(t.Context(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: repoDirectory,
+		Version:    ">= 3.0",
+	})
+	require.ErrorAs(t, err, &vcs.NoMatchingVersionError{})
+}
+
+func TestValidateVersion(t *testing.T) {
+	require.NoError(t, vcs.ValidateVersion("~> 1.4"))
+	require.NoError(t, vcs.ValidateVersion(">= 1.2, < 2.0"))
+	require.Error(t, vcs.ValidateVersion("latest"))
+}
@@ -7,6 +7,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"path"
@@ -18,11 +19,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service"
 	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/ckit/peer"
 	"github.com/prometheus/prometheus/util/httputil"
 )
 
@@ -31,11 +34,13 @@ type AlloyAPI struct {
 	alloy           service.Host
 	CallbackManager livedebugging.CallbackManager
 	logger          log.Logger
+	enablePlan      bool
 }
 
-// NewAlloyAPI instantiates a new Alloy API.
-func NewAlloyAPI(alloy service.Host, CallbackManager livedebugging.CallbackManager, l log.Logger) *AlloyAPI {
-	return &AlloyAPI{alloy: alloy, CallbackManager: CallbackManager, logger: l}
+// NewAlloyAPI instantiates a new Alloy API. The plan endpoint, which evaluates
+// the posted configuration, is only served if enablePlan is true.
+func NewAlloyAPI(alloy service.Host, CallbackManager livedebugging.CallbackManager, l log.Logger, enablePlan bool) *AlloyAPI {
+	return &AlloyAPI{alloy: alloy, CallbackManager: CallbackManager, logger: l, enablePlan: enablePlan}
 }
 
 // RegisterRoutes registers all the API's routes.
@@ -53,6 +58,11 @@ func (a *AlloyAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), httputil.CompressionHandler{Handler: getComponentHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/remotecfg/components/{id:.+}"), httputil.CompressionHandler{Handler: getComponentHandlerRemoteCfg(a.alloy)})
 
+	r.Handle(path.Join(urlPrefix, "/schema"), httputil.CompressionHandler{Handler: listSchemasHandler()})
+	r.Handle(path.Join(urlPrefix, "/schema/{name}"), httputil.CompressionHandler{Handler: getSchemaHandler()})
+
+	r.Handle(path.Join(urlPrefix, "/plan"), planHandler(a.alloy, a.enablePlan))
+
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: getClusteringPeersHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/debug/{id:.+}"), liveDebugging(a.alloy, a.CallbackManager, a.logger))
 
@@ -140,6 +150,7 @@ func getComponentHandlerInternal(host service.Host, w http.ResponseWriter, r *ht
 		GetArguments: true,
 		GetExports:   true,
 		GetDebugInfo: true,
+		GetResources: true,
 	})
 	if err != nil {
 		http.NotFound(w, r)
@@ -154,6 +165,82 @@ func getComponentHandlerInternal(host service.Host, w http.ResponseWriter, r *ht
 	_, _ = w.Write(bb)
 }
 
+func listSchemasHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		bb, err := json.Marshal(component.AllSchemas())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+func getSchemaHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reg, ok := component.Get(mux.Vars(r)["name"])
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		bb, err := json.Marshal(reg.Schema())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// planner is implemented by the runtime, which can compute the changes a new
+// configuration would make to its components.
+type planner interface {
+	Plan(source *runtime.Source) *runtime.Plan
+}
+
+// maxPlanBodySize is the maximum size of the configurations accepted by the
+// plan endpoint.
+const maxPlanBodySize = 10 << 20
+
+func planHandler(host service.Host, enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !enabled {
+			http.Error(w, "planning is disabled; it can be enabled with the --server.http.enable-plan flag", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		p, ok := host.(planner)
+		if !ok {
+			http.Error(w, "planning is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		bb, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPlanBodySize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		source, err := runtime.ParseSource("config.alloy", bb)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		bb, err = json.Marshal(p.Plan(source))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
 func getClusteringPeersHandler(host service.Host) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
 		// TODO(@tpaschalis) Detect if clustering is disabled and propagate to
@@ -163,8 +250,15 @@ func getClusteringPeersHandler(host service.Host) http.HandlerFunc {
 			http.Error(w, "cluster service not running", http.StatusInternalServerError)
 			return
 		}
-		peers := svc.Data().(cluster.Cluster).Peers()
-		bb, err := json.Marshal(peers)
+		c := svc.Data().(cluster.Cluster)
+		owners := cluster.SingletonOwners(c)
+
+		peers := c.Peers()
+		res := make([]peerInfo, 0, len(peers))
+		for _, p := range peers {
+			res = append(res, peerInfo{Peer: p, Singletons: owners[p.Name]})
+		}
+		bb, err := json.Marshal(res)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -173,6 +267,30 @@ func getClusteringPeersHandler(host service.Host) http.HandlerFunc {
 	}
 }
 
+// peerInfo is a peer of the cluster along with the IDs of the singleton
+// components it owns.
+type peerInfo struct {
+	peer.Peer
+	Singletons []string
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (p peerInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name       string   `json:"name"`
+		Addr       string   `json:"addr"`
+		Self       bool     `json:"isSelf"`
+		State      string   `json:"state"`
+		Singletons []string `json:"singletons,omitempty"`
+	}{
+		Name:       p.Name,
+		Addr:       p.Addr,
+		Self:       p.Self,
+		State:      p.State.String(),
+		Singletons: p.Singletons,
+	})
+}
+
 type dataKey struct {
 	ComponentID livedebugging.ComponentID
 	Type        livedebugging.DataType
@@ -8,7 +8,7 @@ interface PeerListProps {
   peers: PeerInfo[];
 }
 
-const TABLEHEADERS = ['Node Name', 'Advertised Address', 'Current State', 'Local Node'];
+const TABLEHEADERS = ['Node Name', 'Advertised Address', 'Current State', 'Local Node', 'Singletons'];
 
 const PeerList = ({ peers }: PeerListProps) => {
   const tableStyles = { width: '130px' };
@@ -17,7 +17,7 @@ const PeerList = ({ peers }: PeerListProps) => {
    * Custom renderer for table data
    */
   const renderTableData = () => {
-    return peers.map(({ name, addr, state, isSelf }) => (
+    return peers.map(({ name, addr, state, isSelf, singletons }) => (
       <tr key={name} style={{ lineHeight: '2.5' }}>
         <td>
           <span className={styles.idName}>{name}</span>
@@ -31,6 +31,9 @@ const PeerList = ({ peers }: PeerListProps) => {
         <td>
           <span> {isSelf ? '✅' : ' '}</span>
         </td>
+        <td>
+          <span className={styles.idName}>{singletons?.join(', ')}</span>
+        </td>
       </tr>
     ));
   };
@@ -6,4 +6,9 @@ export interface PeerInfo {
   state: string;
 
   isSelf: boolean;
+
+  /**
+   * IDs of the singleton components owned by the peer.
+   */
+  singletons?: string[];
 }
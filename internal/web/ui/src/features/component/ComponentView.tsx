@@ -79,6 +79,13 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           {argsPartition && partitionTOC(argsPartition)}
           {exportsPartition && partitionTOC(exportsPartition)}
           {debugPartition && partitionTOC(debugPartition)}
+          {props.component.resources && (
+            <li>
+              <Link to="#resources" target="_top">
+                Resource usage
+              </Link>
+            </li>
+          )}
           {props.component.referencesTo.length > 0 && (
             <li>
               <Link to="#dependencies" target="_top">
@@ -147,6 +154,32 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
         {exportsPartition && <ComponentBody partition={exportsPartition} />}
         {debugPartition && <ComponentBody partition={debugPartition} />}
 
+        {props.component.resources && (
+          <section id="resources">
+            <h2>
+              Resource usage <span className={styles.updateTime}>({props.component.resources.updatedTime})</span>
+            </h2>
+            <div className={styles.sectionContent}>
+              <table>
+                <tbody>
+                  <tr>
+                    <td className={styles.nameColumn}>Goroutines</td>
+                    <td>{props.component.resources.goroutines}</td>
+                  </tr>
+                  <tr>
+                    <td className={styles.nameColumn}>CPU cores</td>
+                    <td>{props.component.resources.cpuCores.toFixed(3)}</td>
+                  </tr>
+                  <tr>
+                    <td className={styles.nameColumn}>Heap in use by {props.component.name} components</td>
+                    <td>{formatBytes(props.component.resources.heapInuseBytes)}</td>
+                  </tr>
+                </tbody>
+              </table>
+            </div>
+          </section>
+        )}
+
         {props.component.referencesTo.length > 0 && (
           <section id="dependencies">
             <h2>Dependencies</h2>
@@ -187,6 +220,16 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
   );
 };
 
+function formatBytes(bytes: number): string {
+  const units = ['B', 'KiB', 'MiB', 'GiB'];
+  let i = 0;
+  while (bytes >= 1024 && i < units.length - 1) {
+    bytes /= 1024;
+    i++;
+  }
+  return `${bytes.toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+}
+
 function pathJoin(paths: (string | undefined)[]): string {
   return paths.filter((p) => p && p !== '').join('/');
 }
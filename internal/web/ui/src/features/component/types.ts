@@ -116,12 +116,39 @@ export interface ComponentDetail extends ComponentInfo {
    */
   createdModuleIDs?: string[];
 
+  /**
+   * Approximate resource usage of the component. Only set when the resource
+   * usage of components is tracked.
+   */
+  resources?: ComponentResources;
+
   /**
    * If a component is a module loader, the loaded components from the module are included here.
    */
   moduleInfo?: ComponentInfo[];
 }
 
+/**
+ * ComponentResources is the approximate resource usage of a component, sampled
+ * from profiles of the process.
+ */
+export interface ComponentResources {
+  /** Number of goroutines running the component. */
+  goroutines: number;
+
+  /** Average number of CPU cores used during the last CPU profile. */
+  cpuCores: number;
+
+  /**
+   * Size of the heap in use allocated by the code of the component, shared by
+   * all the components with the same name.
+   */
+  heapInuseBytes: number;
+
+  /** Time the resource usage was sampled. */
+  updatedTime: string;
+}
+
 export interface PartitionedBody {
   /** key is a list of unique identifiers for this partitioned body. */
   key: string[];
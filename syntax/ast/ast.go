@@ -176,6 +176,32 @@ type ParenExpr struct {
 	Secret bool
 }
 
+// ConditionalExpr selects between two values based on a boolean condition.
+// Only the selected value is evaluated.
+type ConditionalExpr struct {
+	Condition, TrueValue, FalseValue Expr
+	QuestionPos, ColonPos            token.Pos
+
+	Secret bool
+}
+
+// ComprehensionExpr builds an array or an object by iterating over the
+// elements of a collection.
+//
+// When KeyVar is set, it holds the index of array elements or the key of
+// object fields. Key is only set for object comprehensions.
+type ComprehensionExpr struct {
+	Object           bool // True if the comprehension produces an object
+	KeyVar, ValueVar *Ident
+	Collection       Expr
+	Key, Value       Expr
+	Cond             Expr // Optional filter condition
+
+	OpenPos, InPos, ColonPos, AssignPos, IfPos, ClosePos token.Pos
+
+	Secret bool
+}
+
 // Type assertions
 
 var (
@@ -194,6 +220,8 @@ var (
 	_ Node = (*UnaryExpr)(nil)
 	_ Node = (*BinaryExpr)(nil)
 	_ Node = (*ParenExpr)(nil)
+	_ Node = (*ConditionalExpr)(nil)
+	_ Node = (*ComprehensionExpr)(nil)
 
 	_ Stmt = (*AttributeStmt)(nil)
 	_ Stmt = (*BlockStmt)(nil)
@@ -208,61 +236,71 @@ var (
 	_ Expr = (*UnaryExpr)(nil)
 	_ Expr = (*BinaryExpr)(nil)
 	_ Expr = (*ParenExpr)(nil)
+	_ Expr = (*ConditionalExpr)(nil)
+	_ Expr = (*ComprehensionExpr)(nil)
 )
 
-func (n *File) astNode()           {}
-func (n Body) astNode()            {}
-func (n CommentGroup) astNode()    {}
-func (n *Comment) astNode()        {}
-func (n *AttributeStmt) astNode()  {}
-func (n *BlockStmt) astNode()      {}
-func (n *Ident) astNode()          {}
-func (n *IdentifierExpr) astNode() {}
-func (n *LiteralExpr) astNode()    {}
-func (n *ArrayExpr) astNode()      {}
-func (n *ObjectExpr) astNode()     {}
-func (n *AccessExpr) astNode()     {}
-func (n *IndexExpr) astNode()      {}
-func (n *CallExpr) astNode()       {}
-func (n *UnaryExpr) astNode()      {}
-func (n *BinaryExpr) astNode()     {}
-func (n *ParenExpr) astNode()      {}
+func (n *File) astNode()              {}
+func (n Body) astNode()               {}
+func (n CommentGroup) astNode()       {}
+func (n *Comment) astNode()           {}
+func (n *AttributeStmt) astNode()     {}
+func (n *BlockStmt) astNode()         {}
+func (n *Ident) astNode()             {}
+func (n *IdentifierExpr) astNode()    {}
+func (n *LiteralExpr) astNode()       {}
+func (n *ArrayExpr) astNode()         {}
+func (n *ObjectExpr) astNode()        {}
+func (n *AccessExpr) astNode()        {}
+func (n *IndexExpr) astNode()         {}
+func (n *CallExpr) astNode()          {}
+func (n *UnaryExpr) astNode()         {}
+func (n *BinaryExpr) astNode()        {}
+func (n *ParenExpr) astNode()         {}
+func (n *ConditionalExpr) astNode()   {}
+func (n *ComprehensionExpr) astNode() {}
 
 func (n *AttributeStmt) astStmt() {}
 func (n *BlockStmt) astStmt()     {}
 
-func (n *IdentifierExpr) astExpr() {}
-func (n *LiteralExpr) astExpr()    {}
-func (n *ArrayExpr) astExpr()      {}
-func (n *ObjectExpr) astExpr()     {}
-func (n *AccessExpr) astExpr()     {}
-func (n *IndexExpr) astExpr()      {}
-func (n *CallExpr) astExpr()       {}
-func (n *UnaryExpr) astExpr()      {}
-func (n *BinaryExpr) astExpr()     {}
-func (n *ParenExpr) astExpr()      {}
-
-func (n *IdentifierExpr) IsSecret() bool { return n.Secret }
-func (n *LiteralExpr) IsSecret() bool    { return n.Secret }
-func (n *ArrayExpr) IsSecret() bool      { return n.Secret }
-func (n *ObjectExpr) IsSecret() bool     { return n.Secret }
-func (n *AccessExpr) IsSecret() bool     { return n.Secret }
-func (n *IndexExpr) IsSecret() bool      { return n.Secret }
-func (n *CallExpr) IsSecret() bool       { return n.Secret }
-func (n *UnaryExpr) IsSecret() bool      { return n.Secret }
-func (n *BinaryExpr) IsSecret() bool     { return n.Secret }
-func (n *ParenExpr) IsSecret() bool      { return n.Secret }
-
-func (n *IdentifierExpr) SetSecret(s bool) { n.Secret = s }
-func (n *LiteralExpr) SetSecret(s bool)    { n.Secret = s }
-func (n *ArrayExpr) SetSecret(s bool)      { n.Secret = s }
-func (n *ObjectExpr) SetSecret(s bool)     { n.Secret = s }
-func (n *AccessExpr) SetSecret(s bool)     { n.Secret = s }
-func (n *IndexExpr) SetSecret(s bool)      { n.Secret = s }
-func (n *CallExpr) SetSecret(s bool)       { n.Secret = s }
-func (n *UnaryExpr) SetSecret(s bool)      { n.Secret = s }
-func (n *BinaryExpr) SetSecret(s bool)     { n.Secret = s }
-func (n *ParenExpr) SetSecret(s bool)      { n.Secret = s }
+func (n *IdentifierExpr) astExpr()    {}
+func (n *LiteralExpr) astExpr()       {}
+func (n *ArrayExpr) astExpr()         {}
+func (n *ObjectExpr) astExpr()        {}
+func (n *AccessExpr) astExpr()        {}
+func (n *IndexExpr) astExpr()         {}
+func (n *CallExpr) astExpr()          {}
+func (n *UnaryExpr) astExpr()         {}
+func (n *BinaryExpr) astExpr()        {}
+func (n *ParenExpr) astExpr()         {}
+func (n *ConditionalExpr) astExpr()   {}
+func (n *ComprehensionExpr) astExpr() {}
+
+func (n *IdentifierExpr) IsSecret() bool    { return n.Secret }
+func (n *LiteralExpr) IsSecret() bool       { return n.Secret }
+func (n *ArrayExpr) IsSecret() bool         { return n.Secret }
+func (n *ObjectExpr) IsSecret() bool        { return n.Secret }
+func (n *AccessExpr) IsSecret() bool        { return n.Secret }
+func (n *IndexExpr) IsSecret() bool         { return n.Secret }
+func (n *CallExpr) IsSecret() bool          { return n.Secret }
+func (n *UnaryExpr) IsSecret() bool         { return n.Secret }
+func (n *BinaryExpr) IsSecret() bool        { return n.Secret }
+func (n *ParenExpr) IsSecret() bool         { return n.Secret }
+func (n *ConditionalExpr) IsSecret() bool   { return n.Secret }
+func (n *ComprehensionExpr) IsSecret() bool { return n.Secret }
+
+func (n *IdentifierExpr) SetSecret(s bool)    { n.Secret = s }
+func (n *LiteralExpr) SetSecret(s bool)       { n.Secret = s }
+func (n *ArrayExpr) SetSecret(s bool)         { n.Secret = s }
+func (n *ObjectExpr) SetSecret(s bool)        { n.Secret = s }
+func (n *AccessExpr) SetSecret(s bool)        { n.Secret = s }
+func (n *IndexExpr) SetSecret(s bool)         { n.Secret = s }
+func (n *CallExpr) SetSecret(s bool)          { n.Secret = s }
+func (n *UnaryExpr) SetSecret(s bool)         { n.Secret = s }
+func (n *BinaryExpr) SetSecret(s bool)        { n.Secret = s }
+func (n *ParenExpr) SetSecret(s bool)         { n.Secret = s }
+func (n *ConditionalExpr) SetSecret(s bool)   { n.Secret = s }
+func (n *ComprehensionExpr) SetSecret(s bool) { n.Secret = s }
 
 // StartPos returns the position of the first character belonging to a Node.
 func StartPos(n Node) token.Pos {
@@ -310,6 +348,10 @@ func StartPos(n Node) token.Pos {
 		return StartPos(n.Left)
 	case *ParenExpr:
 		return n.LParenPos
+	case *ConditionalExpr:
+		return StartPos(n.Condition)
+	case *ComprehensionExpr:
+		return n.OpenPos
 	default:
 		panic(fmt.Sprintf("Unhandled Node type %T", n))
 	}
@@ -361,11 +403,31 @@ func EndPos(n Node) token.Pos {
 		return EndPos(n.Right)
 	case *ParenExpr:
 		return n.RParenPos
+	case *ConditionalExpr:
+		return EndPos(n.FalseValue)
+	case *ComprehensionExpr:
+		return n.ClosePos
 	default:
 		panic(fmt.Sprintf("Unhandled Node type %T", n))
 	}
 }
 
+// Traversal returns the identifiers of an uninterrupted sequence of field
+// accesses such as a.b.c. It returns false if expr is any other expression.
+func Traversal(expr Expr) ([]*Ident, bool) {
+	switch expr := expr.(type) {
+	case *IdentifierExpr:
+		return []*Ident{expr.Ident}, true
+	case *AccessExpr:
+		traversal, ok := Traversal(expr.Value)
+		if !ok {
+			return nil, false
+		}
+		return append(traversal, expr.Name), true
+	}
+	return nil, false
+}
+
 // GetBlockName retrieves the "." delimited block name.
 func (block *BlockStmt) GetBlockName() string {
 	return strings.Join(block.Name, ".")
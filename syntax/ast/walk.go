@@ -65,6 +65,23 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Right)
 	case *ParenExpr:
 		Walk(v, n.Inner)
+	case *ConditionalExpr:
+		Walk(v, n.Condition)
+		Walk(v, n.TrueValue)
+		Walk(v, n.FalseValue)
+	case *ComprehensionExpr:
+		if n.KeyVar != nil {
+			Walk(v, n.KeyVar)
+		}
+		Walk(v, n.ValueVar)
+		Walk(v, n.Collection)
+		if n.Key != nil {
+			Walk(v, n.Key)
+		}
+		Walk(v, n.Value)
+		if n.Cond != nil {
+			Walk(v, n.Cond)
+		}
 	default:
 		panic(fmt.Sprintf("syntax/ast: unexpected node type %T", n))
 	}
@@ -0,0 +1,311 @@
+package stdlib
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// distinct returns the elements of an array with duplicates removed. The
+// first occurrence of each element is kept. Elements are compared by value,
+// except for capsules which are compared by identity and functions which are
+// never equal.
+var distinct = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		in   = args[0]
+		seen = make(map[string]struct{}, in.Len())
+		res  = make([]value.Value, 0, in.Len())
+		keys distinctKeys
+	)
+	for i := 0; i < in.Len(); i++ {
+		elem := in.Index(i)
+
+		var sb strings.Builder
+		keys.write(&sb, elem)
+		if _, ok := seen[sb.String()]; ok {
+			continue
+		}
+		seen[sb.String()] = struct{}{}
+		res = append(res, elem)
+	}
+	return value.Array(res...), nil
+})
+
+// distinctKeys builds strings which uniquely identify values.
+type distinctKeys struct {
+	ids map[any]int // Identifiers of the Go values held by capsules.
+}
+
+// write writes a string to sb which uniquely identifies the content of v. Two
+// values get the same key if and only if they're equal. Numbers are keyed by
+// their text representation so that 1 and 1.0 are considered equal.
+func (k *distinctKeys) write(sb *strings.Builder, v value.Value) {
+	if obj, ok := v.TryConvertToObject(); ok {
+		v = value.Object(obj)
+	}
+
+	switch v.Type() {
+	case value.TypeNull:
+		sb.WriteString("null")
+	case value.TypeNumber:
+		sb.WriteString("n:")
+		sb.WriteString(v.Number().ToString())
+	case value.TypeString:
+		sb.WriteString("s:")
+		sb.WriteString(strconv.Quote(v.Text()))
+	case value.TypeBool:
+		sb.WriteString("b:")
+		sb.WriteString(strconv.FormatBool(v.Bool()))
+	case value.TypeArray:
+		sb.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			k.write(sb, v.Index(i))
+			sb.WriteString(",")
+		}
+		sb.WriteString("]")
+	case value.TypeObject:
+		keys := v.Keys()
+		sort.Strings(keys)
+
+		sb.WriteString("{")
+		for _, key := range keys {
+			field, _ := v.Key(key)
+			sb.WriteString(strconv.Quote(key))
+			sb.WriteString("=")
+			k.write(sb, field)
+			sb.WriteString(",")
+		}
+		sb.WriteString("}")
+	default:
+		// Capsules and functions are keyed by the identity of their Go value.
+		fmt.Fprintf(sb, "%s:%d", v.Type(), k.id(capsuleIdentity(v)))
+	}
+}
+
+// capsuleIdentity returns the Go value identifying the capsule or function v.
+// Pointers are dereferenced by values, so the address of structs is used to
+// tell apart distinct pointers to equal structs. Other values such as secrets
+// are identified by their content.
+func capsuleIdentity(v value.Value) any {
+	if rv := v.Reflect(); rv.Kind() == reflect.Struct && rv.CanAddr() {
+		return rv.Addr().Interface()
+	}
+	return v.Interface()
+}
+
+// id returns an identifier of the Go value x. Comparable values equal to each
+// other, such as the same pointer, get the same identifier. Other values get a
+// new identifier each time.
+func (k *distinctKeys) id(x any) int {
+	if k.ids == nil {
+		k.ids = make(map[any]int)
+	}
+	if x == nil || !reflect.ValueOf(x).Comparable() {
+		k.ids[new(int)] = len(k.ids)
+		return len(k.ids) - 1
+	}
+	if id, ok := k.ids[x]; ok {
+		return id
+	}
+	k.ids[x] = len(k.ids)
+	return len(k.ids) - 1
+}
+
+// flatten returns the elements of an array, replacing any nested array with
+// its own (recursively flattened) elements.
+var flatten = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		res  = make([]value.Value, 0, args[0].Len())
+		walk func(arr value.Value)
+	)
+	walk = func(arr value.Value) {
+		for i := 0; i < arr.Len(); i++ {
+			elem := arr.Index(i)
+			if elem.Type() == value.TypeArray {
+				walk(elem)
+				continue
+			}
+			res = append(res, elem)
+		}
+	}
+	walk(args[0])
+
+	return value.Array(res...), nil
+})
+
+// filterByLabel returns the objects of an array (typically a list of targets)
+// which have a label with the given name set to the given value.
+//
+// Inputs:
+// args[0]: []map[string]string: the targets to filter
+// args[1]: string:              the label name
+// args[2]: string:              the label value to keep
+var filterByLabel = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 3); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeString); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 2, value.TypeString); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		targets = args[0]
+		name    = args[1].Text()
+		want    = args[2].Text()
+		res     = []value.Value{}
+	)
+	for i := 0; i < targets.Len(); i++ {
+		target := targets.Index(i)
+
+		obj, err := objectOf(funcValue, target, i)
+		if err != nil {
+			return value.Null, err
+		}
+		label, ok := obj.Key(name)
+		if !ok || label.Type() != value.TypeString {
+			continue
+		}
+		if label.Text() == want {
+			res = append(res, target)
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// mapKeys returns the keys of an object in lexicographic order.
+var mapKeys = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	obj, err := objectOf(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := obj.Keys()
+	sort.Strings(keys)
+
+	res := make([]value.Value, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, value.String(key))
+	}
+	return value.Array(res...), nil
+})
+
+// mapValues returns the values of an object, ordered by their key in
+// lexicographic order. Values are returned as is, so secrets stay secret.
+var mapValues = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	obj, err := objectOf(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := obj.Keys()
+	sort.Strings(keys)
+
+	res := make([]value.Value, 0, len(keys))
+	for _, key := range keys {
+		v, _ := obj.Key(key)
+		res = append(res, v)
+	}
+	return value.Array(res...), nil
+})
+
+// mergeDeep merges any number of objects into a single object. If a key
+// exists in multiple objects, the value from the last object is used, unless
+// both values are objects themselves in which case they're merged
+// recursively. null arguments are ignored.
+var mergeDeep = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	res := make(map[string]value.Value)
+	for i, arg := range args {
+		if arg.Type() == value.TypeNull {
+			continue
+		}
+		obj, err := objectOf(funcValue, arg, i)
+		if err != nil {
+			return value.Null, err
+		}
+		mergeInto(res, obj)
+	}
+	return value.Object(res), nil
+})
+
+// mergeInto merges the fields of src into dst, recursively merging fields
+// which are objects on both sides.
+func mergeInto(dst map[string]value.Value, src value.Value) {
+	for _, key := range src.Keys() {
+		srcVal, _ := src.Key(key)
+
+		dstVal, ok := dst[key]
+		if ok && isObject(dstVal) && isObject(srcVal) {
+			merged := make(map[string]value.Value)
+			mergeInto(merged, asObject(dstVal))
+			mergeInto(merged, asObject(srcVal))
+			dst[key] = value.Object(merged)
+			continue
+		}
+		dst[key] = srcVal
+	}
+}
+
+// objectOf returns arg as an object value, converting capsules which can be
+// represented as objects. An ArgError is returned if arg isn't an object.
+func objectOf(funcValue value.Value, arg value.Value, index int) (value.Value, error) {
+	if !isObject(arg) {
+		return value.Null, value.ArgError{
+			Function: funcValue,
+			Argument: arg,
+			Index:    index,
+			Inner: value.TypeError{
+				Value:    arg,
+				Expected: value.TypeObject,
+			},
+		}
+	}
+	return asObject(arg), nil
+}
+
+func isObject(v value.Value) bool {
+	if v.Type() == value.TypeObject {
+		return true
+	}
+	_, ok := v.TryConvertToObject()
+	return ok
+}
+
+// asObject converts v into an object value. v must be an object or a capsule
+// convertible into an object.
+func asObject(v value.Value) value.Value {
+	if v.Type() == value.TypeObject {
+		return v
+	}
+	obj, _ := v.TryConvertToObject()
+	return value.Object(obj)
+}
@@ -0,0 +1,31 @@
+package stdlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// hashSHA256 returns the hex-encoded SHA-256 digest of a string. It is
+// implemented as a raw function so that hashing a secret returns a secret.
+var hashSHA256 = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	text, sensitive, err := sensitiveText(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	return sensitiveValue(hex.EncodeToString(sum[:]), sensitive), nil
+})
+
+// hashFNV32 returns the 32-bit FNV-1a hash of a string as a number.
+func hashFNV32(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,62 @@
+package stdlib
+
+import (
+	"regexp"
+
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// regexMatch reports whether s contains any match of the regular expression
+// pattern. Use ^ and $ to match the whole string.
+func regexMatch(s string, pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// regexFindAll returns all successive non-overlapping matches of the regular
+// expression pattern in s.
+func regexFindAll(s string, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllString(s, -1)
+	if matches == nil {
+		return []string{}, nil
+	}
+	return matches, nil
+}
+
+// regexReplace replaces all matches of a regular expression in a string with
+// a replacement, which can reference capture groups with $1 or ${name}.
+// regexReplace is implemented as a raw function so that replacing text in a
+// secret returns a secret.
+//
+// Inputs:
+// args[0]: string or secret: the text to search
+// args[1]: string:           the regular expression
+// args[2]: string:           the replacement
+var regexReplace = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 3); err != nil {
+		return value.Null, err
+	}
+	text, sensitive, err := sensitiveText(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeString); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 2, value.TypeString); err != nil {
+		return value.Null, err
+	}
+
+	re, err := regexp.Compile(args[1].Text())
+	if err != nil {
+		return value.Null, value.Error{Value: funcValue, Inner: err}
+	}
+	return sensitiveValue(re.ReplaceAllString(text, args[2].Text()), sensitive), nil
+})
@@ -21,7 +21,21 @@ import (
 // ExperimentalIdentifiers contains the full name (namespace + identifier's name) of stdlib
 // identifiers that are considered "experimental".
 var ExperimentalIdentifiers = map[string]bool{
-	"array.combine_maps": true,
+	"array.combine_maps":    true,
+	"array.distinct":        true,
+	"array.flatten":         true,
+	"array.filter_by_label": true,
+	"regex.match":           true,
+	"regex.replace":         true,
+	"regex.find_all":        true,
+	"hash.sha256":           true,
+	"hash.fnv32":            true,
+	"time.now":              true,
+	"time.format":           true,
+	"time.parse_duration":   true,
+	"map.keys":              true,
+	"map.values":            true,
+	"map.merge_deep":        true,
 }
 
 // DeprecatedIdentifiers are deprecated in favour of the namespaced ones.
@@ -62,6 +76,10 @@ var Identifiers = map[string]interface{}{
 	"encoding": encoding,
 	"string":   str,
 	"file":     file,
+	"regex":    regex,
+	"hash":     hash,
+	"time":     timeFuncs,
+	"map":      mapFuncs,
 }
 
 func init() {
@@ -97,8 +115,34 @@ var str = map[string]interface{}{
 }
 
 var array = map[string]interface{}{
-	"concat":       concat,
-	"combine_maps": combineMaps,
+	"concat":          concat,
+	"combine_maps":    combineMaps,
+	"distinct":        distinct,
+	"flatten":         flatten,
+	"filter_by_label": filterByLabel,
+}
+
+var regex = map[string]interface{}{
+	"match":    regexMatch,
+	"replace":  regexReplace,
+	"find_all": regexFindAll,
+}
+
+var hash = map[string]interface{}{
+	"sha256": hashSHA256,
+	"fnv32":  hashFNV32,
+}
+
+var timeFuncs = map[string]interface{}{
+	"now":            timeNow,
+	"format":         timeFormat,
+	"parse_duration": timeParseDuration,
+}
+
+var mapFuncs = map[string]interface{}{
+	"keys":       mapKeys,
+	"values":     mapValues,
+	"merge_deep": mergeDeep,
 }
 
 var convert = map[string]interface{}{
@@ -113,6 +157,72 @@ func nonSensitive(secret alloytypes.Secret) string {
 	return string(secret)
 }
 
+// sensitiveText returns the text of args[index], which can be a string, a
+// secret or an optional secret. sensitive reports whether the text must be
+// kept secret.
+func sensitiveText(funcValue value.Value, args []value.Value, index int) (text string, sensitive bool, err error) {
+	arg := args[index]
+	if arg.Type() == value.TypeString {
+		return arg.Text(), false, nil
+	}
+
+	if arg.Type() == value.TypeCapsule {
+		switch v := arg.Interface().(type) {
+		case alloytypes.Secret:
+			return string(v), true, nil
+		case alloytypes.OptionalSecret:
+			return v.Value, v.IsSecret, nil
+		}
+	}
+
+	return "", false, value.ArgError{
+		Function: funcValue,
+		Argument: arg,
+		Index:    index,
+		Inner: value.TypeError{
+			Value:    arg,
+			Expected: value.TypeString,
+		},
+	}
+}
+
+// sensitiveValue returns text as a secret if sensitive is true, or as a
+// string otherwise.
+func sensitiveValue(text string, sensitive bool) value.Value {
+	if sensitive {
+		return value.Encapsulate(alloytypes.Secret(text))
+	}
+	return value.String(text)
+}
+
+// checkArgCount returns an error if raw function was called with a number of
+// arguments other than expected.
+func checkArgCount(funcValue value.Value, args []value.Value, expected int) error {
+	if len(args) != expected {
+		return value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected %d args, got %d", expected, len(args)),
+		}
+	}
+	return nil
+}
+
+// checkArgType returns an ArgError if args[index] isn't of the expected type.
+func checkArgType(funcValue value.Value, args []value.Value, index int, expected value.Type) error {
+	if args[index].Type() != expected {
+		return value.ArgError{
+			Function: funcValue,
+			Argument: args[index],
+			Index:    index,
+			Inner: value.TypeError{
+				Value:    args[index],
+				Expected: expected,
+			},
+		}
+	}
+	return nil
+}
+
 // concat is implemented as a raw function so it can bypass allocations
 // converting arguments into []interface{}. concat is optimized to allow it
 // to perform well when it is in the hot path for combining targets from many
@@ -0,0 +1,29 @@
+package stdlib
+
+import (
+	"time"
+)
+
+// timeNow returns the current UTC time formatted as an RFC 3339 timestamp.
+func timeNow() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// timeFormat formats an RFC 3339 timestamp using a Go time layout.
+func timeFormat(timestamp string, layout string) (string, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// timeParseDuration parses a duration string such as "1h30m" and returns it
+// as a number of seconds.
+func timeParseDuration(s string) (float64, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return d.Seconds(), nil
+}
@@ -0,0 +1,319 @@
+// Package jsonschema describes the Go types used to decode Alloy blocks as
+// JSON Schema documents.
+//
+// Each attribute and block of a struct is described as a property of a JSON
+// object. Blocks are marked with the x-alloy-block extension, and blocks
+// which can be set multiple times are described as arrays of objects.
+package jsonschema
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/internal/reflectutil"
+	"github.com/grafana/alloy/syntax/internal/tagcache"
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// Draft is the version of JSON Schema used by the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Extensions used to describe Alloy specific information.
+const (
+	// ExtBlock is set to true for properties set with blocks.
+	ExtBlock = "x-alloy-block"
+	// ExtCapsule holds the Go type of capsule values, which can only be set
+	// from references to the exports of other components.
+	ExtCapsule = "x-alloy-capsule"
+	// ExtSecret is set to true for strings which are treated as secrets.
+	ExtSecret = "x-alloy-secret"
+)
+
+var (
+	goAny            = reflect.TypeFor[any]()
+	goSecret         = reflect.TypeFor[alloytypes.Secret]()
+	goOptionalSecret = reflect.TypeFor[alloytypes.OptionalSecret]()
+	goDefaulter      = reflect.TypeFor[value.Defaulter]()
+)
+
+// Schema is a JSON Schema document.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false or a *Schema
+	Items                *Schema            `json:"items,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Default              any                `json:"default,omitempty"`
+
+	// Extensions holds additional keywords, such as the x-alloy-* extensions.
+	// They're marshaled alongside the other keywords.
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler, inlining the extensions of s.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	raw, err := json.Marshal((*plain)(s))
+	if err != nil || len(s.Extensions) == 0 {
+		return raw, err
+	}
+
+	var merged map[string]any
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return nil, err
+	}
+	maps.Copy(merged, s.Extensions)
+	return json.Marshal(merged)
+}
+
+// SetExtension sets the extension keyword name to value.
+func (s *Schema) SetExtension(name string, value any) {
+	if s.Extensions == nil {
+		s.Extensions = make(map[string]any)
+	}
+	s.Extensions[name] = value
+}
+
+// Body returns the schema of the body of a block decoded into v, which must
+// be a struct or a pointer to a struct with alloy tags. Defaults are taken
+// from the SetToDefault method of v's type when it's implemented, otherwise
+// from v itself.
+func Body(v any) *Schema {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv = reflect.New(rv.Type().Elem())
+		}
+		rv = rv.Elem()
+	}
+	g := generator{visiting: make(map[reflect.Type]bool)}
+	return g.bodySchema(rv.Type(), rv)
+}
+
+// generator generates schemas, keeping track of the struct types being
+// described to handle recursive types.
+type generator struct {
+	visiting map[reflect.Type]bool
+}
+
+// bodySchema describes a struct type t, whose fields have the defaults held
+// by defaults.
+func (g generator) bodySchema(t reflect.Type, defaults reflect.Value) *Schema {
+	if g.visiting[t] {
+		// Recursive types are described as any object.
+		return &Schema{Type: "object"}
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	defaults = withDefaults(t, defaults)
+	tags := tagcache.Get(t)
+
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	for name, tf := range tags.TagLookup {
+		field := t.FieldByIndex(tf.Index)
+		fieldDefault := reflectutil.Get(defaults, tf)
+
+		var prop *Schema
+		if tf.IsBlock() {
+			prop = g.blockSchema(field.Type, fieldDefault)
+		} else {
+			prop = g.typeSchema(field.Type)
+			prop.Default = defaultValue(fieldDefault)
+		}
+		s.Properties[name] = prop
+
+		if !tf.IsOptional() {
+			s.Required = append(s.Required, name)
+		}
+	}
+	for name, enum := range tags.EnumLookup {
+		enumType := deferenceType(t.FieldByIndex(enum.EnumField.Index).Type.Elem())
+		blockType := enumType.FieldByIndex(enum.BlockField.Index).Type
+		s.Properties[name] = g.blockSchema(blockType, reflect.Value{})
+	}
+
+	sort.Strings(s.Required)
+	return s
+}
+
+// blockSchema describes a block decoded into a value of type t.
+func (g generator) blockSchema(t reflect.Type, defaults reflect.Value) *Schema {
+	var s *Schema
+	switch t := deferenceType(t); {
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		// Blocks which can be set multiple times don't have defaults.
+		s = &Schema{
+			Type:  "array",
+			Items: g.bodySchema(deferenceType(t.Elem()), reflect.Value{}),
+		}
+		if t.Kind() == reflect.Array {
+			s.MaxItems = ptr(t.Len())
+		}
+	case t.Kind() == reflect.Struct:
+		s = g.bodySchema(t, defaults)
+	case t.Kind() == reflect.Map:
+		// Map blocks accept any attribute.
+		s = &Schema{
+			Type:                 "object",
+			AdditionalProperties: g.typeSchema(t.Elem()),
+		}
+	default:
+		s = &Schema{Type: "object"}
+	}
+	s.SetExtension(ExtBlock, true)
+	return s
+}
+
+// typeSchema describes the values which can be assigned to an attribute of
+// type t.
+func (g generator) typeSchema(t reflect.Type) *Schema {
+	t = deferenceType(t)
+
+	switch t {
+	case goAny:
+		return &Schema{}
+	case goSecret, goOptionalSecret:
+		s := &Schema{Type: "string"}
+		s.SetExtension(ExtSecret, true)
+		return s
+	}
+
+	switch value.AlloyType(t) {
+	case value.TypeNumber:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return &Schema{Type: "number"}
+		default:
+			return &Schema{Type: "integer"}
+		}
+	case value.TypeString:
+		return &Schema{Type: "string"}
+	case value.TypeBool:
+		return &Schema{Type: "boolean"}
+	case value.TypeArray:
+		s := &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			s.MaxItems = ptr(t.Len())
+		}
+		return s
+	case value.TypeObject:
+		switch t.Kind() {
+		case reflect.Map:
+			return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+		case reflect.Struct:
+			return g.bodySchema(t, reflect.Value{})
+		default:
+			// Slices of labeled blocks are objects keyed by the labels.
+			return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+		}
+	default:
+		s := &Schema{}
+		s.SetExtension(ExtCapsule, t.String())
+		return s
+	}
+}
+
+// withDefaults returns the default value of a struct of type t. If t
+// implements value.Defaulter, the result of SetToDefault is used, otherwise
+// defaults is used when it's valid.
+func withDefaults(t reflect.Type, defaults reflect.Value) reflect.Value {
+	if reflect.PointerTo(t).Implements(goDefaulter) {
+		rv := reflect.New(t)
+		rv.Interface().(value.Defaulter).SetToDefault()
+		return rv.Elem()
+	}
+	if defaults.IsValid() {
+		for defaults.Kind() == reflect.Pointer {
+			if defaults.IsNil() {
+				return reflect.New(t).Elem()
+			}
+			defaults = defaults.Elem()
+		}
+		return defaults
+	}
+	return reflect.New(t).Elem()
+}
+
+// defaultValue returns the JSON representation of a default value. nil is
+// returned for zero values and for values which can't be represented as
+// JSON, such as secrets and capsules.
+func defaultValue(rv reflect.Value) any {
+	if !rv.IsValid() || rv.IsZero() {
+		return nil
+	}
+	switch deferenceType(rv.Type()) {
+	case goSecret, goOptionalSecret:
+		return nil
+	}
+	res, ok := jsonValue(value.FromRaw(rv))
+	if !ok {
+		return nil
+	}
+	return res
+}
+
+// jsonValue converts v to a value which can be marshaled to JSON.
+func jsonValue(v value.Value) (any, bool) {
+	switch v.Type() {
+	case value.TypeNull:
+		return nil, true
+	case value.TypeNumber:
+		switch n := v.Number(); n.Kind() {
+		case value.NumberKindInt:
+			return n.Int(), true
+		case value.NumberKindUint:
+			return n.Uint(), true
+		default:
+			return n.Float(), true
+		}
+	case value.TypeString:
+		return v.Text(), true
+	case value.TypeBool:
+		return v.Bool(), true
+	case value.TypeArray:
+		res := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := jsonValue(v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			res = append(res, elem)
+		}
+		return res, true
+	case value.TypeObject:
+		res := make(map[string]any, v.Len())
+		for _, key := range v.Keys() {
+			field, _ := v.Key(key)
+			elem, ok := jsonValue(field)
+			if !ok {
+				return nil, false
+			}
+			res[key] = elem
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
+
+func deferenceType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,129 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/jsonschema"
+	"github.com/stretchr/testify/require"
+)
+
+type Receiver interface{ Receive() }
+
+type Args struct {
+	Name      string              `alloy:"name,attr"`
+	Interval  time.Duration       `alloy:"interval,attr,optional"`
+	Retries   int                 `alloy:"retries,attr,optional"`
+	Ratio     float64             `alloy:"ratio,attr,optional"`
+	Enabled   bool                `alloy:"enabled,attr,optional"`
+	Labels    map[string]string   `alloy:"labels,attr,optional"`
+	Targets   []map[string]string `alloy:"targets,attr,optional"`
+	Password  alloytypes.Secret   `alloy:"password,attr,optional"`
+	ForwardTo []Receiver          `alloy:"forward_to,attr,optional"`
+	Any       any                 `alloy:"any,attr,optional"`
+
+	Auth  *Auth      `alloy:"auth,block,optional"`
+	Rules []Rule     `alloy:"rule,block,optional"`
+	Pair  [2]Rule    `alloy:"pair,block,optional"`
+	Enum  []EnumItem `alloy:"enum,enum,optional"`
+}
+
+func (a *Args) SetToDefault() {
+	*a = Args{
+		Interval: time.Minute,
+		Retries:  3,
+		Enabled:  true,
+		Password: "hidden",
+		Labels:   map[string]string{"env": "prod"},
+		Auth:     &Auth{Username: "admin"},
+	}
+}
+
+type Auth struct {
+	Username string `alloy:"username,attr,optional"`
+}
+
+type Rule struct {
+	Action string `alloy:"action,attr,optional"`
+}
+
+func (r *Rule) SetToDefault() { r.Action = "replace" }
+
+type EnumItem struct {
+	First *Rule `alloy:"first,block,optional"`
+}
+
+func TestBody(t *testing.T) {
+	s := jsonschema.Body(Args{})
+
+	bb, err := json.MarshalIndent(s, "", "  ")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["name"],
+		"properties": {
+			"name":       {"type": "string"},
+			"interval":   {"type": "string", "default": "1m0s"},
+			"retries":    {"type": "integer", "default": 3},
+			"ratio":      {"type": "number"},
+			"enabled":    {"type": "boolean", "default": true},
+			"labels":     {"type": "object", "additionalProperties": {"type": "string"}, "default": {"env": "prod"}},
+			"targets":    {"type": "array", "items": {"type": "object", "additionalProperties": {"type": "string"}}},
+			"password":   {"type": "string", "x-alloy-secret": true},
+			"forward_to": {"type": "array", "items": {"x-alloy-capsule": "jsonschema_test.Receiver"}},
+			"any":        {},
+			"auth": {
+				"type": "object",
+				"additionalProperties": false,
+				"x-alloy-block": true,
+				"properties": {
+					"username": {"type": "string", "default": "admin"}
+				}
+			},
+			"rule": {
+				"type": "array",
+				"x-alloy-block": true,
+				"items": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {
+						"action": {"type": "string", "default": "replace"}
+					}
+				}
+			},
+			"pair": {
+				"type": "array",
+				"maxItems": 2,
+				"x-alloy-block": true,
+				"items": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {
+						"action": {"type": "string", "default": "replace"}
+					}
+				}
+			},
+			"enum.first": {
+				"type": "object",
+				"additionalProperties": false,
+				"x-alloy-block": true,
+				"properties": {
+					"action": {"type": "string", "default": "replace"}
+				}
+			}
+		}
+	}`, string(bb))
+}
+
+type Recursive struct {
+	Name  string     `alloy:"name,attr"`
+	Child *Recursive `alloy:"child,block,optional"`
+}
+
+func TestBody_Recursive(t *testing.T) {
+	s := jsonschema.Body(&Recursive{})
+	require.Equal(t, &jsonschema.Schema{Type: "object", Extensions: map[string]any{jsonschema.ExtBlock: true}}, s.Properties["child"])
+}
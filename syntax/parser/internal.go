@@ -32,6 +32,9 @@ type parser struct {
 	tok token.Token // Current token
 	lit string      // Current token literal
 
+	// Tokens which have been scanned by peek but not consumed yet.
+	lookahead []scannedToken
+
 	// Position of the last error written. Two parse errors on the same line are
 	// ignored.
 	lastError token.Position
@@ -68,7 +71,39 @@ func (p *parser) next() {
 
 // next0 advances the parser to the next token. next0 should not be used
 // directly by parse methods; call next instead.
-func (p *parser) next0() { p.pos, p.tok, p.lit = p.scanner.Scan() }
+func (p *parser) next0() {
+	if len(p.lookahead) > 0 {
+		t := p.lookahead[0]
+		p.lookahead = p.lookahead[1:]
+		p.pos, p.tok, p.lit = t.pos, t.tok, t.lit
+		return
+	}
+	p.pos, p.tok, p.lit = p.scanner.Scan()
+}
+
+type scannedToken struct {
+	pos token.Pos
+	tok token.Token
+	lit string
+}
+
+// peek returns the next non-comment token after the current token without
+// consuming it.
+func (p *parser) peek() token.Token {
+	for _, t := range p.lookahead {
+		if t.tok != token.COMMENT {
+			return t.tok
+		}
+	}
+
+	for {
+		pos, tok, lit := p.scanner.Scan()
+		p.lookahead = append(p.lookahead, scannedToken{pos: pos, tok: tok, lit: lit})
+		if tok != token.COMMENT {
+			return tok
+		}
+	}
+}
 
 // consumeCommentGroup consumes a group of adjacent comments, adding it to p's
 // comment list.
@@ -379,9 +414,39 @@ func (n blockName) ValidAttribute() bool {
 
 // ParseExpression parses a single expression.
 //
-//	Expression = BinOpExpr
+//	Expression = CondExpr
 func (p *parser) ParseExpression() ast.Expr {
-	return p.parseBinOp(1)
+	return p.parseCondExpr()
+}
+
+// parseCondExpr parses a conditional expression. Conditional expressions are
+// right-associative, so "a ? b : c ? d : e" is parsed as
+// "a ? b : (c ? d : e)".
+//
+//	CondExpr = BinOpExpr [ "?" Expression ":" Expression ]
+func (p *parser) parseCondExpr() ast.Expr {
+	cond := p.parseBinOp(1)
+	if p.tok != token.QUESTION {
+		return cond
+	}
+
+	res := &ast.ConditionalExpr{Condition: cond}
+
+	res.QuestionPos, _, _ = p.expect(token.QUESTION)
+	res.TrueValue = p.ParseExpression()
+
+	if p.tok != token.COLON {
+		// Don't consume the unexpected token, which is likely the end of the
+		// statement.
+		p.addErrorf("expected %s, got %s", token.COLON, p.tok)
+		res.ColonPos = p.pos
+		res.FalseValue = &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: p.pos}
+		return res
+	}
+
+	res.ColonPos, _, _ = p.expect(token.COLON)
+	res.FalseValue = p.ParseExpression()
+	return res
 }
 
 // parseBinOp is the entrypoint for binary expressions. If there is no binary
@@ -569,13 +634,14 @@ func isUnaryOp(tok token.Token) bool {
 
 // parsePrimaryExpr parses a primary expression.
 //
-//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr
+//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr | Comprehension
 //
 //	LiteralValue = identifier | string | number | float | bool | null |
 //	               "(" Expression ")"
 //
-//	ArrayExpr  = "[" [ ExpressionList ] "]"
-//	ObjectExpr = "{" [ FieldList ] "}"
+//	ArrayExpr     = "[" [ ExpressionList ] "]"
+//	ObjectExpr    = "{" [ FieldList ] "}"
+//	Comprehension = ArrayComprehension | ObjectComprehension
 func (p *parser) parsePrimaryExpr() ast.Expr {
 	switch p.tok {
 	case token.IDENT:
@@ -612,6 +678,9 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 		var res ast.ArrayExpr
 
 		res.LBrackPos, _, _ = p.expect(token.LBRACK)
+		if p.atComprehension() {
+			return p.parseComprehension(res.LBrackPos, token.RBRACK)
+		}
 		if p.tok != token.RBRACK {
 			res.Elements = p.parseExpressionList(token.RBRACK)
 		}
@@ -622,6 +691,9 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 		var res ast.ObjectExpr
 
 		res.LCurlyPos, _, _ = p.expect(token.LCURLY)
+		if p.atComprehension() {
+			return p.parseComprehension(res.LCurlyPos, token.RCURLY)
+		}
 		if p.tok != token.RBRACK {
 			res.Fields = p.parseFieldList(token.RCURLY)
 		}
@@ -635,6 +707,84 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 	return res
 }
 
+// atComprehension reports whether the parser is at the start of a
+// comprehension. "for", "in", and "if" aren't reserved keywords, so a
+// comprehension is only started by "for" followed by another identifier,
+// which is never valid in any other expression.
+func (p *parser) atComprehension() bool {
+	return p.tok == token.IDENT && p.lit == "for" && p.peek() == token.IDENT
+}
+
+// parseComprehension parses an array or object comprehension. The opening
+// bracket at openPos must already be consumed; close determines the kind of
+// comprehension being parsed.
+//
+//	ArrayComprehension  = "[" ForClause Expression [ IfClause ] "]"
+//	ObjectComprehension = "{" ForClause Expression "=" Expression [ IfClause ] "}"
+//
+//	ForClause = "for" identifier [ "," identifier ] "in" Expression ":"
+//	IfClause  = "if" Expression
+func (p *parser) parseComprehension(openPos token.Pos, close token.Token) ast.Expr {
+	res := &ast.ComprehensionExpr{
+		Object:  close == token.RCURLY,
+		OpenPos: openPos,
+	}
+
+	p.next() // Consume "for"
+
+	res.ValueVar = p.parseIdent()
+	if p.tok == token.COMMA {
+		p.next() // Consume ","
+		res.KeyVar, res.ValueVar = res.ValueVar, p.parseIdent()
+	}
+
+	res.InPos = p.expectKeyword("in")
+	res.Collection = p.ParseExpression()
+	res.ColonPos, _, _ = p.expect(token.COLON)
+
+	if res.Object {
+		res.Key = p.ParseExpression()
+		res.AssignPos, _, _ = p.expect(token.ASSIGN)
+	}
+	res.Value = p.ParseExpression()
+	p.skipTerminator()
+
+	if p.tok == token.IDENT && p.lit == "if" {
+		res.IfPos = p.pos
+		p.next() // Consume "if"
+		res.Cond = p.ParseExpression()
+		p.skipTerminator()
+	}
+
+	res.ClosePos, _, _ = p.expect(close)
+	return res
+}
+
+// parseIdent parses a single identifier.
+func (p *parser) parseIdent() *ast.Ident {
+	pos, _, name := p.expect(token.IDENT)
+	return &ast.Ident{Name: name, NamePos: pos}
+}
+
+// expectKeyword consumes the next token, recording an error if it isn't the
+// identifier kw.
+func (p *parser) expectKeyword(kw string) token.Pos {
+	pos := p.pos
+	if p.tok != token.IDENT || p.lit != kw {
+		p.addErrorf("expected %s, got %s", kw, p.tok)
+	}
+	p.next()
+	return pos
+}
+
+// skipTerminator consumes a terminator inserted by a newline. It is used
+// where a newline may appear inside brackets.
+func (p *parser) skipTerminator() {
+	if p.tok == token.TERMINATOR {
+		p.next()
+	}
+}
+
 var statementEnd = map[token.Token]struct{}{
 	token.TERMINATOR: {},
 	token.RPAREN:     {},
@@ -108,6 +108,26 @@ func TestParseExpressions(t *testing.T) {
 
 		"parens": `(1 + 5) * 100`,
 
+		"conditional":           `a == 1 ? "one" : "other"`,
+		"conditional nested":    `a ? b : c ? d : e`,
+		"conditional in parens": `(a ? 1 : 2) + 3`,
+		"conditional multiline": `a ?
+			1 :
+			2`,
+
+		"array comprehension":              `[for t in targets : t]`,
+		"array comprehension with index":   `[for i, t in targets : i]`,
+		"array comprehension with filter":  `[for t in targets : t if t.job == "x"]`,
+		"object comprehension":             `{for k, v in labels : k = v}`,
+		"object comprehension with filter": `{for k, v in labels : k = v if k != "job"}`,
+		"nested comprehension":             `[for a in [for b in c : b] : a]`,
+		"comprehension multiline": `[for t in targets :
+			t
+			if t.job == "x"
+		]`,
+		"array with for identifier":  `[for, for.x]`,
+		"object with for field name": `{ for = 1 }`,
+
 		"mixed expression": `(a.b.c)(1, 3 * some_list[magic_index * 2]).resulting_field`,
 	}
 
@@ -179,7 +179,78 @@ func (w *walker) walkExpr(e ast.Expr) {
 		w.p.Write(token.LPAREN)
 		w.walkExpr(e.Inner)
 		w.p.Write(token.RPAREN)
+
+	case *ast.ConditionalExpr:
+		w.walkExpr(e.Condition)
+		w.p.Write(wsBlank, e.QuestionPos, token.QUESTION, wsBlank)
+		w.walkExpr(e.TrueValue)
+		w.p.Write(wsBlank, e.ColonPos, token.COLON, wsBlank)
+		w.walkExpr(e.FalseValue)
+
+	case *ast.ComprehensionExpr:
+		w.walkComprehensionExpr(e)
+	}
+}
+
+func (w *walker) walkComprehensionExpr(e *ast.ComprehensionExpr) {
+	open, close := token.LBRACK, token.RBRACK
+	if e.Object {
+		open, close = token.LCURLY, token.RCURLY
 	}
+
+	w.p.Write(e.OpenPos, open, &ast.Ident{Name: "for"}, wsBlank)
+	if e.KeyVar != nil {
+		w.p.Write(e.KeyVar.NamePos, e.KeyVar, token.COMMA, wsBlank)
+	}
+	w.p.Write(e.ValueVar.NamePos, e.ValueVar, wsBlank, e.InPos, &ast.Ident{Name: "in"}, wsBlank)
+	w.walkExpr(e.Collection)
+	w.p.Write(wsBlank, e.ColonPos, token.COLON)
+
+	// Keep the line breaks of multiline comprehensions, indenting the parts
+	// written on their own line like the elements of an array.
+	prevPos := e.ColonPos
+	body := e.Value
+	if e.Object {
+		body = e.Key
+	}
+	w.walkComprehensionPart(prevPos, ast.StartPos(body), func() {
+		if e.Object {
+			w.walkExpr(e.Key)
+			w.p.Write(wsBlank, e.AssignPos, token.ASSIGN, wsBlank)
+		}
+		w.walkExpr(e.Value)
+	})
+	prevPos = ast.EndPos(e.Value)
+
+	if e.Cond != nil {
+		w.walkComprehensionPart(prevPos, e.IfPos, func() {
+			w.p.Write(e.IfPos, &ast.Ident{Name: "if"}, wsBlank)
+			w.walkExpr(e.Cond)
+		})
+		prevPos = ast.EndPos(e.Cond)
+	}
+
+	if differentLines(prevPos, e.ClosePos) {
+		// We add an indentation here so comments after the final part are
+		// indented.
+		w.p.Write(wsIndent, wsFormfeed, wsUnindent)
+	}
+	w.p.Write(e.ClosePos, close)
+}
+
+// walkComprehensionPart writes a part of a comprehension starting at pos,
+// either on a new indented line if it starts on a different line than prevPos
+// or after a space.
+func (w *walker) walkComprehensionPart(prevPos, pos token.Pos, walk func()) {
+	if !differentLines(prevPos, pos) {
+		w.p.Write(wsBlank)
+		walk()
+		return
+	}
+
+	w.p.Write(wsIndent, wsFormfeed)
+	walk()
+	w.p.Write(wsUnindent)
 }
 
 func (w *walker) walkArrayExpr(e *ast.ArrayExpr) {
@@ -69,6 +69,8 @@ var tokens = []tokenExample{
 	{token.LCURLY, "{"},
 	{token.COMMA, ","},
 	{token.DOT, "."},
+	{token.QUESTION, "?"},
+	{token.COLON, ":"},
 
 	{token.RPAREN, ")"},
 	{token.RBRACK, "]"},
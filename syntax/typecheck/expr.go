@@ -0,0 +1,398 @@
+package typecheck
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/internal/tagcache"
+	"github.com/grafana/alloy/syntax/internal/transform"
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// ResolveFunc resolves the Go type of a reference made by an expression, such
+// as a reference to the exports of a component.
+//
+// ResolveFunc returns the Go type of the value referenced by the first
+// consumed identifiers of traversal; the remaining identifiers are checked
+// against that type. A nil type means that the reference is unknown and can't
+// be checked. Diagnostics are returned for references which can never be
+// resolved.
+type ResolveFunc func(traversal []*ast.Ident) (ty reflect.Type, consumed int, diags diag.Diagnostics)
+
+var (
+	goAny             = reflect.TypeFor[any]()
+	goTextUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+	goUnmarshaler     = reflect.TypeFor[value.Unmarshaler]()
+	goConvertibleFrom = reflect.TypeFor[value.ConvertibleFromCapsule]()
+	goConvertibleInto = reflect.TypeFor[value.ConvertibleIntoCapsule]()
+)
+
+// Expressions checks the types of the expressions assigned to the attributes
+// of b against the fields of args, which must be a struct. Literals, arrays,
+// objects and references resolved by resolve are checked, while the values of
+// other expressions such as function calls are assumed to be valid. The
+// references made anywhere in the expressions are resolved.
+//
+// Expressions doesn't report structural errors of b, such as unknown
+// attributes: use Block for those.
+func Expressions(b *ast.BlockStmt, args any, resolve ResolveFunc) diag.Diagnostics {
+	c := exprChecker{resolve: resolve}
+	c.checkBody(b.Body, reflect.TypeOf(args))
+	return c.diags
+}
+
+// FieldType returns the Go type of the attribute or block called name in the
+// struct type t.
+func FieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	t = deferenceType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	tf, ok := tagcache.Get(t).TagLookup[name]
+	if !ok {
+		return nil, false
+	}
+	return t.FieldByIndex(tf.Index).Type, true
+}
+
+type exprChecker struct {
+	resolve ResolveFunc
+	diags   diag.Diagnostics
+	locals  map[string]int // Variables of the enclosing comprehensions.
+}
+
+func (c *exprChecker) checkBody(body ast.Body, t reflect.Type) {
+	t = deferenceType(t)
+	if t.Kind() != reflect.Struct {
+		// Map blocks accept arbitrary attributes.
+		return
+	}
+	tags := tagcache.Get(t)
+
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			tf, ok := tags.TagLookup[stmt.Name.Name]
+			if !ok || !tf.IsAttr() {
+				continue
+			}
+			c.checkExpr(stmt.Value, t.FieldByIndex(tf.Index).Type)
+
+		case *ast.BlockStmt:
+			name := stmt.GetBlockName()
+			if enum, ok := tags.EnumLookup[name]; ok {
+				enumType := deferenceType(t.FieldByIndex(enum.EnumField.Index).Type.Elem())
+				c.checkBody(stmt.Body, deferenceType(enumType).FieldByIndex(enum.BlockField.Index).Type)
+				continue
+			}
+
+			tf, ok := tags.TagLookup[name]
+			if !ok || !tf.IsBlock() {
+				continue
+			}
+			fieldType := deferenceType(t.FieldByIndex(tf.Index).Type)
+			if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+				fieldType = fieldType.Elem()
+			}
+			c.checkBody(stmt.Body, fieldType)
+		}
+	}
+}
+
+func (c *exprChecker) checkExpr(expr ast.Expr, want reflect.Type) {
+	want = deferenceType(want)
+	if want == goAny || acceptsAnyValue(want) {
+		// The value can't be checked, but the references it makes must still
+		// exist.
+		c.checkReferences(expr)
+		return
+	}
+
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		c.checkExpr(expr.Inner, want)
+
+	case *ast.ConditionalExpr:
+		c.checkReferences(expr.Condition)
+		c.checkExpr(expr.TrueValue, want)
+		c.checkExpr(expr.FalseValue, want)
+
+	case *ast.LiteralExpr:
+		v, err := transform.ValueFromLiteral(expr.Value, expr.Kind)
+		if err != nil || v.Type() == value.TypeNull {
+			return
+		}
+		if expr.Kind == token.STRING && value.AlloyType(want) == value.TypeNumber {
+			// Strings are only converted to numbers if they hold a number.
+			if _, err := strconv.ParseFloat(v.Text(), 64); err != nil {
+				c.reportType(expr, v.Reflect().Type(), want)
+			}
+			return
+		}
+		c.checkType(expr, v.Reflect().Type(), want)
+
+	case *ast.ArrayExpr:
+		switch deferenceType(want).Kind() {
+		case reflect.Slice, reflect.Array:
+			if value.AlloyType(want) != value.TypeArray {
+				// Slices of labeled blocks are objects.
+				c.checkReferences(expr)
+				return
+			}
+			for _, elem := range expr.Elements {
+				c.checkExpr(elem, want.Elem())
+			}
+		default:
+			c.checkType(expr, reflect.TypeFor[[]any](), want)
+		}
+
+	case *ast.ObjectExpr:
+		switch {
+		case want.Kind() == reflect.Map && want.Key().Kind() == reflect.String:
+			for _, field := range expr.Fields {
+				c.checkExpr(field.Value, want.Elem())
+			}
+		case value.AlloyType(want) == value.TypeObject:
+			// Objects are decoded into structs field by field; the unknown
+			// fields are reported when evaluating the expression.
+			c.checkReferences(expr)
+		default:
+			c.checkType(expr, reflect.TypeFor[map[string]any](), want)
+		}
+
+	case *ast.IdentifierExpr, *ast.AccessExpr, *ast.IndexExpr:
+		if got := c.referenceType(expr); got != nil {
+			c.checkType(expr, got, want)
+		}
+
+	default:
+		c.checkReferences(expr)
+	}
+}
+
+// checkReferences resolves the references made by expr without checking the
+// type of its value.
+func (c *exprChecker) checkReferences(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		c.checkReferences(expr.Inner)
+	case *ast.ConditionalExpr:
+		c.checkReferences(expr.Condition)
+		c.checkReferences(expr.TrueValue)
+		c.checkReferences(expr.FalseValue)
+	case *ast.ArrayExpr:
+		for _, elem := range expr.Elements {
+			c.checkReferences(elem)
+		}
+	case *ast.ObjectExpr:
+		for _, field := range expr.Fields {
+			c.checkReferences(field.Value)
+		}
+	case *ast.CallExpr:
+		// The name of the called function isn't a reference.
+		if _, ok := ast.Traversal(expr.Value); !ok {
+			c.checkReferences(expr.Value)
+		}
+		for _, arg := range expr.Args {
+			c.checkReferences(arg)
+		}
+	case *ast.UnaryExpr:
+		c.checkReferences(expr.Value)
+	case *ast.BinaryExpr:
+		c.checkReferences(expr.Left)
+		c.checkReferences(expr.Right)
+	case *ast.ComprehensionExpr:
+		c.checkReferences(expr.Collection)
+
+		// The variables of the comprehension shadow the references with the
+		// same name.
+		vars := []*ast.Ident{expr.ValueVar}
+		if expr.KeyVar != nil {
+			vars = append(vars, expr.KeyVar)
+		}
+		if c.locals == nil {
+			c.locals = make(map[string]int)
+		}
+		for _, v := range vars {
+			c.locals[v.Name]++
+		}
+		for _, e := range []ast.Expr{expr.Key, expr.Value, expr.Cond} {
+			if e != nil {
+				c.checkReferences(e)
+			}
+		}
+		for _, v := range vars {
+			c.locals[v.Name]--
+		}
+	case *ast.IdentifierExpr, *ast.AccessExpr, *ast.IndexExpr:
+		c.referenceType(expr)
+	}
+}
+
+// referenceType returns the Go type of the value referenced by expr. A nil
+// type is returned if the type isn't known.
+func (c *exprChecker) referenceType(expr ast.Expr) reflect.Type {
+	if index, ok := expr.(*ast.IndexExpr); ok {
+		c.checkReferences(index.Index)
+		t := c.referenceType(index.Value)
+		if t == nil {
+			return nil
+		}
+		switch t := deferenceType(t); t.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if value.AlloyType(t) == value.TypeCapsule {
+				return nil
+			}
+			return t.Elem()
+		}
+		return nil
+	}
+
+	traversal, ok := ast.Traversal(expr)
+	if !ok {
+		// The references made by the accessed value are still resolved.
+		if access, isAccess := expr.(*ast.AccessExpr); isAccess {
+			c.checkReferences(access.Value)
+		} else {
+			c.checkReferences(expr)
+		}
+		return nil
+	}
+	if c.resolve == nil || c.locals[traversal[0].Name] > 0 {
+		return nil
+	}
+
+	t, consumed, diags := c.resolve(traversal)
+	c.diags.Merge(diags)
+	if t == nil || diags.HasErrors() {
+		return nil
+	}
+
+	for i := consumed; i < len(traversal); i++ {
+		t = deferenceType(t)
+		switch {
+		case t.Kind() == reflect.Struct && value.AlloyType(t) == value.TypeObject:
+			ft, ok := FieldType(t, traversal[i].Name)
+			if !ok {
+				c.diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(traversal[i]).Position(),
+					EndPos:   ast.EndPos(traversal[i]).Position(),
+					Message:  fmt.Sprintf("field %q does not exist in %s", traversal[i].Name, identsString(traversal[:i])),
+				})
+				return nil
+			}
+			t = ft
+		case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+			t = t.Elem()
+		default:
+			// The value can't be inspected any further.
+			return nil
+		}
+	}
+	return t
+}
+
+// checkType reports a diagnostic for expr if a value of type got can never be
+// assigned to a value of type want.
+func (c *exprChecker) checkType(expr ast.Expr, got reflect.Type, want reflect.Type) {
+	if !assignable(got, want) {
+		c.reportType(expr, got, want)
+	}
+}
+
+func (c *exprChecker) reportType(expr ast.Expr, got reflect.Type, want reflect.Type) {
+	c.diags.Add(diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(expr).Position(),
+		EndPos:   ast.EndPos(expr).Position(),
+		Message:  fmt.Sprintf("%s should be %s, got %s", exprString(expr), describeType(want), describeType(got)),
+	})
+}
+
+// assignable reports whether a value of type got may be assigned to a value
+// of type want. It follows the conversion rules used when decoding values,
+// and only returns false when the conversion can never succeed.
+func assignable(got, want reflect.Type) bool {
+	got, want = deferenceType(got), deferenceType(want)
+
+	switch {
+	case got == want, want == goAny, got == goAny:
+		return true
+	case acceptsAnyValue(want), implements(got, goConvertibleInto):
+		// Custom conversions can only be checked with a value.
+		return true
+	}
+
+	gotType, wantType := value.AlloyType(got), value.AlloyType(want)
+	if gotType == value.TypeCapsule || wantType == value.TypeCapsule {
+		if gotType != wantType {
+			return false
+		}
+		if want.Kind() == reflect.Interface {
+			return got.Implements(want) || reflect.PointerTo(got).Implements(want)
+		}
+		return got.ConvertibleTo(want)
+	}
+
+	switch {
+	case gotType == wantType:
+		if gotType == value.TypeArray && got.Kind() != reflect.Struct && want.Kind() != reflect.Struct {
+			return assignable(got.Elem(), want.Elem())
+		}
+		return true
+	case gotType == value.TypeNumber && wantType == value.TypeString:
+		return true
+	case gotType == value.TypeString && wantType == value.TypeNumber:
+		// The conversion depends on the value of the string.
+		return true
+	}
+	return false
+}
+
+// acceptsAnyValue reports whether values of type t implement custom decoding
+// which can't be checked statically.
+func acceptsAnyValue(t reflect.Type) bool {
+	return implements(t, goConvertibleFrom) || implements(t, goUnmarshaler) || implements(t, goTextUnmarshaler)
+}
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func describeType(t reflect.Type) string {
+	if ty := value.AlloyType(t); ty != value.TypeCapsule {
+		return ty.String()
+	}
+	return fmt.Sprintf("capsule(%q)", t.String())
+}
+
+func exprString(expr ast.Expr) string {
+	var sb strings.Builder
+	if err := printer.Fprint(&sb, expr); err != nil {
+		return "value"
+	}
+	return sb.String()
+}
+
+func identsString(idents []*ast.Ident) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ".")
+}
+
+func deferenceType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,221 @@
+package typecheck
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/stretchr/testify/require"
+)
+
+type MetricsReceiver interface{ AppendMetrics() }
+
+type LogsReceiver interface{ AppendLogs() }
+
+type ExprExports struct {
+	Metrics MetricsReceiver   `alloy:"metrics,attr"`
+	Logs    LogsReceiver      `alloy:"logs,attr"`
+	Names   []string          `alloy:"names,attr"`
+	Labels  map[string]string `alloy:"labels,attr"`
+	Nested  NestedExports     `alloy:"nested,attr"`
+}
+
+type NestedExports struct {
+	Count int `alloy:"count,attr"`
+}
+
+type ExprArgs struct {
+	ForwardTo []LogsReceiver          `alloy:"forward_to,attr,optional"`
+	Receiver  LogsReceiver            `alloy:"receiver,attr,optional"`
+	Name      string                  `alloy:"name,attr,optional"`
+	Count     int                     `alloy:"count,attr,optional"`
+	Enabled   bool                    `alloy:"enabled,attr,optional"`
+	Labels    map[string]string       `alloy:"labels,attr,optional"`
+	Password  alloytypes.Secret       `alloy:"password,attr,optional"`
+	Any       any                     `alloy:"any,attr,optional"`
+	Block     *ExprBlock              `alloy:"block,block,optional"`
+	Blocks    []ExprBlock             `alloy:"blocks,block,optional"`
+	Secrets   []alloytypes.Secret     `alloy:"secrets,attr,optional"`
+	Extra     map[string]LogsReceiver `alloy:"extra,attr,optional"`
+}
+
+type ExprBlock struct {
+	Receiver LogsReceiver `alloy:"receiver,attr"`
+}
+
+func TestExpressions(t *testing.T) {
+	// resolve resolves references to "comp.<label>" to ExprExports and reports
+	// references to other labels as missing.
+	resolve := func(traversal []*ast.Ident) (reflect.Type, int, diag.Diagnostics) {
+		if traversal[0].Name != "comp" || len(traversal) < 2 {
+			return nil, 0, nil
+		}
+		if traversal[1].Name != "a" {
+			return nil, 0, diag.Diagnostics{{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(traversal[0]).Position(),
+				EndPos:   ast.EndPos(traversal[1]).Position(),
+				Message:  fmt.Sprintf("component %q does not exist", "comp."+traversal[1].Name),
+			}}
+		}
+		return reflect.TypeFor[ExprExports](), 2, nil
+	}
+
+	tests := []struct {
+		desc        string
+		src         string
+		expectedErr string
+	}{
+		{
+			desc: "valid references",
+			src: `
+				forward_to = [comp.a.logs]
+				receiver   = comp.a.logs
+				name       = comp.a.names[0]
+				count      = comp.a.nested.count
+				labels     = comp.a.labels
+				any        = comp.a.metrics
+				block {
+					receiver = comp.a.logs
+				}
+				blocks {
+					receiver = comp.a.logs
+				}
+				extra = {
+					a = comp.a.logs,
+				}
+			`,
+		},
+		{
+			desc: "valid literals",
+			src: `
+				name     = 1
+				count    = "10"
+				enabled  = true
+				password = "secret"
+				secrets  = ["secret"]
+				labels   = { a = "b", c = 1 }
+				receiver = null
+			`,
+		},
+		{
+			desc: "unknown expressions are not checked",
+			src: `
+				receiver = unknown.value
+				count    = string.to_upper("a")
+				name     = [for n in comp.a.names : n]
+			`,
+		},
+		{
+			desc: "comprehension variables are not references",
+			src:  `any = [for comp in comp.a.names : comp.b if comp.c != ""]`,
+		},
+		{
+			desc:        "unresolved reference in a call",
+			src:         `any = array.concat(comp.a.names, comp.b.names)`,
+			expectedErr: `2:38: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "unresolved reference in a condition",
+			src:         `receiver = comp.b.enabled ? comp.a.logs : null`,
+			expectedErr: `2:16: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "unresolved reference in an operation",
+			src:         `count = -comp.a.nested.count + comp.b.count`,
+			expectedErr: `2:36: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "unresolved reference in a comprehension",
+			src:         `name = [for n in comp.b.names : n]`,
+			expectedErr: `2:22: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "unresolved reference in an index",
+			src:         `name = comp.a.names[comp.b.count]`,
+			expectedErr: `2:25: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "capsule mismatch",
+			src:         `receiver = comp.a.metrics`,
+			expectedErr: `2:16: comp.a.metrics should be capsule("typecheck.LogsReceiver"), got capsule("typecheck.MetricsReceiver")`,
+		},
+		{
+			desc:        "capsule mismatch in array",
+			src:         `forward_to = [comp.a.logs, comp.a.metrics]`,
+			expectedErr: `2:32: comp.a.metrics should be capsule("typecheck.LogsReceiver"), got capsule("typecheck.MetricsReceiver")`,
+		},
+		{
+			desc:        "capsule mismatch in block",
+			src:         `block { receiver = comp.a.metrics }`,
+			expectedErr: `2:24: comp.a.metrics should be capsule("typecheck.LogsReceiver"), got capsule("typecheck.MetricsReceiver")`,
+		},
+		{
+			desc:        "capsule mismatch in object",
+			src:         `extra = { a = comp.a.metrics }`,
+			expectedErr: `2:19: comp.a.metrics should be capsule("typecheck.LogsReceiver"), got capsule("typecheck.MetricsReceiver")`,
+		},
+		{
+			desc:        "capsule mismatch in conditional",
+			src:         `receiver = true ? comp.a.logs : comp.a.metrics`,
+			expectedErr: `2:37: comp.a.metrics should be capsule("typecheck.LogsReceiver"), got capsule("typecheck.MetricsReceiver")`,
+		},
+		{
+			desc:        "array assigned to capsule",
+			src:         `receiver = comp.a.names`,
+			expectedErr: `2:16: comp.a.names should be capsule("typecheck.LogsReceiver"), got array`,
+		},
+		{
+			desc:        "literal mismatch",
+			src:         `enabled = "yes"`,
+			expectedErr: `2:15: "yes" should be bool, got string`,
+		},
+		{
+			desc:        "string not holding a number",
+			src:         `count = "ten"`,
+			expectedErr: `2:13: "ten" should be number, got string`,
+		},
+		{
+			desc:        "object assigned to string",
+			src:         `name = { a = 1 }`,
+			expectedErr: `2:12: {a = 1} should be string, got object`,
+		},
+		{
+			desc:        "unknown field",
+			src:         `count = comp.a.nested.total`,
+			expectedErr: `2:27: field "total" does not exist in comp.a.nested`,
+		},
+		{
+			desc:        "unresolved reference",
+			src:         `receiver = comp.b.logs`,
+			expectedErr: `2:16: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "unresolved reference to a value that can't be checked",
+			src:         `password = comp.b.token`,
+			expectedErr: `2:16: component "comp.b" does not exist`,
+		},
+		{
+			desc:        "unknown field of a value that can't be checked",
+			src:         `any = [comp.a.nested.total]`,
+			expectedErr: `2:26: field "total" does not exist in comp.a.nested`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			file, err := parser.ParseFile("", []byte("test {\n\t\t\t\t"+tt.src+"\n}"))
+			require.NoError(t, err)
+			diags := Expressions(file.Body[0].(*ast.BlockStmt), &ExprArgs{}, resolve)
+			if tt.expectedErr == "" {
+				require.Len(t, diags, 0)
+			} else {
+				require.EqualError(t, diags, tt.expectedErr)
+			}
+		})
+	}
+}
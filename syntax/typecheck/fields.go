@@ -0,0 +1,73 @@
+package typecheck
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/internal/tagcache"
+)
+
+// Field describes an attribute or a block which can be set in the body of a
+// block decoded into a struct.
+type Field struct {
+	// Name of the attribute or block. Block names may contain several
+	// identifiers separated by periods.
+	Name string
+	// Block is true if the field is set with a block rather than an attribute.
+	Block bool
+	// Optional is true if the field doesn't have to be set.
+	Optional bool
+	// Type is the Go type of the field. For blocks, Type is the type of the
+	// block body: the element type is used for blocks which may be set
+	// multiple times, and pointers are dereferenced.
+	Type reflect.Type
+}
+
+// Fields returns the attributes and blocks which can be set in the body of a
+// block decoded into a value of type t, ordered by name. Each block of an
+// enum is returned as a separate field. Fields returns nil if t isn't a
+// struct.
+func Fields(t reflect.Type) []Field {
+	t = deferenceType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	tags := tagcache.Get(t)
+
+	fields := make([]Field, 0, len(tags.TagLookup)+len(tags.EnumLookup))
+	for name, tf := range tags.TagLookup {
+		ft := t.FieldByIndex(tf.Index).Type
+		if tf.IsBlock() {
+			ft = blockBodyType(ft)
+		}
+		fields = append(fields, Field{
+			Name:     name,
+			Block:    tf.IsBlock(),
+			Optional: tf.IsOptional(),
+			Type:     ft,
+		})
+	}
+	for name, enum := range tags.EnumLookup {
+		enumType := deferenceType(t.FieldByIndex(enum.EnumField.Index).Type.Elem())
+		fields = append(fields, Field{
+			Name:     name,
+			Block:    true,
+			Optional: true,
+			Type:     blockBodyType(enumType.FieldByIndex(enum.BlockField.Index).Type),
+		})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return strings.Compare(fields[i].Name, fields[j].Name) < 0
+	})
+	return fields
+}
+
+func blockBodyType(t reflect.Type) reflect.Type {
+	t = deferenceType(t)
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = deferenceType(t.Elem())
+	}
+	return t
+}
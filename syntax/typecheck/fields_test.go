@@ -0,0 +1,37 @@
+package typecheck
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields(t *testing.T) {
+	type field struct {
+		Name     string
+		Block    bool
+		Optional bool
+		Type     reflect.Type
+	}
+
+	var actual []field
+	for _, f := range Fields(reflect.TypeFor[*Args]()) {
+		actual = append(actual, field(f))
+	}
+
+	expected := []field{
+		{Name: "arg1", Optional: true, Type: reflect.TypeFor[string]()},
+		{Name: "arg2", Type: reflect.TypeFor[string]()},
+		{Name: "arg3", Type: reflect.TypeFor[bool]()},
+		{Name: "block1", Block: true, Type: reflect.TypeFor[Block1]()},
+		{Name: "block2", Block: true, Optional: true, Type: reflect.TypeFor[Block1]()},
+		{Name: "block3", Block: true, Optional: true, Type: reflect.TypeFor[Block1]()},
+		{Name: "block4", Block: true, Optional: true, Type: reflect.TypeFor[Block2]()},
+		{Name: "enum.block1", Block: true, Optional: true, Type: reflect.TypeFor[Block1]()},
+		{Name: "enum.block2", Block: true, Optional: true, Type: reflect.TypeFor[InnerBlock]()},
+	}
+	require.Equal(t, expected, actual)
+
+	require.Nil(t, Fields(reflect.TypeFor[map[string]string]()))
+}
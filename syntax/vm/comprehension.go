@@ -0,0 +1,125 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// evaluateComprehension evaluates an array or object comprehension. Elements
+// of the collection are bound to the iteration variables as Alloy values, so
+// secrets and capsules are passed through without being converted.
+func (vm *Evaluator) evaluateComprehension(scope *Scope, assoc map[value.Value]ast.Node, expr *ast.ComprehensionExpr) (value.Value, error) {
+	coll, err := vm.evaluateExpr(scope, assoc, expr.Collection)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys, elems, err := iterationPairs(coll)
+	if err != nil {
+		return value.Null, err
+	}
+
+	var (
+		arrayRes  = make([]value.Value, 0, len(elems))
+		objectRes = make(map[string]value.Value)
+	)
+
+	for i := range elems {
+		iterScope := &Scope{
+			Variables: map[string]interface{}{expr.ValueVar.Name: elems[i]},
+			parent:    scope,
+		}
+		if expr.KeyVar != nil {
+			iterScope.Variables[expr.KeyVar.Name] = keys[i]
+		}
+
+		if expr.Cond != nil {
+			cond, err := vm.evaluateExpr(iterScope, assoc, expr.Cond)
+			if err != nil {
+				return value.Null, err
+			}
+			if cond.Type() != value.TypeBool {
+				return value.Null, value.TypeError{Value: cond, Expected: value.TypeBool}
+			}
+			if !cond.Bool() {
+				continue
+			}
+		}
+
+		val, err := vm.evaluateExpr(iterScope, assoc, expr.Value)
+		if err != nil {
+			return value.Null, err
+		}
+
+		if !expr.Object {
+			arrayRes = append(arrayRes, val)
+			continue
+		}
+
+		key, err := vm.evaluateExpr(iterScope, assoc, expr.Key)
+		if err != nil {
+			return value.Null, err
+		}
+		if key.Type() != value.TypeString {
+			return value.Null, value.TypeError{Value: key, Expected: value.TypeString}
+		}
+		if _, exists := objectRes[key.Text()]; exists {
+			return value.Null, diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(expr.Key).Position(),
+				EndPos:   ast.EndPos(expr.Key).Position(),
+				Message:  fmt.Sprintf("duplicate key %q in object comprehension", key.Text()),
+			}
+		}
+		objectRes[key.Text()] = val
+	}
+
+	if expr.Object {
+		return value.Object(objectRes), nil
+	}
+	return value.Array(arrayRes...), nil
+}
+
+// iterationPairs returns the keys and elements to iterate over for coll.
+// Arrays are keyed by index, while objects are keyed by field name in sorted
+// order so that the iteration order is deterministic.
+func iterationPairs(coll value.Value) (keys, elems []value.Value, err error) {
+	switch coll.Type() {
+	case value.TypeArray:
+		keys = make([]value.Value, coll.Len())
+		elems = make([]value.Value, coll.Len())
+		for i := 0; i < coll.Len(); i++ {
+			keys[i] = value.Int(int64(i))
+			elems[i] = coll.Index(i)
+		}
+		return keys, elems, nil
+
+	case value.TypeObject:
+		names := coll.Keys()
+		sort.Strings(names)
+
+		keys = make([]value.Value, len(names))
+		elems = make([]value.Value, len(names))
+		for i, name := range names {
+			keys[i] = value.String(name)
+			elems[i], _ = coll.Key(name)
+		}
+		return keys, elems, nil
+
+	case value.TypeCapsule:
+		// Check if this capsule can be converted into an Alloy object to iterate
+		// over its fields.
+		if obj, ok := coll.TryConvertToObject(); ok {
+			return iterationPairs(value.Object(obj))
+		}
+	}
+
+	return nil, nil, value.Error{
+		Value: coll,
+		Inner: fmt.Errorf("should be array or object, got %s", coll.Describe()),
+	}
+}
@@ -0,0 +1,47 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// NewFunction creates a function value which can be added to the Variables of
+// a Scope and called from Alloy expressions.
+//
+// Calling the function binds each of params to the corresponding argument and
+// evaluates result against scope. Arguments are bound as Alloy values, so
+// secrets and capsules passed to the function are not converted. result may
+// only reference params and identifiers available in scope.
+//
+// Errors raised while evaluating result are reported relative to result
+// rather than to the call site.
+func NewFunction(params []string, result ast.Expr, scope *Scope) interface{} {
+	return value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		if len(args) != len(params) {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("expected %d args, got %d", len(params), len(args)),
+			}
+		}
+
+		callScope := &Scope{
+			Variables: make(map[string]interface{}, len(params)),
+			parent:    scope,
+		}
+		for i, param := range params {
+			callScope.Variables[param] = args[i]
+		}
+
+		var (
+			vm    = New(result)
+			assoc = make(map[value.Value]ast.Node)
+		)
+		res, err := vm.evaluateExpr(callScope, assoc, result)
+		if err != nil {
+			return value.Null, makeDiagnostic(err, assoc)
+		}
+		return res, nil
+	})
+}
@@ -0,0 +1,151 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFunction(t *testing.T, params []string, result string, scope *vm.Scope) interface{} {
+	t.Helper()
+
+	expr, err := parser.ParseExpression(result)
+	require.NoError(t, err)
+	return vm.NewFunction(params, expr, scope)
+}
+
+func TestVM_Function(t *testing.T) {
+	defs := vm.NewScope(map[string]interface{}{
+		"suffix": "-prod",
+	})
+	defs.Variables["with_suffix"] = newTestFunction(t, []string{"name"}, `name + suffix`, defs)
+	defs.Variables["add"] = newTestFunction(t, []string{"a", "b"}, `a + b`, defs)
+	defs.Variables["double_suffix"] = newTestFunction(t, []string{"name"}, `with_suffix(with_suffix(name))`, defs)
+	defs.Variables["upper"] = newTestFunction(t, []string{"s"}, `string.to_upper(s)`, defs)
+	defs.Variables["pick"] = newTestFunction(t, []string{"cond", "a", "b"}, `cond ? a : b`, defs)
+
+	scope := vm.NewScope(map[string]interface{}{
+		"with_suffix":   defs.Variables["with_suffix"],
+		"add":           defs.Variables["add"],
+		"double_suffix": defs.Variables["double_suffix"],
+		"upper":         defs.Variables["upper"],
+		"pick":          defs.Variables["pick"],
+		"secret":        alloytypes.Secret("password"),
+		"name":          "shadowed",
+	})
+
+	tt := []struct {
+		input  string
+		expect interface{}
+	}{
+		{`with_suffix("api")`, "api-prod"},
+		{`add(1, 2)`, 3},
+		{`double_suffix("api")`, "api-prod-prod"},
+		{`upper("api")`, "API"},
+		{`[for n in ["a", "b"] : with_suffix(n)]`, []string{"a-prod", "b-prod"}},
+		{`with_suffix(name)`, "shadowed-prod"},
+		{`pick(false, 1, 2)`, 2},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			// Evaluate into a value of the same type as the expectation.
+			switch tc.expect.(type) {
+			case string:
+				var actual string
+				require.NoError(t, eval.Evaluate(scope, &actual))
+				require.Equal(t, tc.expect, actual)
+			case int:
+				var actual int
+				require.NoError(t, eval.Evaluate(scope, &actual))
+				require.Equal(t, tc.expect, actual)
+			case []string:
+				var actual []string
+				require.NoError(t, eval.Evaluate(scope, &actual))
+				require.Equal(t, tc.expect, actual)
+			}
+		})
+	}
+}
+
+func TestVM_Function_Secrets(t *testing.T) {
+	defs := vm.NewScope(map[string]interface{}{})
+	scope := vm.NewScope(map[string]interface{}{
+		"identity": newTestFunction(t, []string{"v"}, `v`, defs),
+		"prefix":   newTestFunction(t, []string{"v"}, `"Bearer " + v`, defs),
+		"secret":   alloytypes.Secret("password"),
+	})
+
+	t.Run("Secrets are passed through", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`identity(secret)`)
+		require.NoError(t, err)
+
+		var actual alloytypes.Secret
+		require.NoError(t, vm.New(expr).Evaluate(scope, &actual))
+		require.Equal(t, alloytypes.Secret("password"), actual)
+	})
+
+	t.Run("Secrets are preserved by operations", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`prefix(secret)`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var actual alloytypes.Secret
+		require.NoError(t, eval.Evaluate(scope, &actual))
+		require.Equal(t, alloytypes.Secret("Bearer password"), actual)
+
+		var plain string
+		require.Error(t, eval.Evaluate(scope, &plain))
+	})
+}
+
+func TestVM_Function_Errors(t *testing.T) {
+	defs := vm.NewScope(map[string]interface{}{})
+	scope := vm.NewScope(map[string]interface{}{
+		"add":       newTestFunction(t, []string{"a", "b"}, `a + b`, defs),
+		"uses_args": newTestFunction(t, []string{"a"}, `a + outer`, defs),
+		"outer":     1,
+	})
+
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "wrong number of arguments",
+			input:  `add(1)`,
+			expect: `1:1: add expected 2 args, got 1`,
+		},
+		{
+			name:   "error in function body",
+			input:  `add(1, "a")`,
+			expect: `1:5: b should be number, got string`,
+		},
+		{
+			name:   "caller scope is not visible",
+			input:  `uses_args(1)`,
+			expect: `1:5: identifier "outer" does not exist`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var v interface{}
+			err = vm.New(expr).Evaluate(scope, &v)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
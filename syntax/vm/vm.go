@@ -462,6 +462,25 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 	case *ast.ParenExpr:
 		return vm.evaluateExpr(scope, assoc, expr.Inner)
 
+	case *ast.ComprehensionExpr:
+		return vm.evaluateComprehension(scope, assoc, expr)
+
+	case *ast.ConditionalExpr:
+		cond, err := vm.evaluateExpr(scope, assoc, expr.Condition)
+		if err != nil {
+			return value.Null, err
+		}
+		if cond.Type() != value.TypeBool {
+			return value.Null, value.TypeError{Value: cond, Expected: value.TypeBool}
+		}
+
+		// Only evaluate the selected branch so that the other branch may contain
+		// expressions which are invalid or expensive for the current condition.
+		if cond.Bool() {
+			return vm.evaluateExpr(scope, assoc, expr.TrueValue)
+		}
+		return vm.evaluateExpr(scope, assoc, expr.FalseValue)
+
 	case *ast.UnaryExpr:
 		val, err := vm.evaluateExpr(scope, assoc, expr.Value)
 		if err != nil {
@@ -501,6 +520,10 @@ type Scope struct {
 	// Evaluate; maps and slices will be copied by reference for performance
 	// optimizations.
 	Variables map[string]interface{}
+
+	// parent is an optional enclosing scope. It is used for scopes created
+	// during evaluation, such as the per-iteration scope of a comprehension.
+	parent *Scope
 }
 
 func NewScope(variables map[string]interface{}) *Scope {
@@ -511,8 +534,8 @@ func NewScope(variables map[string]interface{}) *Scope {
 
 // Lookup looks up a named identifier from the scope and the stdlib.
 func (s *Scope) Lookup(name string) (interface{}, bool) {
-	// Check the scope first.
-	if s != nil {
+	// Check the scope and its parents first.
+	for ; s != nil; s = s.parent {
 		if val, ok := s.Variables[name]; ok {
 			return val, true
 		}
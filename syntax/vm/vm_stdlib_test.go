@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/grafana/alloy/syntax/alloytypes"
 	"github.com/grafana/alloy/syntax/internal/value"
@@ -139,6 +140,40 @@ func TestVM_Stdlib(t *testing.T) {
 				{"a": 1, "n": 2.1}, {"a": 1, "n": 2.2}, {"a": 1, "n": 2.3},
 			},
 		},
+
+		{"array.distinct", `array.distinct([1, "a", 1, "b", "a"])`, []interface{}{1, "a", "b"}},
+		{"array.distinct numbers", `array.distinct([1, 1.0, 2])`, []interface{}{1, 2}},
+		{"array.distinct objects", `array.distinct([{"a" = 1}, {"a" = 1}, {"a" = 2}])`, []map[string]interface{}{{"a": 1}, {"a": 2}}},
+		{"array.distinct empty", `array.distinct([])`, []interface{}{}},
+		{"array.flatten", `array.flatten([1, [2, [3, [4]]], [], 5])`, []interface{}{1, 2, 3, 4, 5}},
+		{
+			"array.filter_by_label",
+			`array.filter_by_label([{"job" = "a", "id" = "1"}, {"job" = "b", "id" = "2"}, {"id" = "3"}], "job", "a")`,
+			[]map[string]interface{}{{"job": "a", "id": "1"}},
+		},
+		{"map.keys", `map.keys({"b" = 1, "a" = 2, "c" = 3})`, []string{"a", "b", "c"}},
+		{"map.values", `map.values({"b" = 1, "a" = 2, "c" = 3})`, []int{2, 1, 3}},
+		{
+			"map.merge_deep",
+			`map.merge_deep({"a" = {"x" = 1, "y" = 2}, "b" = 1}, {"a" = {"y" = 3}, "c" = 2}, null)`,
+			map[string]interface{}{"a": map[string]interface{}{"x": 1, "y": 3}, "b": 1, "c": 2},
+		},
+		{
+			// Non-object values are replaced rather than merged.
+			"map.merge_deep replace",
+			`map.merge_deep({"a" = {"x" = 1}}, {"a" = [1]})`,
+			map[string]interface{}{"a": []interface{}{1}},
+		},
+		{"map.merge_deep empty", `map.merge_deep()`, map[string]interface{}{}},
+		{"regex.match", `regex.match("api-prod-1", "^api-.*-[0-9]+$")`, true},
+		{"regex.match no match", `regex.match("web-prod", "^api-")`, false},
+		{"regex.replace", `regex.replace("api-prod-1", "-([0-9]+)$", ":$1")`, "api-prod:1"},
+		{"regex.find_all", `regex.find_all("a1b22c333", "[0-9]+")`, []string{"1", "22", "333"}},
+		{"regex.find_all no match", `regex.find_all("abc", "[0-9]+")`, []string{}},
+		{"hash.sha256", `hash.sha256("hello")`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"hash.fnv32", `hash.fnv32("hello")`, uint32(1335831723)},
+		{"time.format", `time.format("2024-03-01T10:30:00Z", "2006-01-02")`, "2024-03-01"},
+		{"time.parse_duration", `time.parse_duration("1m30s")`, float64(90)},
 	}
 
 	for _, tc := range tt {
@@ -179,6 +214,46 @@ func TestVM_Stdlib_Errors(t *testing.T) {
 			`array.combine_maps([{"a" = "a1", "b" = "b1"}], [{"a" = "a1", "c" = "b1"}], [])`,
 			`combine_maps: merge conditions must not be empty`,
 		},
+		{
+			"array.distinct",
+			`array.distinct("a")`,
+			`"a" should be array, got string`,
+		},
+		{
+			"array.filter_by_label",
+			`array.filter_by_label(["a"], "job", "a")`,
+			`"a" should be object, got string`,
+		},
+		{
+			"map.merge_deep",
+			`map.merge_deep({}, 1)`,
+			`1 should be object, got number`,
+		},
+		{
+			"map.keys",
+			`map.keys({}, {})`,
+			`expected 1 args, got 2`,
+		},
+		{
+			"regex.match",
+			`regex.match("a", "(")`,
+			"error parsing regexp: missing closing ): `(`",
+		},
+		{
+			"regex.replace",
+			`regex.replace(1, "a", "b")`,
+			`1 should be string, got number`,
+		},
+		{
+			"time.format",
+			`time.format("yesterday", "2006-01-02")`,
+			`cannot parse "yesterday"`,
+		},
+		{
+			"time.parse_duration",
+			`time.parse_duration("soon")`,
+			`invalid duration "soon"`,
+		},
 		{
 			"encoding.to_json",
 			`encoding.to_json(12)`,
@@ -294,6 +369,78 @@ func TestStdlib_Nonsensitive(t *testing.T) {
 		})
 	}
 }
+
+func TestStdlib_SensitiveArgs(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"secret":         alloytypes.Secret("token-1234"),
+		"optionalSecret": alloytypes.OptionalSecret{Value: "token-1234", IsSecret: true},
+		"optionalString": alloytypes.OptionalSecret{Value: "token-1234"},
+		"secretMap":      map[string]any{"b": alloytypes.Secret("b"), "a": "a"},
+	})
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"regex.replace secret", `regex.replace(secret, "[0-9]+", "xxxx")`, alloytypes.Secret("token-xxxx")},
+		{"regex.replace optional secret", `regex.replace(optionalSecret, "[0-9]+", "xxxx")`, alloytypes.Secret("token-xxxx")},
+		{"regex.replace optional string", `regex.replace(optionalString, "[0-9]+", "xxxx")`, "token-xxxx"},
+		{"hash.sha256 secret", `hash.sha256(secret)`, alloytypes.Secret("f4e7969805960ccee00fd6912ac55f8a83d04ae031e0c2119acf4b4166ab31e4")},
+		{"hash.sha256 optional string", `hash.sha256(optionalString)`, "f4e7969805960ccee00fd6912ac55f8a83d04ae031e0c2119acf4b4166ab31e4"},
+		{"map.values secret", `map.values(secretMap)`, []alloytypes.Secret{"a", "b"}},
+		{"array.distinct secret", `array.distinct([secret, secret])`, []alloytypes.Secret{"token-1234"}},
+		{"map.merge_deep secret", `map.merge_deep(secretMap, {"a" = "c"})["b"]`, alloytypes.Secret("b")},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+
+	t.Run("secrets are rejected by functions returning plain values", func(t *testing.T) {
+		for _, input := range []string{
+			`regex.match(secret, "[0-9]+")`,
+			`regex.find_all(secret, "[0-9]+")`,
+			`hash.fnv32(secret)`,
+		} {
+			expr, err := parser.ParseExpression(input)
+			require.NoError(t, err)
+
+			var v interface{}
+			require.Error(t, vm.New(expr).Evaluate(scope, &v), input)
+		}
+	})
+
+	t.Run("secret results can't be decoded into strings", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`hash.sha256(secret)`)
+		require.NoError(t, err)
+
+		var v string
+		require.Error(t, vm.New(expr).Evaluate(scope, &v))
+	})
+}
+
+func TestStdlib_TimeNow(t *testing.T) {
+	expr, err := parser.ParseExpression(`time.now()`)
+	require.NoError(t, err)
+
+	var now string
+	require.NoError(t, vm.New(expr).Evaluate(nil, &now))
+
+	parsed, err := time.Parse(time.RFC3339, now)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), parsed, time.Minute)
+}
+
 func TestStdlib_StringFunc(t *testing.T) {
 	scope := vm.NewScope(make(map[string]interface{}))
 
@@ -429,3 +576,20 @@ func BenchmarkConcat(b *testing.B) {
 		_ = eval.Evaluate(scope, &b)
 	}
 }
+
+func TestStdlib_DistinctCapsules(t *testing.T) {
+	var (
+		a, b = &capsuleHandle{id: 1}, &capsuleHandle{id: 1}
+		fn   = func() {}
+	)
+	scope := vm.NewScope(map[string]any{"a": a, "b": b, "fn": fn})
+
+	expr, err := parser.ParseExpression(`array.distinct([a, b, a, fn, fn])`)
+	require.NoError(t, err)
+
+	var res []any
+	require.NoError(t, vm.New(expr).Evaluate(scope, &res))
+	require.Len(t, res, 4)
+	require.Same(t, a, res[0])
+	require.Same(t, b, res[1])
+}
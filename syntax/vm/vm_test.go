@@ -6,6 +6,7 @@ import (
 	"testing"
 	"unicode"
 
+	"github.com/grafana/alloy/syntax/alloytypes"
 	"github.com/grafana/alloy/syntax/parser"
 	"github.com/grafana/alloy/syntax/scanner"
 	"github.com/grafana/alloy/syntax/token"
@@ -137,6 +138,14 @@ func TestVM_Evaluate(t *testing.T) {
 		{`!true`, bool(false)},
 		{`!false`, bool(true)},
 		{`-15`, int(-15)},
+
+		// Conditional
+		{`true ? 1 : 2`, int(1)},
+		{`false ? 1 : 2`, int(2)},
+		{`foobar == 42 ? "yes" : "no"`, string("yes")},
+		{`false ? 1 : true ? 2 : 3`, int(2)},
+		{`(true ? 1 : 2) + 10`, int(11)},
+		{`true ? [1, 2] : []`, []int{1, 2}},
 	}
 
 	for _, tc := range tt {
@@ -250,6 +259,161 @@ func TestVM_Evaluate_AccessExpr(t *testing.T) {
 	})
 }
 
+func TestVM_Evaluate_ConditionalExpr(t *testing.T) {
+	t.Run("Short-circuits unselected branch", func(t *testing.T) {
+		// Neither missing_ident nor the failing index may be evaluated.
+		expr, err := parser.ParseExpression(`true ? "ok" : [][5] + missing_ident`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var actual string
+		require.NoError(t, eval.Evaluate(nil, &actual))
+		require.Equal(t, "ok", actual)
+
+		expr, err = parser.ParseExpression(`false ? missing_ident : "ok"`)
+		require.NoError(t, err)
+
+		eval = vm.New(expr)
+		require.NoError(t, eval.Evaluate(nil, &actual))
+		require.Equal(t, "ok", actual)
+	})
+
+	t.Run("Preserves secrets", func(t *testing.T) {
+		scope := vm.NewScope(map[string]interface{}{
+			"secret": alloytypes.Secret("password"),
+		})
+
+		expr, err := parser.ParseExpression(`true ? secret : "fallback"`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var actual alloytypes.Secret
+		require.NoError(t, eval.Evaluate(scope, &actual))
+		require.Equal(t, alloytypes.Secret("password"), actual)
+
+		var plain string
+		require.Error(t, eval.Evaluate(scope, &plain))
+	})
+
+	t.Run("Non-bool condition", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`1 ? "a" : "b"`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var v interface{}
+		err = eval.Evaluate(nil, &v)
+		require.EqualError(t, err, `1:1: 1 should be bool, got number`)
+	})
+}
+
+func TestVM_Evaluate_ComprehensionExpr(t *testing.T) {
+	scope := vm.NewScope(map[string]interface{}{
+		"targets": []map[string]string{
+			{"__address__": "a:80", "job": "x"},
+			{"__address__": "b:80", "job": "y"},
+			{"__address__": "c:80", "job": "x"},
+		},
+		"labels":  map[string]string{"job": "x", "env": "prod", "zone": "a"},
+		"secrets": []alloytypes.Secret{"s1", "s2"},
+		"handles": []*capsuleHandle{{id: 1}, {id: 2}},
+	})
+
+	tt := []struct {
+		input  string
+		expect interface{}
+	}{
+		{`[for t in targets : t.__address__]`, []string{"a:80", "b:80", "c:80"}},
+		{`[for t in targets : t.__address__ if t.job == "x"]`, []string{"a:80", "c:80"}},
+		{`[for i, t in targets : i]`, []int{0, 1, 2}},
+		{`[for v in labels : v]`, []string{"prod", "x", "a"}},
+		{`[for k, v in labels : k]`, []string{"env", "job", "zone"}},
+		{`[for t in [] : t]`, []int{}},
+		{`[for a in [for b in [1, 2, 3] : b * 2] : a + 1]`, []int{3, 5, 7}},
+		{`{for k, v in labels : "l_" + k = v if k != "job"}`, map[string]string{"l_env": "prod", "l_zone": "a"}},
+		{`{for t in targets : t.__address__ = t.job}`, map[string]string{"a:80": "x", "b:80": "y", "c:80": "x"}},
+		{`{for for in [1] : "for" = for}`, map[string]int{"for": 1}},
+	}
+
+	for _, tc := range tt {
+		t.Run(trimWhitespace(tc.input), func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			vPtr := reflect.New(reflect.TypeOf(tc.expect)).Interface()
+			require.NoError(t, eval.Evaluate(scope, vPtr))
+
+			actual := reflect.ValueOf(vPtr).Elem().Interface()
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+
+	t.Run("Preserves secrets", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`[for s in secrets : s + "_suffix"]`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var actual []alloytypes.Secret
+		require.NoError(t, eval.Evaluate(scope, &actual))
+		require.Equal(t, []alloytypes.Secret{"s1_suffix", "s2_suffix"}, actual)
+
+		var plain []string
+		require.Error(t, eval.Evaluate(scope, &plain))
+	})
+
+	t.Run("Preserves capsules", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`[for h in handles : h]`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var actual []*capsuleHandle
+		require.NoError(t, eval.Evaluate(scope, &actual))
+		require.Len(t, actual, 2)
+		require.Same(t, scope.Variables["handles"].([]*capsuleHandle)[1], actual[1])
+	})
+
+	t.Run("Iteration variables are not visible outside", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`[for t in targets : t] + t`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var v interface{}
+		require.EqualError(t, eval.Evaluate(scope, &v), `1:26: identifier "t" does not exist`)
+	})
+
+	errorTests := []struct {
+		input  string
+		expect string
+	}{
+		{`[for t in 5 : t]`, `1:11: 5 should be array or object, got number`},
+		{`[for t in [1] : t if t]`, `1:22: t should be bool, got number`},
+		{`{for t in [1] : t = t}`, `1:17: t should be string, got number`},
+		{`{for t in ["a", "a"] : t = t}`, `1:24: duplicate key "a" in object comprehension`},
+	}
+	for _, tc := range errorTests {
+		t.Run(tc.input, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			require.EqualError(t, eval.Evaluate(scope, &v), tc.expect)
+		})
+	}
+}
+
+type capsuleHandle struct{ id int }
+
+func (*capsuleHandle) AlloyCapsule() {}
+
 func trimWhitespace(in string) string {
 	f := token.NewFile("")
 